@@ -2,71 +2,296 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
 
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/auth"
 	"github.com/chameleon-db/chameleon-examples/todo-app/internal/config"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/passwordreset"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/session"
 	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/todo"
 	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/user"
 	"github.com/chameleon-db/chameleon-examples/todo-app/internal/handler"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/health"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/logging"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/mail"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/metrics"
+	appMiddleware "github.com/chameleon-db/chameleon-examples/todo-app/internal/middleware"
 	"github.com/chameleon-db/chameleon-examples/todo-app/internal/repository"
 	"github.com/chameleon-db/chameleon-examples/todo-app/internal/router"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/tracing"
 
 	"github.com/chameleon-db/chameleondb/chameleon/pkg/engine"
 )
 
 func main() {
-	// Load configuration
-	cfg := config.Load()
+	// Load configuration: defaults, YAML file, env, then flags
+	cfg, opts, err := config.Load(os.Args[1:], os.LookupEnv)
+	if errors.Is(err, flag.ErrHelp) {
+		config.Usage(os.Stdout)
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	if opts.PrintConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 
-	log.Printf("Starting Todo App on port %d", cfg.Port)
+	logger, err := logging.New(os.Stderr, cfg.LogLevel, cfg.LogFormat)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// Route the standard log package through it too
+	slog.SetDefault(logger)
+
+	if err := run(cfg, logger); err != nil {
+		logger.Error("Server failed", "error", err)
+		os.Exit(1)
+	}
+}
+
+// run starts the API and blocks until SIGINT or SIGTERM, then drains
+// in-flight requests and closes the database pool, in that order
+func run(cfg *config.Config, logger *slog.Logger) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	logger.Info("Starting Todo App", "port", cfg.Port, "log_level", cfg.LogLevel)
+
+	// Initialize tracing
+	shutdownTracing, err := tracing.Setup(tracing.Config{
+		Exporter:    cfg.TraceExporter,
+		File:        cfg.TraceFile,
+		ServiceName: cfg.TraceServiceName,
+		SampleRatio: cfg.TraceSampleRatio,
+	})
+	if err != nil {
+		return err
+	}
+	// Deferred before the engine and server so buffered spans are flushed last
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logger.Warn("Failed to flush traces", "error", err)
+		}
+	}()
+
+	// Initialize mailer
+	mailer, closeMailer, err := mail.New(mail.Config{
+		Transport: cfg.MailTransport,
+		From:      cfg.MailFrom,
+		File:      cfg.MailFile,
+		SMTP: mail.SMTPConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+		},
+	}, logger)
+	if err != nil {
+		return err
+	}
+	defer closeMailer()
+	if cfg.MailTransport == mail.TransportLog {
+		logger.Warn("mail.transport (MAIL_TRANSPORT) is log, emails are logged instead of sent")
+	}
 
 	// Initialize database engine
-	log.Println("Initializing database engine...")
+	logger.Info("Initializing database engine")
 	eng, err := engine.NewEngine()
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+
+	// Engine SQL logging is only on at debug level
+	if logger.Enabled(context.Background(), slog.LevelDebug) {
+		eng.Debug = &engine.DebugContext{Level: engine.DebugSQL, Writer: os.Stderr}
+	}
+
+	// Fail fast if schemas/*.cham drifted from the typed domain models
+	if err := repository.VerifySchema(eng.Schema()); err != nil {
+		return err
 	}
-	defer eng.Close()
 
 	// Connect to database
-	ctx := context.Background()
 	dbConfig, err := engine.ParseConnectionString(cfg.DatabaseURL)
 	if err != nil {
-		log.Fatalf("Failed to parse database URL: %v", err)
+		return fmt.Errorf("failed to parse database URL: %w", err)
 	}
 
-	if err := eng.Connect(ctx, dbConfig); err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+	if err := connectDatabase(ctx, eng, dbConfig, cfg, logger); err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
 	}
+	// Deferred first so it runs last, once the server has drained
 	defer eng.Close()
-	log.Println("Database connected successfully")
+	logger.Info("Database connected")
+
+	// Initialize metrics
+	registry := metrics.NewRegistry()
+	repository.SetObserver(metrics.NewDB(registry))
+	repository.RegisterPoolMetrics(registry, eng)
 
 	// Initialize repositories
-	log.Println("Initializing repositories...")
+	logger.Info("Initializing repositories")
 	userRepo := repository.NewUserRepository(eng)
 	todoRepo := repository.NewTodoRepository(eng)
+	sessionRepo := repository.NewSessionRepository(eng)
+	passwordResetRepo := repository.NewPasswordResetRepository(eng)
+	uow := repository.NewUnitOfWork(eng)
 
-	// Initialize domain services
-	log.Println("Initializing domain services...")
-	var userService user.Service = user.NewService(userRepo)
-	var todoService todo.Service = todo.NewService(todoRepo)
+	// Initialize domain services, authorizing every call by the caller's role
+	logger.Info("Initializing domain services")
+	var userService user.Service = tracing.UserService(user.Authorize(user.NewService(userRepo, sessionRepo, passwordResetRepo, uow)))
+	var todoService todo.Service = tracing.TodoService(todo.Authorize(todo.NewService(todoRepo, userRepo, uow)))
+	var sessionService session.Service = tracing.SessionService(session.Authorize(session.NewService(sessionRepo, userRepo, cfg.RefreshTokenTTL)))
+	var passwordResetService passwordreset.Service = tracing.PasswordResetService(
+		passwordreset.NewService(passwordResetRepo, userRepo, sessionRepo, uow, mailer, cfg.PasswordResetTTL, cfg.PasswordResetURL, logger),
+	)
+
+	// Initialize token manager
+	secret := []byte(cfg.JWTSecret)
+	if len(secret) == 0 {
+		logger.Warn("auth.jwt_secret (JWT_SECRET) not set, using a random secret (tokens will not survive restarts)")
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return fmt.Errorf("failed to generate JWT secret: %w", err)
+		}
+	}
+	tokens := auth.NewTokenManager(secret, cfg.AccessTokenTTL)
+
+	// Initialize readiness checks
+	checker := health.NewChecker(cfg.ReadinessTimeout)
+	checker.Add("database", repository.DatabaseCheck(eng))
+	checker.Add("schema", health.SchemaCheck(
+		filepath.Join(cfg.ChameleonStateDir, "schema.merged.cham"),
+		cfg.ChameleonStateDir,
+	))
 
 	// Initialize handlers
-	log.Println("Initializing handlers...")
-	userHandler := handler.NewUserHandler(userService)
+	logger.Info("Initializing handlers")
+	userHandler := handler.NewUserHandler(userService, sessionService, tokens)
 	todoHandler := handler.NewTodoHandler(todoService)
+	sessionHandler := handler.NewSessionHandler(sessionService, tokens)
+	passwordResetHandler := handler.NewPasswordResetHandler(passwordResetService)
+	healthHandler := handler.NewHealthHandler(checker)
 
 	// Create router
-	log.Println("Creating router...")
-	r := router.New(userHandler, todoHandler)
+	logger.Info("Creating router")
+	cors := appMiddleware.CORSPolicy{
+		AllowedOrigins:   cfg.CORSAllowedOrigins,
+		AllowedMethods:   cfg.CORSAllowedMethods,
+		AllowedHeaders:   cfg.CORSAllowedHeaders,
+		ExposedHeaders:   cfg.CORSExposedHeaders,
+		AllowCredentials: cfg.CORSAllowCredentials,
+		MaxAge:           cfg.CORSMaxAge,
+	}
+	r := router.New(userHandler, todoHandler, sessionHandler, passwordResetHandler, healthHandler, tokens, logger, registry, cors)
 
 	// Start HTTP server
-	addr := fmt.Sprintf(":%d", cfg.Port)
-	log.Printf("Server listening on %s", addr)
+	srv := &http.Server{
+		Addr:              fmt.Sprintf(":%d", cfg.Port),
+		Handler:           r,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
+	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		logger.Info("Server listening", "addr", srv.Addr)
+		serverErr <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		return fmt.Errorf("server error: %w", err)
+	case <-ctx.Done():
+	}
+
+	// Restore default signal handling: a second signal kills the process
+	stop()
+	logger.Info("Shutting down, draining requests", "timeout", cfg.ShutdownTimeout)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		srv.Close()
+		return fmt.Errorf("graceful shutdown failed: %w", err)
+	}
+	if err := <-serverErr; !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("server error: %w", err)
+	}
+
+	// Reset emails outlive their requests; let them finish
+	passwordResetService.Wait()
+
+	logger.Info("Server stopped")
+	return nil
+}
+
+// connectDatabase connects eng and pings the database, retrying with
+// exponential backoff so a database that is still starting is waited for
+// while one that never comes up fails startup
+func connectDatabase(ctx context.Context, eng *engine.Engine, dbConfig engine.ConnectorConfig, cfg *config.Config, logger *slog.Logger) error {
+	backoff := cfg.DBConnectBackoff
+
+	for attempt := 1; ; attempt++ {
+		err := pingDatabase(ctx, eng, dbConfig, cfg.DBConnectTimeout)
+		if err == nil {
+			return nil
+		}
+
+		if attempt >= cfg.DBConnectAttempts {
+			return fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+		}
+
+		logger.Warn("Database not reachable, retrying",
+			"attempt", attempt, "attempts", cfg.DBConnectAttempts, "error", err, "backoff", backoff)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, cfg.DBConnectMaxBackoff)
+	}
+}
 
-	if err := http.ListenAndServe(addr, r); err != nil {
-		log.Fatalf("Server error: %v", err)
+// pingDatabase makes one connection attempt. The pool connects lazily, so
+// only the ping proves the database is reachable.
+func pingDatabase(ctx context.Context, eng *engine.Engine, dbConfig engine.ConnectorConfig, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if err := eng.Connect(ctx, dbConfig); err != nil {
+		return err
 	}
+
+	if err := eng.Ping(ctx); err != nil {
+		eng.Close()
+		return err
+	}
+
+	return nil
 }
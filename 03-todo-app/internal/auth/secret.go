@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Opaque tokens, such as refresh and password reset tokens, are the ID of
+// the row that stores them and a random secret: "<id>.<secret>". Only the
+// secret's hash is stored, and the ID finds the row to compare it with.
+
+// NewSecret returns a random token secret and the hash it is stored as
+func NewSecret() (secret, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+
+	secret = base64.RawURLEncoding.EncodeToString(b)
+	return secret, HashSecret(secret), nil
+}
+
+// HashSecret returns the stored form of a token secret. Secrets are
+// random, so a fast hash is enough to make a leaked table useless.
+func HashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// SecretMatches reports, in constant time, whether hash is the stored
+// form of the same secret as stored
+func SecretMatches(hash, stored string) bool {
+	return subtle.ConstantTimeCompare([]byte(hash), []byte(stored)) == 1
+}
+
+// FormatToken returns the token handed to the client for the row id
+func FormatToken(id uuid.UUID, secret string) string {
+	return id.String() + "." + secret
+}
+
+// ParseToken splits a token made by FormatToken into its row ID and secret
+func ParseToken(token string) (id, secret string, ok bool) {
+	id, secret, ok = strings.Cut(token, ".")
+	if !ok || secret == "" {
+		return "", "", false
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return "", "", false
+	}
+	return id, secret, true
+}
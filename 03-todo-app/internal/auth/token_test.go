@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/authz"
+)
+
+func TestTokenRoundTrip(t *testing.T) {
+	m := NewTokenManager([]byte("secret"), time.Minute)
+
+	token, expiresAt, err := m.Issue("user-1", authz.RoleAdmin)
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+
+	if !expiresAt.After(time.Now()) {
+		t.Errorf("Expected expiry in the future, got %v", expiresAt)
+	}
+
+	claims, err := m.Verify(token)
+	if err != nil {
+		t.Fatalf("Expected valid token, got %v", err)
+	}
+
+	if claims.Subject != "user-1" || claims.Role != authz.RoleAdmin {
+		t.Errorf("Expected subject user-1 with role admin, got %s, %s", claims.Subject, claims.Role)
+	}
+}
+
+func TestTokenRejectsTampering(t *testing.T) {
+	m := NewTokenManager([]byte("secret"), time.Minute)
+	token, _, _ := m.Issue("user-1", authz.RoleUser)
+
+	other := NewTokenManager([]byte("other-secret"), time.Minute)
+	if _, err := other.Verify(token); err != ErrInvalidToken {
+		t.Errorf("Expected ErrInvalidToken for wrong secret, got %v", err)
+	}
+
+	if _, err := m.Verify(token + "x"); err != ErrInvalidToken {
+		t.Errorf("Expected ErrInvalidToken for modified signature, got %v", err)
+	}
+
+	if _, err := m.Verify("not-a-token"); err != ErrInvalidToken {
+		t.Errorf("Expected ErrInvalidToken for garbage, got %v", err)
+	}
+}
+
+func TestTokenExpiry(t *testing.T) {
+	m := NewTokenManager([]byte("secret"), time.Minute)
+	token, _, _ := m.Issue("user-1", authz.RoleUser)
+
+	m.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	if _, err := m.Verify(token); err != ErrExpiredToken {
+		t.Errorf("Expected ErrExpiredToken, got %v", err)
+	}
+}
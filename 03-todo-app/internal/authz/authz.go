@@ -0,0 +1,73 @@
+// Package authz decides what an authenticated caller may do: their role,
+// carried in the request context, and the policies the domain services
+// check it against
+package authz
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Role is a user's privilege level (the role column of schemas/user.cham)
+type Role string
+
+const (
+	// RoleUser may act on their own account and todos
+	RoleUser Role = "user"
+
+	// RoleAdmin may also list, deactivate and restore users, and act on
+	// anyone's account and todos
+	RoleAdmin Role = "admin"
+)
+
+// ParseRole parses user or admin, in any case
+func ParseRole(s string) (Role, error) {
+	switch role := Role(strings.ToLower(strings.TrimSpace(s))); role {
+	case RoleUser, RoleAdmin:
+		return role, nil
+	default:
+		return "", fmt.Errorf("invalid role %q: want user or admin", s)
+	}
+}
+
+// Principal is the authenticated caller
+type Principal struct {
+	UserID string
+	Role   Role
+}
+
+// contextKey is unexported to avoid collisions with other packages
+type contextKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying the authenticated caller
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// PrincipalFromContext returns the authenticated caller, if any
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(contextKey{}).(Principal)
+	return p, ok && p.UserID != ""
+}
+
+// Policy decides whether p may perform an operation on the resources of
+// the user ownerID
+type Policy func(p Principal, ownerID string) bool
+
+// AdminOnly allows admins
+func AdminOnly(p Principal, _ string) bool {
+	return p.Role == RoleAdmin
+}
+
+// SelfOrAdmin allows the owner and admins
+func SelfOrAdmin(p Principal, ownerID string) bool {
+	return p.Role == RoleAdmin || strings.EqualFold(p.UserID, ownerID)
+}
+
+// Allow reports whether the caller in ctx passes policy for ownerID. A
+// context without a caller is denied.
+func Allow(ctx context.Context, policy Policy, ownerID string) bool {
+	p, ok := PrincipalFromContext(ctx)
+	return ok && policy(p, ownerID)
+}
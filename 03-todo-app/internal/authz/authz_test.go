@@ -0,0 +1,53 @@
+package authz
+
+import (
+	"context"
+	"testing"
+)
+
+func TestParseRole(t *testing.T) {
+	tests := map[string]Role{"user": RoleUser, "Admin": RoleAdmin, " admin ": RoleAdmin}
+
+	for in, want := range tests {
+		got, err := ParseRole(in)
+		if err != nil || got != want {
+			t.Errorf("ParseRole(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+
+	for _, in := range []string{"", "root"} {
+		if _, err := ParseRole(in); err == nil {
+			t.Errorf("Expected an error for role %q", in)
+		}
+	}
+}
+
+func TestAllow(t *testing.T) {
+	const self, other = "8d2c6a52-0000-4000-8000-000000000001", "8d2c6a52-0000-4000-8000-000000000002"
+
+	user := WithPrincipal(context.Background(), Principal{UserID: self, Role: RoleUser})
+	admin := WithPrincipal(context.Background(), Principal{UserID: self, Role: RoleAdmin})
+	anonymous := context.Background()
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		policy Policy
+		owner  string
+		want   bool
+	}{
+		{"user on self", user, SelfOrAdmin, self, true},
+		{"user on self, upper case", user, SelfOrAdmin, "8D2C6A52-0000-4000-8000-000000000001", true},
+		{"user on other", user, SelfOrAdmin, other, false},
+		{"admin on other", admin, SelfOrAdmin, other, true},
+		{"user on admin-only", user, AdminOnly, self, false},
+		{"admin on admin-only", admin, AdminOnly, other, true},
+		{"anonymous", anonymous, SelfOrAdmin, self, false},
+	}
+
+	for _, tt := range tests {
+		if got := Allow(tt.ctx, tt.policy, tt.owner); got != tt.want {
+			t.Errorf("%s: Allow = %t, want %t", tt.name, got, tt.want)
+		}
+	}
+}
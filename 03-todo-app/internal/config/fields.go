@@ -0,0 +1,215 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// field is one setting, addressed as key in the YAML file (and as
+// --key on the command line) and as env in the environment
+type field struct {
+	key    string
+	env    string
+	value  value
+	secret bool // may be read from a file; redacted when printed
+}
+
+// value parses a setting into its Config field. It is a flag.Value.
+type value interface {
+	Set(s string) error
+	String() string
+	// tag is the YAML tag the value prints with, or empty to let YAML
+	// infer it
+	tag() string
+}
+
+// fields lists every setting of cfg, in the order they are printed
+func fields(cfg *Config) []field {
+	return []field{
+		{key: "server.port", env: "PORT", value: (*portValue)(&cfg.Port)},
+		{key: "server.read_header_timeout", env: "HTTP_READ_HEADER_TIMEOUT", value: (*durationValue)(&cfg.ReadHeaderTimeout)},
+		{key: "server.read_timeout", env: "HTTP_READ_TIMEOUT", value: (*durationValue)(&cfg.ReadTimeout)},
+		{key: "server.write_timeout", env: "HTTP_WRITE_TIMEOUT", value: (*durationValue)(&cfg.WriteTimeout)},
+		{key: "server.idle_timeout", env: "HTTP_IDLE_TIMEOUT", value: (*durationValue)(&cfg.IdleTimeout)},
+		{key: "server.shutdown_timeout", env: "SHUTDOWN_TIMEOUT", value: (*durationValue)(&cfg.ShutdownTimeout)},
+
+		{key: "database.url", env: "DATABASE_URL", value: (*urlValue)(&cfg.DatabaseURL), secret: true},
+		{key: "database.connect_attempts", env: "DB_CONNECT_ATTEMPTS", value: (*intValue)(&cfg.DBConnectAttempts)},
+		{key: "database.connect_timeout", env: "DB_CONNECT_TIMEOUT", value: (*durationValue)(&cfg.DBConnectTimeout)},
+		{key: "database.connect_backoff", env: "DB_CONNECT_BACKOFF", value: (*durationValue)(&cfg.DBConnectBackoff)},
+		{key: "database.connect_max_backoff", env: "DB_CONNECT_MAX_BACKOFF", value: (*durationValue)(&cfg.DBConnectMaxBackoff)},
+
+		{key: "log.level", env: "LOG_LEVEL", value: (*stringValue)(&cfg.LogLevel)},
+		{key: "log.format", env: "LOG_FORMAT", value: (*stringValue)(&cfg.LogFormat)},
+
+		{key: "auth.jwt_secret", env: "JWT_SECRET", value: (*stringValue)(&cfg.JWTSecret), secret: true},
+		{key: "auth.access_token_ttl", env: "ACCESS_TOKEN_TTL", value: (*durationValue)(&cfg.AccessTokenTTL)},
+		{key: "auth.refresh_token_ttl", env: "REFRESH_TOKEN_TTL", value: (*durationValue)(&cfg.RefreshTokenTTL)},
+		{key: "auth.password_reset_ttl", env: "PASSWORD_RESET_TTL", value: (*durationValue)(&cfg.PasswordResetTTL)},
+		{key: "auth.password_reset_url", env: "PASSWORD_RESET_URL", value: (*stringValue)(&cfg.PasswordResetURL)},
+
+		{key: "cors.allowed_origins", env: "CORS_ALLOWED_ORIGINS", value: (*listValue)(&cfg.CORSAllowedOrigins)},
+		{key: "cors.allowed_methods", env: "CORS_ALLOWED_METHODS", value: (*listValue)(&cfg.CORSAllowedMethods)},
+		{key: "cors.allowed_headers", env: "CORS_ALLOWED_HEADERS", value: (*listValue)(&cfg.CORSAllowedHeaders)},
+		{key: "cors.exposed_headers", env: "CORS_EXPOSED_HEADERS", value: (*listValue)(&cfg.CORSExposedHeaders)},
+		{key: "cors.allow_credentials", env: "CORS_ALLOW_CREDENTIALS", value: (*boolValue)(&cfg.CORSAllowCredentials)},
+		{key: "cors.max_age", env: "CORS_MAX_AGE", value: (*durationValue)(&cfg.CORSMaxAge)},
+
+		{key: "tracing.exporter", env: "TRACE_EXPORTER", value: (*stringValue)(&cfg.TraceExporter)},
+		{key: "tracing.file", env: "TRACE_FILE", value: (*stringValue)(&cfg.TraceFile)},
+		{key: "tracing.sample_ratio", env: "TRACE_SAMPLE_RATIO", value: (*floatValue)(&cfg.TraceSampleRatio)},
+		{key: "tracing.service_name", env: "OTEL_SERVICE_NAME", value: (*stringValue)(&cfg.TraceServiceName)},
+
+		{key: "mail.transport", env: "MAIL_TRANSPORT", value: (*stringValue)(&cfg.MailTransport)},
+		{key: "mail.from", env: "MAIL_FROM", value: (*stringValue)(&cfg.MailFrom)},
+		{key: "mail.file", env: "MAIL_FILE", value: (*stringValue)(&cfg.MailFile)},
+		{key: "mail.smtp_host", env: "SMTP_HOST", value: (*stringValue)(&cfg.SMTPHost)},
+		{key: "mail.smtp_port", env: "SMTP_PORT", value: (*portValue)(&cfg.SMTPPort)},
+		{key: "mail.smtp_username", env: "SMTP_USERNAME", value: (*stringValue)(&cfg.SMTPUsername)},
+		{key: "mail.smtp_password", env: "SMTP_PASSWORD", value: (*stringValue)(&cfg.SMTPPassword), secret: true},
+
+		{key: "readiness.timeout", env: "READINESS_TIMEOUT", value: (*durationValue)(&cfg.ReadinessTimeout)},
+
+		{key: "chameleon.config", env: "CHAMELEON_CONFIG", value: (*stringValue)(&cfg.ChameleonConfig)},
+		{key: "chameleon.state_dir", env: "CHAMELEON_STATE_DIR", value: (*stringValue)(&cfg.ChameleonStateDir)},
+	}
+}
+
+// redacted is what secrets print as
+const redacted = "[REDACTED]"
+
+// printed returns the value as --print-config shows it
+func (f field) printed() string {
+	if !f.secret {
+		return f.value.String()
+	}
+	if r, ok := f.value.(redactor); ok {
+		return r.redacted()
+	}
+	if f.value.String() == "" {
+		return ""
+	}
+	return redacted
+}
+
+// redactor is a secret that can be shown in part, like a URL's host
+type redactor interface {
+	redacted() string
+}
+
+type stringValue string
+
+func (v *stringValue) Set(s string) error { *v = stringValue(s); return nil }
+func (v *stringValue) String() string     { return string(*v) }
+func (v *stringValue) tag() string        { return "!!str" }
+
+type intValue int
+
+func (v *intValue) Set(s string) error {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return fmt.Errorf("%q is not an integer", s)
+	}
+	*v = intValue(n)
+	return nil
+}
+func (v *intValue) String() string { return strconv.Itoa(int(*v)) }
+func (v *intValue) tag() string    { return "" }
+
+// portValue is an intValue that names what it expects
+type portValue int
+
+func (v *portValue) Set(s string) error {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return fmt.Errorf("%q is not a port number", s)
+	}
+	*v = portValue(n)
+	return nil
+}
+func (v *portValue) String() string { return strconv.Itoa(int(*v)) }
+func (v *portValue) tag() string    { return "" }
+
+type floatValue float64
+
+func (v *floatValue) Set(s string) error {
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return fmt.Errorf("%q is not a number", s)
+	}
+	*v = floatValue(f)
+	return nil
+}
+func (v *floatValue) String() string { return strconv.FormatFloat(float64(*v), 'g', -1, 64) }
+func (v *floatValue) tag() string    { return "" }
+
+type boolValue bool
+
+func (v *boolValue) Set(s string) error {
+	b, err := strconv.ParseBool(strings.TrimSpace(s))
+	if err != nil {
+		return fmt.Errorf("%q is not a boolean (true or false)", s)
+	}
+	*v = boolValue(b)
+	return nil
+}
+func (v *boolValue) String() string   { return strconv.FormatBool(bool(*v)) }
+func (v *boolValue) tag() string      { return "" }
+func (v *boolValue) IsBoolFlag() bool { return true }
+
+type durationValue time.Duration
+
+func (v *durationValue) Set(s string) error {
+	d, err := time.ParseDuration(strings.TrimSpace(s))
+	if err != nil {
+		return fmt.Errorf("%q is not a duration (e.g. 500ms, 30s, 5m)", s)
+	}
+	*v = durationValue(d)
+	return nil
+}
+func (v *durationValue) String() string { return time.Duration(*v).String() }
+func (v *durationValue) tag() string    { return "!!str" }
+
+// listValue is comma-separated in the environment and on the command
+// line, and a YAML sequence in the file. Blank entries are dropped.
+type listValue []string
+
+func (v *listValue) Set(s string) error {
+	return v.setList(strings.Split(s, ","))
+}
+func (v *listValue) setList(items []string) error {
+	values := []string{}
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	*v = values
+	return nil
+}
+func (v *listValue) String() string { return strings.Join(*v, ",") }
+func (v *listValue) tag() string    { return "!!seq" }
+
+// urlValue is a connection string whose password is redacted
+type urlValue string
+
+func (v *urlValue) Set(s string) error { *v = urlValue(strings.TrimSpace(s)); return nil }
+func (v *urlValue) String() string     { return string(*v) }
+func (v *urlValue) tag() string        { return "!!str" }
+
+// redacted hides the password, whether in the userinfo or, as libpq also
+// accepts, in the query string
+func (v *urlValue) redacted() string {
+	u, err := url.Parse(string(*v))
+	if err != nil {
+		return redacted
+	}
+	if q := u.Query(); q.Has("password") {
+		q.Set("password", "xxxxx")
+		u.RawQuery = q.Encode()
+	}
+	return u.Redacted()
+}
@@ -0,0 +1,197 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// loadFile applies the YAML file at path, or DefaultFile when path is
+// empty. Settings are nested by the dotted parts of their key:
+//
+//	server:
+//	  port: 8080
+//	database:
+//	  url: ${DATABASE_URL}
+//
+// Unknown keys are rejected, so a typo is not silently ignored.
+func (l *loader) loadFile(path string) {
+	explicit := path != ""
+	if !explicit {
+		path = DefaultFile
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && !explicit {
+		return
+	}
+	if err != nil {
+		l.problem("%v", err)
+		return
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		l.problem("%s: %v", path, err)
+		return
+	}
+	if len(doc.Content) == 0 {
+		return // empty file
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		l.problem("%s:%d: expected a mapping of settings", path, root.Line)
+		return
+	}
+	l.walk(path, root, "")
+}
+
+// walk applies the settings under node, whose keys are prefixed by prefix
+func (l *loader) walk(path string, node *yaml.Node, prefix string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		k, v := node.Content[i], node.Content[i+1]
+		key := prefix + k.Value
+		source := fmt.Sprintf("%s:%d", path, k.Line)
+
+		if v.Kind == yaml.MappingNode {
+			l.walk(path, v, key+".")
+			continue
+		}
+		if v.Tag == "!!null" {
+			continue
+		}
+
+		f, ok := l.field(key)
+		fromFile := false
+		if !ok {
+			f, fromFile = l.secretFile(key)
+			ok = fromFile
+		}
+		if !ok {
+			l.problem("%s: unknown setting %q", source, key)
+			continue
+		}
+
+		if f.secret && strings.HasPrefix(l.sources[f.key], path+":") {
+			l.problem("%s (%s): both %s and %s_file are set", f.key, source, f.key, f.key)
+			continue
+		}
+
+		if !fromFile {
+			l.setNode(f, v, source)
+		} else if file, ok := l.scalar(f, v, source); ok {
+			l.setFromFile(f, file, source)
+		}
+	}
+}
+
+// setNode applies a YAML value: a sequence for lists, a scalar otherwise
+func (l *loader) setNode(f field, v *yaml.Node, source string) {
+	if list, ok := f.value.(*listValue); ok && v.Kind == yaml.SequenceNode {
+		items := make([]string, 0, len(v.Content))
+		for _, item := range v.Content {
+			raw, ok := l.scalar(f, item, source)
+			if !ok {
+				return
+			}
+			items = append(items, raw)
+		}
+		list.setList(items)
+		l.record(f, source)
+		return
+	}
+
+	if raw, ok := l.scalar(f, v, source); ok {
+		l.set(f, raw, source)
+	}
+}
+
+// scalar returns the expanded text of a scalar node
+func (l *loader) scalar(f field, v *yaml.Node, source string) (string, bool) {
+	if v.Kind != yaml.ScalarNode {
+		l.problem("%s (%s): expected a single value", f.key, source)
+		return "", false
+	}
+
+	raw, err := expand(v.Value, l.lookupEnv)
+	if err != nil {
+		l.problem("%s (%s): %v", f.key, source, err)
+		return "", false
+	}
+	return raw, true
+}
+
+// envRef matches ${VAR} and ${VAR:-default}
+var envRef = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// expand replaces ${VAR} with the variable's value, as .chameleon.yml
+// does, or with default in ${VAR:-default} when VAR is unset or empty. A
+// bare $ is left alone, so passwords need no escaping.
+func expand(s string, lookupEnv func(string) (string, bool)) (string, error) {
+	var missing []string
+
+	out := envRef.ReplaceAllStringFunc(s, func(ref string) string {
+		m := envRef.FindStringSubmatch(ref)
+		if v, ok := lookupEnv(m[1]); ok && v != "" {
+			return v
+		}
+		if m[2] != "" {
+			return m[3]
+		}
+		missing = append(missing, m[1])
+		return ""
+	})
+
+	if len(missing) > 0 {
+		return "", fmt.Errorf("${%s} is not set", strings.Join(missing, "}, ${"))
+	}
+	return out, nil
+}
+
+// chameleonFile is the part of .chameleon.yml the API shares
+type chameleonFile struct {
+	Database struct {
+		ConnectionString string `yaml:"connection_string"`
+	} `yaml:"database"`
+}
+
+// loadChameleon falls back to the connection string in .chameleon.yml when
+// no layer set database.url. A reference to an unset variable there
+// leaves the default in place, as the file is also read by the CLI.
+func (l *loader) loadChameleon() {
+	if _, ok := l.sources["database.url"]; ok {
+		return
+	}
+
+	path := l.cfg.ChameleonConfig
+	_, explicit := l.sources["chameleon.config"]
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && !explicit {
+		return
+	}
+	if err != nil {
+		l.problem("chameleon.config: %v", err)
+		return
+	}
+
+	var file chameleonFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		l.problem("%s: %v", path, err)
+		return
+	}
+
+	url, err := expand(file.Database.ConnectionString, l.lookupEnv)
+	if err != nil || url == "" {
+		return
+	}
+
+	f, _ := l.field("database.url")
+	l.set(f, url, path)
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Print writes the configuration to w as a YAML file that Load accepts,
+// with secrets redacted and each setting that is not a default commented
+// with where it came from
+func (c *Config) Print(w io.Writer) error {
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	sections := make(map[string]*yaml.Node)
+
+	for _, f := range fields(c) {
+		section, name, _ := strings.Cut(f.key, ".")
+
+		parent, ok := sections[section]
+		if !ok {
+			parent = &yaml.Node{Kind: yaml.MappingNode}
+			sections[section] = parent
+			root.Content = append(root.Content, scalarNode("!!str", section), parent)
+		}
+
+		key := scalarNode("!!str", name)
+		val := valueNode(f)
+		if source, ok := c.sources[f.key]; ok {
+			val.LineComment = source
+		}
+		parent.Content = append(parent.Content, key, val)
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(root); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func scalarNode(tag, value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: value}
+}
+
+func valueNode(f field) *yaml.Node {
+	list, ok := f.value.(*listValue)
+	if !ok {
+		return scalarNode(f.value.tag(), f.printed())
+	}
+
+	seq := &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle}
+	for _, item := range *list {
+		seq.Content = append(seq.Content, scalarNode("!!str", item))
+	}
+	return seq
+}
@@ -0,0 +1,123 @@
+package config
+
+import (
+	"fmt"
+	netmail "net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/logging"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/mail"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/tracing"
+)
+
+// Validate checks every setting, reporting all problems in one *Error.
+// Each problem names the setting and, when not a default, where it was set.
+func (c *Config) Validate() error {
+	var problems []string
+	check := func(key string, ok bool, format string, args ...interface{}) {
+		if ok {
+			return
+		}
+		msg := fmt.Sprintf(format, args...)
+		if source, ok := c.sources[key]; ok {
+			problems = append(problems, fmt.Sprintf("%s (%s): %s", key, source, msg))
+		} else {
+			problems = append(problems, fmt.Sprintf("%s: %s", key, msg))
+		}
+	}
+	positive := func(key string, d time.Duration) {
+		check(key, d > 0, "must be positive, got %s", d)
+	}
+	notNegative := func(key string, d time.Duration) {
+		check(key, d >= 0, "must not be negative, got %s", d)
+	}
+
+	check("server.port", c.Port >= 1 && c.Port <= 65535, "must be between 1 and 65535, got %d", c.Port)
+	// Zero disables these net/http timeouts
+	notNegative("server.read_header_timeout", c.ReadHeaderTimeout)
+	notNegative("server.read_timeout", c.ReadTimeout)
+	notNegative("server.write_timeout", c.WriteTimeout)
+	notNegative("server.idle_timeout", c.IdleTimeout)
+	positive("server.shutdown_timeout", c.ShutdownTimeout)
+
+	check("database.url", c.DatabaseURL != "", "must be set")
+	if c.DatabaseURL != "" {
+		u, err := url.Parse(c.DatabaseURL)
+		check("database.url", err == nil && (u.Scheme == "postgres" || u.Scheme == "postgresql"),
+			"must be a postgres:// or postgresql:// URL")
+	}
+	check("database.connect_attempts", c.DBConnectAttempts >= 1, "must be at least 1, got %d", c.DBConnectAttempts)
+	positive("database.connect_timeout", c.DBConnectTimeout)
+	notNegative("database.connect_backoff", c.DBConnectBackoff)
+	check("database.connect_max_backoff", c.DBConnectMaxBackoff >= c.DBConnectBackoff,
+		"must be at least database.connect_backoff (%s), got %s", c.DBConnectBackoff, c.DBConnectMaxBackoff)
+
+	_, err := logging.ParseLevel(c.LogLevel)
+	check("log.level", err == nil, "must be debug, info, warn or error, got %q", c.LogLevel)
+	check("log.format", c.LogFormat == logging.FormatJSON || c.LogFormat == logging.FormatText,
+		"must be %s or %s, got %q", logging.FormatJSON, logging.FormatText, c.LogFormat)
+
+	positive("auth.access_token_ttl", c.AccessTokenTTL)
+	positive("auth.refresh_token_ttl", c.RefreshTokenTTL)
+	positive("auth.password_reset_ttl", c.PasswordResetTTL)
+	resetURL, err := url.Parse(c.PasswordResetURL)
+	check("auth.password_reset_url", err == nil && (resetURL.Scheme == "http" || resetURL.Scheme == "https") && resetURL.Host != "",
+		"must be an http:// or https:// URL, got %q", c.PasswordResetURL)
+
+	for _, origin := range c.CORSAllowedOrigins {
+		check("cors.allowed_origins", origin == "*" || strings.Contains(origin, "://"),
+			"%q must be * or an origin like https://app.example.com", origin)
+	}
+	for _, method := range c.CORSAllowedMethods {
+		check("cors.allowed_methods", method == strings.ToUpper(method),
+			"%q must be an upper-case HTTP method", method)
+	}
+	check("cors.allow_credentials", !c.CORSAllowCredentials || !contains(c.CORSAllowedOrigins, "*"),
+		"cannot be true when cors.allowed_origins is *")
+	notNegative("cors.max_age", c.CORSMaxAge)
+
+	switch c.TraceExporter {
+	case tracing.ExporterNone, tracing.ExporterStdout:
+	case tracing.ExporterFile:
+		check("tracing.file", c.TraceFile != "", "must be set when tracing.exporter is file")
+	default:
+		check("tracing.exporter", false, "must be %s, %s or %s, got %q",
+			tracing.ExporterNone, tracing.ExporterStdout, tracing.ExporterFile, c.TraceExporter)
+	}
+	check("tracing.sample_ratio", c.TraceSampleRatio >= 0 && c.TraceSampleRatio <= 1,
+		"must be between 0 and 1, got %g", c.TraceSampleRatio)
+	check("tracing.service_name", c.TraceServiceName != "", "must be set")
+
+	switch c.MailTransport {
+	case mail.TransportLog:
+	case mail.TransportFile:
+		check("mail.file", c.MailFile != "", "must be set when mail.transport is file")
+	case mail.TransportSMTP:
+		check("mail.smtp_host", c.SMTPHost != "", "must be set when mail.transport is smtp")
+		check("mail.smtp_port", c.SMTPPort >= 1 && c.SMTPPort <= 65535, "must be between 1 and 65535, got %d", c.SMTPPort)
+	default:
+		check("mail.transport", false, "must be %s, %s or %s, got %q",
+			mail.TransportLog, mail.TransportFile, mail.TransportSMTP, c.MailTransport)
+	}
+	_, err = netmail.ParseAddress(c.MailFrom)
+	check("mail.from", err == nil, "must be an email address, got %q", c.MailFrom)
+
+	positive("readiness.timeout", c.ReadinessTimeout)
+	check("chameleon.state_dir", c.ChameleonStateDir != "", "must be set")
+
+	if len(problems) > 0 {
+		return &Error{Problems: problems}
+	}
+	return nil
+}
+
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,26 @@
+package passwordreset
+
+import "errors"
+
+var (
+	// ErrNotFound is returned when a reset token is not found or already used
+	ErrNotFound = errors.New("password reset token not found")
+
+	// ErrInvalidInput is returned when input validation fails
+	ErrInvalidInput = errors.New("invalid input")
+
+	// ErrInvalidToken is returned when a reset token is malformed, unknown,
+	// already used or belongs to a deactivated user
+	ErrInvalidToken = errors.New("invalid password reset token")
+
+	// ErrExpiredToken is returned when a reset token is past its expiry
+	ErrExpiredToken = errors.New("password reset token expired")
+
+	// ErrWeakPassword is returned when the new password is too weak; the
+	// token stays usable
+	ErrWeakPassword = errors.New("password must be at least 8 characters")
+
+	// ErrConflict is returned when a write collides with concurrent changes
+	// (a unique violation or a serialization failure); it is safe to retry
+	ErrConflict = errors.New("password reset write conflicted with a concurrent change")
+)
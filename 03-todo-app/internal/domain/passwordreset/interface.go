@@ -0,0 +1,61 @@
+package passwordreset
+
+import (
+	"context"
+	"time"
+
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/user"
+)
+
+// Service defines the forgot-password flow. Both operations are public:
+// they are for users who cannot log in.
+type Service interface {
+	// Request emails a reset link to the active user with email. The link
+	// is sent in the background; other emails are ignored without an
+	// error, and so are failures to send the link, so callers cannot tell
+	// who has an account.
+	Request(ctx context.Context, email string) error
+
+	// Reset sets a new password with the token from a reset link, uses up
+	// every reset token of the user and revokes all of the user's sessions
+	Reset(ctx context.Context, token, password string) error
+
+	// Wait blocks until the emails of earlier Requests have been sent or
+	// have failed; call it before shutting down
+	Wait()
+}
+
+// Users finds and updates the account being reset. It is implemented by
+// the user repository, which skips deactivated users.
+type Users interface {
+	GetByEmail(ctx context.Context, email string) (*user.User, error)
+	SetPassword(ctx context.Context, id, passwordHash string) error
+}
+
+// Sessions revokes a user's sessions. It is implemented by the session
+// repository.
+type Sessions interface {
+	RevokeAll(ctx context.Context, userID string) error
+}
+
+// Transactor runs fn atomically: repository calls made with the context
+// passed to fn commit or roll back together. It is implemented by
+// repository.UnitOfWork.
+type Transactor interface {
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// Repository defines data access contracts.
+// Used tokens are kept; Use never applies to them again.
+type Repository interface {
+	Create(ctx context.Context, userID, tokenHash string, expiresAt time.Time) (*Token, error)
+	GetByID(ctx context.Context, id string) (*Token, error)
+
+	// Use marks an unused token used, or returns ErrNotFound when it was
+	// already used (a concurrent reset got there first)
+	Use(ctx context.Context, id string) error
+
+	// UseAll marks every unused token of userID used, so no reset link
+	// sent before a password change still works
+	UseAll(ctx context.Context, userID string) error
+}
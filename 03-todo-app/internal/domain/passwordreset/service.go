@@ -0,0 +1,209 @@
+package passwordreset
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/auth"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/user"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/mail"
+)
+
+// resetService implements the Service interface
+type resetService struct {
+	repo     Repository
+	users    Users
+	sessions Sessions
+	tx       Transactor
+	mailer   mail.Mailer
+	ttl      time.Duration
+	link     string
+	logger   *slog.Logger
+	now      func() time.Time
+	sending  sync.WaitGroup // reset emails Request left running
+}
+
+// NewService creates a new password reset service. Tokens expire ttl after
+// they were requested; the emailed link is link with the token added as
+// the token query parameter. Reset emails are sent in the background and
+// those that cannot be sent are logged to logger.
+func NewService(repo Repository, users Users, sessions Sessions, tx Transactor, mailer mail.Mailer, ttl time.Duration, link string, logger *slog.Logger) Service {
+	return &resetService{
+		repo:     repo,
+		users:    users,
+		sessions: sessions,
+		tx:       tx,
+		mailer:   mailer,
+		ttl:      ttl,
+		link:     link,
+		logger:   logger,
+		now:      time.Now,
+	}
+}
+
+// Request emails a reset link to the active user with email. Once the
+// user is found, the token and email are left to a goroutine whose
+// failures are logged: an error, or a slower answer, only for emails with
+// an account would tell callers who has one.
+func (s *resetService) Request(ctx context.Context, email string) error {
+	if email == "" {
+		return ErrInvalidInput
+	}
+
+	u, err := s.users.GetByEmail(ctx, email)
+	if err == user.ErrNotFound || err == user.ErrInvalidInput {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// The request's context ends with the response; the email must not
+	ctx = context.WithoutCancel(ctx)
+	s.sending.Add(1)
+	go func() {
+		defer s.sending.Done()
+		if err := s.send(ctx, u); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to send password reset email", "user_id", u.ID.String(), "error", err)
+		}
+	}()
+
+	return nil
+}
+
+// Wait blocks until every email Request started has been sent or has failed
+func (s *resetService) Wait() {
+	s.sending.Wait()
+}
+
+// send stores a new reset token for u and emails u the link
+func (s *resetService) send(ctx context.Context, u *user.User) error {
+	secret, hash, err := auth.NewSecret()
+	if err != nil {
+		return err
+	}
+
+	created, err := s.repo.Create(ctx, u.ID.String(), hash, s.now().Add(s.ttl))
+	if err != nil {
+		return err
+	}
+
+	msg, err := s.message(u, auth.FormatToken(created.ID, secret))
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, msg)
+}
+
+// Reset sets a new password, uses the token and every other outstanding
+// token of the user up and revokes the user's sessions, all or nothing
+func (s *resetService) Reset(ctx context.Context, token, password string) error {
+	current, err := s.lookup(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	hash, err := user.HashPassword(password)
+	if err == user.ErrWeakPassword {
+		return ErrWeakPassword
+	}
+	if err != nil {
+		return err
+	}
+
+	userID := current.UserID.String()
+	return s.tx.WithTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Use(ctx, current.ID.String()); err != nil {
+			if err == ErrNotFound {
+				return ErrInvalidToken
+			}
+			return err
+		}
+		if err := s.repo.UseAll(ctx, userID); err != nil {
+			return err
+		}
+
+		if err := s.users.SetPassword(ctx, userID, hash); err != nil {
+			switch err {
+			case user.ErrNotFound:
+				return ErrInvalidToken
+			case user.ErrConflict:
+				return ErrConflict
+			}
+			return err
+		}
+
+		return s.sessions.RevokeAll(ctx, userID)
+	})
+}
+
+// lookup returns the unused, unexpired token that token names
+func (s *resetService) lookup(ctx context.Context, token string) (*Token, error) {
+	id, secret, ok := auth.ParseToken(token)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	current, err := s.repo.GetByID(ctx, id)
+	if err == ErrNotFound || err == ErrInvalidInput {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if current.UsedAt != nil || !auth.SecretMatches(auth.HashSecret(secret), current.TokenHash) {
+		return nil, ErrInvalidToken
+	}
+	if !s.now().Before(current.ExpiresAt) {
+		return nil, ErrExpiredToken
+	}
+
+	return current, nil
+}
+
+// message is the email carrying a reset link with token
+func (s *resetService) message(u *user.User, token string) (mail.Message, error) {
+	link, err := url.Parse(s.link)
+	if err != nil {
+		return mail.Message{}, fmt.Errorf("invalid password reset link %q: %w", s.link, err)
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+
+	return mail.Message{
+		To:      u.Email,
+		Subject: "Reset your todo-app password",
+		Body: fmt.Sprintf(`Hi %s,
+
+Someone asked to reset the password of your todo-app account. To choose a
+new one, open this link within %s:
+
+%s
+
+Resetting your password signs you out on every device. If you did not ask
+for this, ignore this email; your password has not changed.
+`, u.Name, within(s.ttl), link),
+	}, nil
+}
+
+// within describes a token lifetime for people, e.g. "1 hour" or "30 minutes"
+func within(d time.Duration) string {
+	plural := func(n int64, unit string) string {
+		if n == 1 {
+			return "1 " + unit
+		}
+		return fmt.Sprintf("%d %ss", n, unit)
+	}
+
+	if d >= time.Hour && d%time.Hour == 0 {
+		return plural(int64(d/time.Hour), "hour")
+	}
+	return plural(int64(d.Round(time.Minute)/time.Minute), "minute")
+}
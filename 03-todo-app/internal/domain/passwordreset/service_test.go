@@ -0,0 +1,233 @@
+package passwordreset_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/passwordreset"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/session"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/user"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/mail"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/repository/memrepo"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// outbox records the messages sent through it, or fails with err when set.
+// With hold set, sends wait until it is closed.
+type outbox struct {
+	sent []mail.Message
+	err  error
+	hold chan struct{}
+}
+
+func (o *outbox) Send(_ context.Context, msg mail.Message) error {
+	if o.hold != nil {
+		<-o.hold
+	}
+	if o.err != nil {
+		return o.err
+	}
+	o.sent = append(o.sent, msg)
+	return nil
+}
+
+// fixture is a reset service over a store holding one user
+type fixture struct {
+	svc      passwordreset.Service
+	users    user.Repository
+	accounts user.Service
+	sessions session.Service
+	outbox   *outbox
+	log      *bytes.Buffer
+	user     *user.User
+}
+
+func newFixture(t *testing.T, ttl time.Duration) *fixture {
+	t.Helper()
+
+	store := memrepo.NewStore()
+	users := memrepo.NewUserRepository(store)
+	sessionRepo := memrepo.NewSessionRepository(store)
+	resets := memrepo.NewPasswordResetRepository(store)
+	box := &outbox{}
+	log := &bytes.Buffer{}
+
+	hash, _ := user.HashPassword("password123")
+	u, err := users.Create(context.Background(), "test@example.com", "Test User", hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &fixture{
+		svc:      passwordreset.NewService(resets, users, sessionRepo, store, box, ttl, "https://app.example.com/reset-password", slog.New(slog.NewTextHandler(log, nil))),
+		users:    users,
+		accounts: user.NewService(users, sessionRepo, resets, store),
+		sessions: session.NewService(sessionRepo, users, time.Hour),
+		outbox:   box,
+		log:      log,
+		user:     u,
+	}
+}
+
+var linkPattern = regexp.MustCompile(`https://app\.example\.com/reset-password\?token=\S+`)
+
+// requestToken requests a reset for the fixture's user and returns the
+// token from the emailed link
+func (f *fixture) requestToken(t *testing.T) string {
+	t.Helper()
+
+	if err := f.svc.Request(context.Background(), f.user.Email); err != nil {
+		t.Fatalf("Request: expected no error, got %v", err)
+	}
+	f.svc.Wait()
+	if len(f.outbox.sent) == 0 {
+		t.Fatal("Expected an email")
+	}
+
+	msg := f.outbox.sent[len(f.outbox.sent)-1]
+	link, err := url.Parse(linkPattern.FindString(msg.Body))
+	if msg.To != f.user.Email || err != nil || link.Query().Get("token") == "" {
+		t.Fatalf("Expected a reset link to %s, got %+v", f.user.Email, msg)
+	}
+	return link.Query().Get("token")
+}
+
+func TestServiceReset(t *testing.T) {
+	f := newFixture(t, time.Hour)
+	ctx := context.Background()
+
+	started, _ := f.sessions.Start(ctx, f.user, session.Client{})
+	token := f.requestToken(t)
+
+	if err := f.svc.Reset(ctx, token, "short"); err != passwordreset.ErrWeakPassword {
+		t.Errorf("Weak password: expected ErrWeakPassword, got %v", err)
+	}
+	if err := f.svc.Reset(ctx, token, "new-password"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	u, _ := f.users.GetByID(ctx, f.user.ID.String())
+	if bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte("new-password")) != nil {
+		t.Error("Expected the new password to be set")
+	}
+	if _, err := f.sessions.Refresh(ctx, started.RefreshToken, session.Client{}); err != session.ErrInvalidToken {
+		t.Errorf("Expected the session revoked, got %v", err)
+	}
+
+	if err := f.svc.Reset(ctx, token, "another-password"); err != passwordreset.ErrInvalidToken {
+		t.Errorf("Used token: expected ErrInvalidToken, got %v", err)
+	}
+}
+
+func TestServiceResetUsesEveryToken(t *testing.T) {
+	f := newFixture(t, time.Hour)
+	ctx := context.Background()
+
+	older := f.requestToken(t)
+	token := f.requestToken(t)
+
+	if err := f.svc.Reset(ctx, token, "new-password"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := f.svc.Reset(ctx, older, "another-password"); err != passwordreset.ErrInvalidToken {
+		t.Errorf("Older token: expected ErrInvalidToken, got %v", err)
+	}
+}
+
+func TestChangePasswordUsesTokens(t *testing.T) {
+	f := newFixture(t, time.Hour)
+	ctx := context.Background()
+
+	token := f.requestToken(t)
+
+	if err := f.accounts.ChangePassword(ctx, f.user.ID.String(), "password123", "changed-password"); err != nil {
+		t.Fatalf("ChangePassword: expected no error, got %v", err)
+	}
+	if err := f.svc.Reset(ctx, token, "new-password"); err != passwordreset.ErrInvalidToken {
+		t.Errorf("Expected the token used up by the password change, got %v", err)
+	}
+}
+
+func TestServiceResetRejects(t *testing.T) {
+	ctx := context.Background()
+
+	f := newFixture(t, time.Hour)
+	for _, token := range []string{"", "garbage", "8d2c6a52-0000-4000-8000-000000000001.secret"} {
+		if err := f.svc.Reset(ctx, token, "new-password"); err != passwordreset.ErrInvalidToken {
+			t.Errorf("Reset(%q): expected ErrInvalidToken, got %v", token, err)
+		}
+	}
+
+	token := f.requestToken(t)
+	f.users.Delete(ctx, f.user.ID.String(), nil)
+	if err := f.svc.Reset(ctx, token, "new-password"); err != passwordreset.ErrInvalidToken {
+		t.Errorf("Deactivated user: expected ErrInvalidToken, got %v", err)
+	}
+
+	expiring := newFixture(t, -time.Second)
+	token = expiring.requestToken(t)
+	if err := expiring.svc.Reset(ctx, token, "new-password"); err != passwordreset.ErrExpiredToken {
+		t.Errorf("Expired token: expected ErrExpiredToken, got %v", err)
+	}
+}
+
+func TestServiceRequestUnknownEmail(t *testing.T) {
+	f := newFixture(t, time.Hour)
+
+	if err := f.svc.Request(context.Background(), "nobody@example.com"); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	f.svc.Wait()
+	if len(f.outbox.sent) != 0 {
+		t.Errorf("Expected no email, got %+v", f.outbox.sent)
+	}
+}
+
+func TestServiceRequestDeliveryFailure(t *testing.T) {
+	f := newFixture(t, time.Hour)
+	f.outbox.err = errors.New("smtp: connection refused")
+
+	// Failing only for existing accounts would reveal which emails have one
+	if err := f.svc.Request(context.Background(), f.user.Email); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	f.svc.Wait()
+	if !strings.Contains(f.log.String(), "smtp: connection refused") {
+		t.Errorf("Expected the delivery error logged, got %q", f.log.String())
+	}
+}
+
+func TestServiceRequestSendsInBackground(t *testing.T) {
+	f := newFixture(t, time.Hour)
+	f.outbox.hold = make(chan struct{})
+
+	// Answering only once the email is out would make existing accounts
+	// slower to answer than unknown emails
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- f.svc.Request(ctx, f.user.Email) }()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		close(f.outbox.hold)
+		t.Fatal("Expected Request to answer before the email is sent")
+	}
+	cancel()
+
+	close(f.outbox.hold)
+	f.svc.Wait()
+	if len(f.outbox.sent) != 1 {
+		t.Errorf("Expected the email sent after the request ended, got %+v", f.outbox.sent)
+	}
+}
@@ -0,0 +1,18 @@
+package passwordreset
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Token is a password reset token (see schemas/password_reset.cham). Only
+// the hash of the emailed secret is stored.
+type Token struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
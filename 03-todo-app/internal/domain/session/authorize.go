@@ -0,0 +1,65 @@
+package session
+
+import (
+	"context"
+
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/authz"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/user"
+)
+
+// policy applies to listing and revoking a user's sessions. Start, Refresh
+// and Logout are public: the refresh token is the credential.
+var policy = authz.SelfOrAdmin
+
+// authorizedService checks the caller in the context against policy for
+// the userID of each call before calling the wrapped service
+type authorizedService struct {
+	next Service
+}
+
+// Authorize wraps svc so users may only see and revoke their own sessions,
+// and admins anyone's
+func Authorize(svc Service) Service {
+	return &authorizedService{next: svc}
+}
+
+// allow returns ErrForbidden unless the caller may act for userID
+func allow(ctx context.Context, userID string) error {
+	if !authz.Allow(ctx, policy, userID) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+func (s *authorizedService) Start(ctx context.Context, u *user.User, client Client) (*Grant, error) {
+	return s.next.Start(ctx, u, client)
+}
+
+func (s *authorizedService) Refresh(ctx context.Context, token string, client Client) (*Grant, error) {
+	return s.next.Refresh(ctx, token, client)
+}
+
+func (s *authorizedService) Logout(ctx context.Context, token string) error {
+	return s.next.Logout(ctx, token)
+}
+
+func (s *authorizedService) ListByUser(ctx context.Context, userID string) ([]Session, error) {
+	if err := allow(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.next.ListByUser(ctx, userID)
+}
+
+func (s *authorizedService) Revoke(ctx context.Context, userID, id string) error {
+	if err := allow(ctx, userID); err != nil {
+		return err
+	}
+	return s.next.Revoke(ctx, userID, id)
+}
+
+func (s *authorizedService) RevokeAll(ctx context.Context, userID string) error {
+	if err := allow(ctx, userID); err != nil {
+		return err
+	}
+	return s.next.RevokeAll(ctx, userID)
+}
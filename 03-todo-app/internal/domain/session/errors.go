@@ -0,0 +1,31 @@
+package session
+
+import "errors"
+
+var (
+	// ErrNotFound is returned when session is not found or already revoked
+	ErrNotFound = errors.New("session not found")
+
+	// ErrInvalidInput is returned when input validation fails
+	ErrInvalidInput = errors.New("invalid input")
+
+	// ErrInvalidToken is returned when a refresh token is malformed, unknown,
+	// revoked or belongs to a deactivated user
+	ErrInvalidToken = errors.New("invalid refresh token")
+
+	// ErrExpiredToken is returned when a refresh token is past its expiry
+	ErrExpiredToken = errors.New("refresh token expired")
+
+	// ErrTokenReused is returned when a refresh token that was already
+	// rotated is presented again. The session is revoked, since either the
+	// client or whoever stole the token is replaying it.
+	ErrTokenReused = errors.New("refresh token reused")
+
+	// ErrForbidden is returned when the caller's role does not allow acting
+	// on that user's sessions
+	ErrForbidden = errors.New("forbidden")
+
+	// ErrConflict is returned when a write collides with concurrent changes
+	// (a unique violation or a serialization failure); it is safe to retry
+	ErrConflict = errors.New("session write conflicted with a concurrent change")
+)
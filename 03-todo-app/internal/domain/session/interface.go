@@ -0,0 +1,61 @@
+package session
+
+import (
+	"context"
+	"time"
+
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/user"
+)
+
+// Service defines session business logic contracts.
+//
+// A refresh token names its session and is rotated on every refresh:
+// presenting one that was already rotated fails with ErrTokenReused and
+// revokes the session. Wrapped by Authorize, calls for a userID the caller
+// may not act for fail with ErrForbidden.
+type Service interface {
+	// Start opens a session for a user who just logged in
+	Start(ctx context.Context, u *user.User, client Client) (*Grant, error)
+
+	// Refresh exchanges a refresh token for the next one and extends the
+	// session
+	Refresh(ctx context.Context, token string, client Client) (*Grant, error)
+
+	// Logout revokes the session a refresh token names. Tokens naming no
+	// active session are ignored, so logging out twice succeeds.
+	Logout(ctx context.Context, token string) error
+
+	// ListByUser returns user's active sessions, most recently used first
+	ListByUser(ctx context.Context, userID string) ([]Session, error)
+
+	// Revoke revokes one of user's sessions
+	Revoke(ctx context.Context, userID, id string) error
+
+	// RevokeAll revokes every session of user
+	RevokeAll(ctx context.Context, userID string) error
+}
+
+// Users looks up the account a session authenticates. It is implemented by
+// the user repository, whose GetByID skips deactivated users.
+type Users interface {
+	GetByID(ctx context.Context, id string) (*user.User, error)
+}
+
+// Repository defines data access contracts.
+// Revoked sessions are kept; writes never apply to them.
+type Repository interface {
+	Create(ctx context.Context, userID, tokenHash string, client Client, expiresAt time.Time) (*Session, error)
+	GetByID(ctx context.Context, id string) (*Session, error)
+	ListActive(ctx context.Context, userID string, now time.Time) ([]Session, error)
+
+	// Rotate replaces the token hash of an unrevoked session whose hash is
+	// still oldHash and returns the updated row, or ErrNotFound when none
+	// matched (a concurrent refresh rotated it first)
+	Rotate(ctx context.Context, id, oldHash, newHash string, client Client, expiresAt time.Time) (*Session, error)
+
+	// Revoke revokes an unrevoked session of userID, or returns ErrNotFound
+	Revoke(ctx context.Context, id, userID string) error
+
+	// RevokeAll revokes every unrevoked session of userID
+	RevokeAll(ctx context.Context, userID string) error
+}
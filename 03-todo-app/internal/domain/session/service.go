@@ -0,0 +1,165 @@
+package session
+
+import (
+	"context"
+	"time"
+
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/auth"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/user"
+)
+
+// sessionService implements the Service interface
+type sessionService struct {
+	repo  Repository
+	users Users
+	ttl   time.Duration
+	now   func() time.Time
+}
+
+// NewService creates a new session service. Sessions expire ttl after
+// they were started or last refreshed.
+func NewService(repo Repository, users Users, ttl time.Duration) Service {
+	return &sessionService{repo: repo, users: users, ttl: ttl, now: time.Now}
+}
+
+// Start opens a session for a user who just logged in
+func (s *sessionService) Start(ctx context.Context, u *user.User, client Client) (*Grant, error) {
+	if u == nil {
+		return nil, ErrInvalidInput
+	}
+
+	secret, hash, err := auth.NewSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := s.repo.Create(ctx, u.ID.String(), hash, client, s.now().Add(s.ttl))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Grant{Session: created, RefreshToken: auth.FormatToken(created.ID, secret), User: u}, nil
+}
+
+// Refresh exchanges a refresh token for the next one and extends the session
+func (s *sessionService) Refresh(ctx context.Context, token string, client Client) (*Grant, error) {
+	current, hash, err := s.lookup(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if current.RevokedAt != nil {
+		return nil, ErrInvalidToken
+	}
+	if !s.now().Before(current.ExpiresAt) {
+		return nil, ErrExpiredToken
+	}
+	if !auth.SecretMatches(hash, current.TokenHash) {
+		return nil, s.reused(ctx, current)
+	}
+
+	// Deactivated users lose their sessions; this catches one that was
+	// refreshed while they were being deactivated
+	u, err := s.users.GetByID(ctx, current.UserID.String())
+	if err == user.ErrNotFound {
+		if err := s.revoke(ctx, current); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	secret, next, err := auth.NewSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	rotated, err := s.repo.Rotate(ctx, current.ID.String(), current.TokenHash, next, client, s.now().Add(s.ttl))
+	if err == ErrNotFound {
+		// Another refresh with the same token got there first
+		return nil, s.reused(ctx, current)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Grant{Session: rotated, RefreshToken: auth.FormatToken(rotated.ID, secret), User: u}, nil
+}
+
+// Logout revokes the session a refresh token names
+func (s *sessionService) Logout(ctx context.Context, token string) error {
+	current, _, err := s.lookup(ctx, token)
+	if err == ErrInvalidToken {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.revoke(ctx, current)
+}
+
+// ListByUser returns user's active sessions, most recently used first
+func (s *sessionService) ListByUser(ctx context.Context, userID string) ([]Session, error) {
+	if userID == "" {
+		return nil, ErrInvalidInput
+	}
+
+	return s.repo.ListActive(ctx, userID, s.now())
+}
+
+// Revoke revokes one of user's sessions
+func (s *sessionService) Revoke(ctx context.Context, userID, id string) error {
+	if userID == "" || id == "" {
+		return ErrInvalidInput
+	}
+
+	return s.repo.Revoke(ctx, id, userID)
+}
+
+// RevokeAll revokes every session of user
+func (s *sessionService) RevokeAll(ctx context.Context, userID string) error {
+	if userID == "" {
+		return ErrInvalidInput
+	}
+
+	return s.repo.RevokeAll(ctx, userID)
+}
+
+// lookup returns the session a refresh token names and the hash of the
+// token's secret, or ErrInvalidToken when the token names no session
+func (s *sessionService) lookup(ctx context.Context, token string) (*Session, string, error) {
+	id, secret, ok := auth.ParseToken(token)
+	if !ok {
+		return nil, "", ErrInvalidToken
+	}
+
+	current, err := s.repo.GetByID(ctx, id)
+	if err == ErrNotFound || err == ErrInvalidInput {
+		return nil, "", ErrInvalidToken
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	return current, auth.HashSecret(secret), nil
+}
+
+// reused revokes a session whose rotated token was presented again
+func (s *sessionService) reused(ctx context.Context, current *Session) error {
+	if err := s.revoke(ctx, current); err != nil {
+		return err
+	}
+	return ErrTokenReused
+}
+
+// revoke revokes a session unless it already is
+func (s *sessionService) revoke(ctx context.Context, current *Session) error {
+	err := s.repo.Revoke(ctx, current.ID.String(), current.UserID.String())
+	if err == ErrNotFound {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,87 @@
+package session_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/session"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/user"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/repository/memrepo"
+)
+
+func newService(ttl time.Duration) (session.Service, user.Repository) {
+	store := memrepo.NewStore()
+	users := memrepo.NewUserRepository(store)
+	return session.NewService(memrepo.NewSessionRepository(store), users, ttl), users
+}
+
+func TestServiceRefreshRotates(t *testing.T) {
+	svc, users := newService(time.Hour)
+	ctx := context.Background()
+
+	u, _ := users.Create(ctx, "test@example.com", "Test User", "hash")
+	started, err := svc.Start(ctx, u, session.Client{UserAgent: "laptop"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	refreshed, err := svc.Refresh(ctx, started.RefreshToken, session.Client{UserAgent: "laptop"})
+	if err != nil || refreshed.User.ID != u.ID || refreshed.RefreshToken == started.RefreshToken {
+		t.Fatalf("Expected a rotated token for the user, got %+v (%v)", refreshed, err)
+	}
+
+	if _, err := svc.Refresh(ctx, started.RefreshToken, session.Client{}); err != session.ErrTokenReused {
+		t.Errorf("Replayed token: expected ErrTokenReused, got %v", err)
+	}
+	if _, err := svc.Refresh(ctx, refreshed.RefreshToken, session.Client{}); err != session.ErrInvalidToken {
+		t.Errorf("After reuse: expected the session revoked, got %v", err)
+	}
+}
+
+func TestServiceRefreshRejects(t *testing.T) {
+	ctx := context.Background()
+
+	svc, users := newService(time.Hour)
+	u, _ := users.Create(ctx, "test@example.com", "Test User", "hash")
+
+	for _, token := range []string{"", "garbage", "not-a-uuid.secret", "8d2c6a52-0000-4000-8000-000000000001.secret"} {
+		if _, err := svc.Refresh(ctx, token, session.Client{}); err != session.ErrInvalidToken {
+			t.Errorf("Refresh(%q): expected ErrInvalidToken, got %v", token, err)
+		}
+	}
+
+	started, _ := svc.Start(ctx, u, session.Client{})
+	users.Delete(ctx, u.ID.String(), nil)
+	if _, err := svc.Refresh(ctx, started.RefreshToken, session.Client{}); err != session.ErrInvalidToken {
+		t.Errorf("Deactivated user: expected ErrInvalidToken, got %v", err)
+	}
+
+	expiring, users := newService(-time.Second)
+	u, _ = users.Create(ctx, "test@example.com", "Test User", "hash")
+	started, _ = expiring.Start(ctx, u, session.Client{})
+	if _, err := expiring.Refresh(ctx, started.RefreshToken, session.Client{}); err != session.ErrExpiredToken {
+		t.Errorf("Expired session: expected ErrExpiredToken, got %v", err)
+	}
+}
+
+func TestServiceLogout(t *testing.T) {
+	svc, users := newService(time.Hour)
+	ctx := context.Background()
+
+	u, _ := users.Create(ctx, "test@example.com", "Test User", "hash")
+	started, _ := svc.Start(ctx, u, session.Client{})
+
+	for i := 0; i < 2; i++ {
+		if err := svc.Logout(ctx, started.RefreshToken); err != nil {
+			t.Errorf("Logout %d: expected no error, got %v", i+1, err)
+		}
+	}
+
+	if _, err := svc.Refresh(ctx, started.RefreshToken, session.Client{}); err != session.ErrInvalidToken {
+		t.Errorf("After logout: expected ErrInvalidToken, got %v", err)
+	}
+	if active, _ := svc.ListByUser(ctx, u.ID.String()); len(active) != 0 {
+		t.Errorf("Expected no active sessions, got %d", len(active))
+	}
+}
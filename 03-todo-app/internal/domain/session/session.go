@@ -0,0 +1,43 @@
+package session
+
+import (
+	"time"
+
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/user"
+	"github.com/google/uuid"
+)
+
+// Session is a signed-in device (see schemas/session.cham). Only the hash
+// of its current refresh token is stored.
+type Session struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	TokenHash string
+	UserAgent string
+	IP        string
+	ExpiresAt time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time
+
+	// UpdatedAt is when the session was last refreshed
+	UpdatedAt time.Time
+}
+
+// Active reports whether the session can still be refreshed at now
+func (s *Session) Active(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// Client describes the device a request comes from
+type Client struct {
+	UserAgent string
+	IP        string
+}
+
+// Grant is a session that was just started or refreshed, with the refresh
+// token to present next time and the user it authenticates
+type Grant struct {
+	Session      *Session
+	RefreshToken string
+	User         *user.User
+}
@@ -0,0 +1,95 @@
+package todo
+
+import (
+	"context"
+	"time"
+
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/authz"
+)
+
+// policy applies to every Service operation: a user's todos are theirs and
+// the admins' to act on
+var policy = authz.SelfOrAdmin
+
+// authorizedService checks the caller in the context against policy for
+// the userID of each call before calling the wrapped service
+type authorizedService struct {
+	next Service
+}
+
+// Authorize wraps svc so users may only act on their own todos, and admins
+// on anyone's
+func Authorize(svc Service) Service {
+	return &authorizedService{next: svc}
+}
+
+// allow returns ErrForbidden unless the caller may act for userID
+func allow(ctx context.Context, userID string) error {
+	if !authz.Allow(ctx, policy, userID) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+func (s *authorizedService) Create(ctx context.Context, userID string, in CreateInput) (*Todo, error) {
+	if err := allow(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.next.Create(ctx, userID, in)
+}
+
+func (s *authorizedService) GetByID(ctx context.Context, userID, id string) (*Todo, error) {
+	if err := allow(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.next.GetByID(ctx, userID, id)
+}
+
+func (s *authorizedService) ListByUser(ctx context.Context, userID string, limit, offset int) ([]Todo, error) {
+	if err := allow(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.next.ListByUser(ctx, userID, limit, offset)
+}
+
+func (s *authorizedService) ListByUserFiltered(ctx context.Context, userID string, filter ListFilter, limit, offset int) ([]Todo, error) {
+	if err := allow(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.next.ListByUserFiltered(ctx, userID, filter, limit, offset)
+}
+
+func (s *authorizedService) Update(ctx context.Context, userID, id string, in UpdateInput) (*Todo, error) {
+	if err := allow(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.next.Update(ctx, userID, id, in)
+}
+
+func (s *authorizedService) Patch(ctx context.Context, userID, id string, patch Patch) (*Todo, error) {
+	if err := allow(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.next.Patch(ctx, userID, id, patch)
+}
+
+func (s *authorizedService) Delete(ctx context.Context, userID, id string, version *time.Time) error {
+	if err := allow(ctx, userID); err != nil {
+		return err
+	}
+	return s.next.Delete(ctx, userID, id, version)
+}
+
+func (s *authorizedService) GetOverdue(ctx context.Context, userID string, limit, offset int) ([]Todo, int, error) {
+	if err := allow(ctx, userID); err != nil {
+		return nil, 0, err
+	}
+	return s.next.GetOverdue(ctx, userID, limit, offset)
+}
+
+func (s *authorizedService) ToggleCompletion(ctx context.Context, userID, id string) (*Todo, error) {
+	if err := allow(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.next.ToggleCompletion(ctx, userID, id)
+}
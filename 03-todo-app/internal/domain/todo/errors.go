@@ -12,6 +12,20 @@ var (
 	// ErrUnauthorized is returned when user tries to access todo they don't own
 	ErrUnauthorized = errors.New("unauthorized: todo does not belong to user")
 
+	// ErrForbidden is returned when the caller's role does not allow acting
+	// on that user's todos
+	ErrForbidden = errors.New("forbidden")
+
 	// ErrInvalidUserID is returned when user ID is invalid
 	ErrInvalidUserID = errors.New("invalid user id")
+
+	// ErrOwnerInactive is returned when creating a todo for a deactivated user
+	ErrOwnerInactive = errors.New("user is inactive")
+
+	// ErrVersionMismatch is returned when a todo changed since the caller read it
+	ErrVersionMismatch = errors.New("todo was modified by another request")
+
+	// ErrConflict is returned when a write collides with concurrent changes
+	// (a unique violation or a serialization failure); it is safe to retry
+	ErrConflict = errors.New("todo write conflicted with a concurrent change")
 )
@@ -1,42 +1,77 @@
 package todo
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
-// Service defines todo business logic contracts
+// Service defines todo business logic contracts.
+//
+// Methods acting on a single todo take the ID of the user making the
+// request and fail with ErrUnauthorized when the todo belongs to someone else.
+// Writes given an expected version (the UpdatedAt the caller last read)
+// fail with ErrVersionMismatch when the todo has changed since. Wrapped by
+// Authorize, calls for a userID the caller may not act for fail with
+// ErrForbidden.
 type Service interface {
 	// Create creates a new todo for user
-	Create(ctx context.Context, userID, title, description string) (map[string]interface{}, error)
+	Create(ctx context.Context, userID string, in CreateInput) (*Todo, error)
 
-	// GetByID retrieves todo
-	GetByID(ctx context.Context, id string) (map[string]interface{}, error)
+	// GetByID retrieves a todo owned by userID
+	GetByID(ctx context.Context, userID, id string) (*Todo, error)
 
 	// ListByUser returns user's todos (paginated)
-	ListByUser(ctx context.Context, userID string, limit, offset int) ([]map[string]interface{}, error)
+	ListByUser(ctx context.Context, userID string, limit, offset int) ([]Todo, error)
 
 	// ListByUserFiltered returns user's todos with filters
-	ListByUserFiltered(ctx context.Context, userID string, completed *bool, limit, offset int) ([]map[string]interface{}, error)
+	ListByUserFiltered(ctx context.Context, userID string, filter ListFilter, limit, offset int) ([]Todo, error)
 
-	// Update updates todo
-	Update(ctx context.Context, id, title, description string, completed bool) error
+	// Update replaces the fields of a todo owned by userID and returns the result
+	Update(ctx context.Context, userID, id string, in UpdateInput) (*Todo, error)
 
-	// Delete deletes todo
-	Delete(ctx context.Context, id string) error
+	// Patch applies a partial update to a todo owned by userID and returns the result
+	Patch(ctx context.Context, userID, id string, patch Patch) (*Todo, error)
 
-	// GetOverdue returns overdue todos for user
-	GetOverdue(ctx context.Context, userID string) ([]map[string]interface{}, error)
+	// Delete deletes a todo owned by userID; a nil version skips the check
+	Delete(ctx context.Context, userID, id string, version *time.Time) error
 
-	// ToggleCompletion toggles todo completion status
-	ToggleCompletion(ctx context.Context, id string) error
+	// GetOverdue returns a page of user's incomplete todos past their due
+	// date, oldest due first, along with the total number of overdue todos
+	GetOverdue(ctx context.Context, userID string, limit, offset int) ([]Todo, int, error)
+
+	// ToggleCompletion flips completion status of a todo owned by userID
+	// and returns the updated todo
+	ToggleCompletion(ctx context.Context, userID, id string) (*Todo, error)
+}
+
+// Owners reports whether a user may own todos. It is implemented by the
+// user repository, keeping this package independent of the user domain.
+// Inside a Transactor, IsActive holds the owner until the transaction ends.
+type Owners interface {
+	IsActive(ctx context.Context, userID string) (bool, error)
 }
 
-// Repository defines data access contracts
+// Transactor runs fn atomically: repository calls made with the context
+// passed to fn commit or roll back together. It is implemented by
+// repository.UnitOfWork.
+type Transactor interface {
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// Repository defines data access contracts.
+// Todos archived along with a deactivated owner are invisible to every method.
 type Repository interface {
-	Create(ctx context.Context, userID, title, description string) (map[string]interface{}, error)
-	GetByID(ctx context.Context, id string) (map[string]interface{}, error)
-	ListByUser(ctx context.Context, userID string, limit, offset int) ([]map[string]interface{}, error)
-	ListByUserFiltered(ctx context.Context, userID string, completed *bool, limit, offset int) ([]map[string]interface{}, error)
-	Update(ctx context.Context, id, title, description string, completed bool) error
-	Delete(ctx context.Context, id string) error
-	GetOverdue(ctx context.Context, userID string) ([]map[string]interface{}, error)
-	GetByIDForUser(ctx context.Context, id, userID string) (map[string]interface{}, error)
+	Create(ctx context.Context, userID string, in CreateInput) (*Todo, error)
+	GetByID(ctx context.Context, id string) (*Todo, error)
+	ListByUser(ctx context.Context, userID string, limit, offset int) ([]Todo, error)
+	ListByUserFiltered(ctx context.Context, userID string, filter ListFilter, limit, offset int) ([]Todo, error)
+	Update(ctx context.Context, id, userID string, in UpdateInput) (*Todo, error)
+	Patch(ctx context.Context, id, userID string, patch Patch) (*Todo, error)
+	Delete(ctx context.Context, id, userID string, version *time.Time) error
+	GetOverdue(ctx context.Context, userID string, now time.Time, limit, offset int) ([]Todo, int, error)
+	GetByIDForUser(ctx context.Context, id, userID string) (*Todo, error)
+
+	// Toggle atomically flips completed on a todo owned by userID and
+	// returns the updated row, or ErrNotFound when none matched
+	Toggle(ctx context.Context, id, userID string) (*Todo, error)
 }
@@ -1,26 +1,45 @@
 package todo
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // todoService implements the Service interface
 type todoService struct {
-	repo Repository
+	repo   Repository
+	owners Owners
+	tx     Transactor
 }
 
 // NewService creates a new todo service
-func NewService(repo Repository) Service {
-	return &todoService{repo: repo}
+func NewService(repo Repository, owners Owners, tx Transactor) Service {
+	return &todoService{repo: repo, owners: owners, tx: tx}
 }
 
 // Create creates a new todo for user
-func (s *todoService) Create(ctx context.Context, userID, title, description string) (map[string]interface{}, error) {
+func (s *todoService) Create(ctx context.Context, userID string, in CreateInput) (*Todo, error) {
 	// Validation
-	if userID == "" || title == "" {
+	if userID == "" || in.Title == "" {
 		return nil, ErrInvalidInput
 	}
 
-	// Create via repository
-	todo, err := s.repo.Create(ctx, userID, title, description)
+	// Deactivated users keep their archived todos but cannot add new ones.
+	// Checking and inserting in one transaction keeps the owner from being
+	// deactivated in between.
+	var todo *Todo
+	err := s.tx.WithTx(ctx, func(ctx context.Context) error {
+		active, err := s.owners.IsActive(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if !active {
+			return ErrOwnerInactive
+		}
+
+		todo, err = s.repo.Create(ctx, userID, in)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -28,26 +47,17 @@ func (s *todoService) Create(ctx context.Context, userID, title, description str
 	return todo, nil
 }
 
-// GetByID retrieves todo
-func (s *todoService) GetByID(ctx context.Context, id string) (map[string]interface{}, error) {
-	if id == "" {
+// GetByID retrieves a todo owned by userID
+func (s *todoService) GetByID(ctx context.Context, userID, id string) (*Todo, error) {
+	if userID == "" || id == "" {
 		return nil, ErrInvalidInput
 	}
 
-	todo, err := s.repo.GetByID(ctx, id)
-	if err != nil {
-		return nil, err
-	}
-
-	if todo == nil {
-		return nil, ErrNotFound
-	}
-
-	return todo, nil
+	return s.getOwned(ctx, userID, id)
 }
 
 // ListByUser returns user's todos (paginated)
-func (s *todoService) ListByUser(ctx context.Context, userID string, limit, offset int) ([]map[string]interface{}, error) {
+func (s *todoService) ListByUser(ctx context.Context, userID string, limit, offset int) ([]Todo, error) {
 	if userID == "" {
 		return nil, ErrInvalidInput
 	}
@@ -69,11 +79,16 @@ func (s *todoService) ListByUser(ctx context.Context, userID string, limit, offs
 }
 
 // ListByUserFiltered returns user's todos with filters
-func (s *todoService) ListByUserFiltered(ctx context.Context, userID string, completed *bool, limit, offset int) ([]map[string]interface{}, error) {
+func (s *todoService) ListByUserFiltered(ctx context.Context, userID string, filter ListFilter, limit, offset int) ([]Todo, error) {
 	if userID == "" {
 		return nil, ErrInvalidInput
 	}
 
+	// An empty due range can never match
+	if filter.DueAfter != nil && filter.DueBefore != nil && !filter.DueAfter.Before(*filter.DueBefore) {
+		return nil, ErrInvalidInput
+	}
+
 	// Validate pagination
 	if limit <= 0 || limit > 100 {
 		limit = 10
@@ -82,7 +97,7 @@ func (s *todoService) ListByUserFiltered(ctx context.Context, userID string, com
 		offset = 0
 	}
 
-	todos, err := s.repo.ListByUserFiltered(ctx, userID, completed, limit, offset)
+	todos, err := s.repo.ListByUserFiltered(ctx, userID, filter, limit, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -90,64 +105,136 @@ func (s *todoService) ListByUserFiltered(ctx context.Context, userID string, com
 	return todos, nil
 }
 
-// Update updates todo
-func (s *todoService) Update(ctx context.Context, id, title, description string, completed bool) error {
-	if id == "" || title == "" {
-		return ErrInvalidInput
+// Update replaces the fields of a todo owned by userID
+func (s *todoService) Update(ctx context.Context, userID, id string, in UpdateInput) (*Todo, error) {
+	if userID == "" || id == "" || in.Title == "" {
+		return nil, ErrInvalidInput
 	}
 
-	return s.repo.Update(ctx, id, title, description, completed)
-}
+	current, err := s.getOwned(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
 
-// Delete deletes todo
-func (s *todoService) Delete(ctx context.Context, id string) error {
-	if id == "" {
-		return ErrInvalidInput
+	if err := checkVersion(current, in.Version); err != nil {
+		return nil, err
 	}
 
-	return s.repo.Delete(ctx, id)
+	return s.repo.Update(ctx, id, userID, in)
 }
 
-// GetOverdue returns overdue todos for user
-func (s *todoService) GetOverdue(ctx context.Context, userID string) ([]map[string]interface{}, error) {
-	if userID == "" {
+// Patch applies a partial update to a todo owned by userID
+func (s *todoService) Patch(ctx context.Context, userID, id string, patch Patch) (*Todo, error) {
+	if userID == "" || id == "" {
+		return nil, ErrInvalidInput
+	}
+
+	if patch.Title != nil && *patch.Title == "" {
 		return nil, ErrInvalidInput
 	}
 
-	todos, err := s.repo.GetOverdue(ctx, userID)
+	current, err := s.getOwned(ctx, userID, id)
 	if err != nil {
 		return nil, err
 	}
 
-	return todos, nil
+	if err := checkVersion(current, patch.Version); err != nil {
+		return nil, err
+	}
+
+	if patch.IsEmpty() {
+		return current, nil
+	}
+
+	return s.repo.Patch(ctx, id, userID, patch)
 }
 
-// ToggleCompletion toggles todo completion status
-func (s *todoService) ToggleCompletion(ctx context.Context, id string) error {
-	if id == "" {
+// Delete deletes a todo owned by userID
+func (s *todoService) Delete(ctx context.Context, userID, id string, version *time.Time) error {
+	if userID == "" || id == "" {
 		return ErrInvalidInput
 	}
 
-	// Get current todo
-	todo, err := s.repo.GetByID(ctx, id)
+	current, err := s.getOwned(ctx, userID, id)
 	if err != nil {
 		return err
 	}
 
-	if todo == nil {
-		return ErrNotFound
+	if err := checkVersion(current, version); err != nil {
+		return err
 	}
 
-	// Toggle completion
-	completed, ok := todo["completed"].(bool)
-	if !ok {
-		return ErrInvalidInput
+	return s.repo.Delete(ctx, id, userID, version)
+}
+
+// GetOverdue returns a page of overdue todos for user and the overdue total
+func (s *todoService) GetOverdue(ctx context.Context, userID string, limit, offset int) ([]Todo, int, error) {
+	if userID == "" {
+		return nil, 0, ErrInvalidInput
 	}
 
-	// Get other fields
-	title, _ := todo["title"].(string)
-	description, _ := todo["description"].(string)
+	// Validate pagination
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+	if offset < 0 {
+		offset = 0
+	}
 
-	// Update with toggled value
-	return s.repo.Update(ctx, id, title, description, !completed)
+	todos, total, err := s.repo.GetOverdue(ctx, userID, time.Now(), limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return todos, total, nil
+}
+
+// ToggleCompletion flips completion status of a todo owned by userID and
+// returns the updated todo
+func (s *todoService) ToggleCompletion(ctx context.Context, userID, id string) (*Todo, error) {
+	if userID == "" || id == "" {
+		return nil, ErrInvalidInput
+	}
+
+	// The repository flips the flag in a single statement, so concurrent
+	// toggles and edits cannot overwrite each other
+	todo, err := s.repo.Toggle(ctx, id, userID)
+	if err != ErrNotFound {
+		return todo, err
+	}
+
+	// Nothing matched: report whether it is missing or someone else's
+	if _, err := s.getOwned(ctx, userID, id); err != nil {
+		return nil, err
+	}
+
+	return nil, ErrNotFound
+}
+
+// getOwned loads a todo and checks that it belongs to userID.
+// It returns ErrUnauthorized when the todo exists but has another owner.
+func (s *todoService) getOwned(ctx context.Context, userID, id string) (*Todo, error) {
+	todo, err := s.repo.GetByIDForUser(ctx, id, userID)
+	if err == nil && todo != nil {
+		return todo, nil
+	}
+	if err != nil && err != ErrNotFound {
+		return nil, err
+	}
+
+	// Distinguish "does not exist" from "belongs to someone else"
+	if _, err := s.repo.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return nil, ErrUnauthorized
+}
+
+// checkVersion fails fast when the caller's expected version is already
+// stale. The repository repeats the check atomically in the write itself.
+func checkVersion(current *Todo, version *time.Time) error {
+	if version != nil && !current.UpdatedAt.Equal(*version) {
+		return ErrVersionMismatch
+	}
+	return nil
 }
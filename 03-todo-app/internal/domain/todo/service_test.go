@@ -0,0 +1,53 @@
+package todo_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/todo"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/user"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/repository/memrepo"
+)
+
+func newService() (todo.Service, user.Repository) {
+	store := memrepo.NewStore()
+	users := memrepo.NewUserRepository(store)
+	return todo.NewService(memrepo.NewTodoRepository(store), users, store), users
+}
+
+func TestServiceOwnership(t *testing.T) {
+	svc, users := newService()
+	ctx := context.Background()
+
+	owner, _ := users.Create(ctx, "owner@example.com", "Owner", "hash")
+	intruder, _ := users.Create(ctx, "intruder@example.com", "Intruder", "hash")
+
+	created, err := svc.Create(ctx, owner.ID.String(), todo.CreateInput{Title: "Private"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if _, err := svc.GetByID(ctx, intruder.ID.String(), created.ID.String()); err != todo.ErrUnauthorized {
+		t.Errorf("GetByID: expected ErrUnauthorized, got %v", err)
+	}
+	if _, err := svc.ToggleCompletion(ctx, intruder.ID.String(), created.ID.String()); err != todo.ErrUnauthorized {
+		t.Errorf("ToggleCompletion: expected ErrUnauthorized, got %v", err)
+	}
+
+	toggled, err := svc.ToggleCompletion(ctx, owner.ID.String(), created.ID.String())
+	if err != nil || !toggled.Completed {
+		t.Errorf("ToggleCompletion: expected completed todo, got %+v (%v)", toggled, err)
+	}
+}
+
+func TestServiceCreateForInactiveOwner(t *testing.T) {
+	svc, users := newService()
+	ctx := context.Background()
+
+	u, _ := users.Create(ctx, "test@example.com", "Test User", "hash")
+	users.Delete(ctx, u.ID.String(), nil)
+
+	if _, err := svc.Create(ctx, u.ID.String(), todo.CreateInput{Title: "Too late"}); err != todo.ErrOwnerInactive {
+		t.Errorf("Expected ErrOwnerInactive, got %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package todo
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Todo is a task owned by a user (see schemas/todo.cham)
+type Todo struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	Title       string
+	Description *string
+	Completed   bool
+	DueDate     *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// CreateInput holds the fields of a new todo
+type CreateInput struct {
+	Title       string
+	Description string
+	DueDate     *time.Time
+}
+
+// UpdateInput replaces every editable field of a todo.
+// A nil DueDate clears the due date.
+type UpdateInput struct {
+	Title       string
+	Description string
+	Completed   bool
+	DueDate     *time.Time
+
+	// Version is the UpdatedAt the caller last read. When set, the update
+	// fails with ErrVersionMismatch if the todo has changed since.
+	Version *time.Time
+}
+
+// Patch holds the fields changed by a partial update; nil fields are left
+// untouched. ClearDescription and ClearDueDate set those columns to NULL.
+type Patch struct {
+	Title            *string
+	Description      *string
+	ClearDescription bool
+	Completed        *bool
+	DueDate          *time.Time
+	ClearDueDate     bool
+
+	// Version is the UpdatedAt the caller last read; see UpdateInput.Version
+	Version *time.Time
+}
+
+// IsEmpty reports whether the patch changes nothing
+func (p Patch) IsEmpty() bool {
+	return p.Title == nil && p.Description == nil && !p.ClearDescription &&
+		p.Completed == nil && p.DueDate == nil && !p.ClearDueDate
+}
+
+// ListFilter narrows a todo listing; nil fields are not applied.
+// DueAfter is inclusive and DueBefore exclusive, so consecutive
+// ranges never overlap. Todos without a due date never match a due range.
+type ListFilter struct {
+	Completed *bool
+	DueAfter  *time.Time
+	DueBefore *time.Time
+}
+
+// IsEmpty reports whether no filter is set
+func (f ListFilter) IsEmpty() bool {
+	return f.Completed == nil && f.DueAfter == nil && f.DueBefore == nil
+}
+
+// NarrowDue intersects the due range with [after, before)
+func (f *ListFilter) NarrowDue(after, before time.Time) {
+	if f.DueAfter == nil || after.After(*f.DueAfter) {
+		f.DueAfter = &after
+	}
+	if f.DueBefore == nil || before.Before(*f.DueBefore) {
+		f.DueBefore = &before
+	}
+}
@@ -0,0 +1,122 @@
+package user
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/authz"
+)
+
+// Policies for each Service operation. Create and VerifyPassword are
+// public, since signing up and logging in come before having a token.
+var (
+	policyGetByEmail = authz.AdminOnly
+	policyGetByID    = authz.SelfOrAdmin
+	policyList       = authz.AdminOnly
+	policyUpdate     = authz.SelfOrAdmin
+	policyDelete     = authz.AdminOnly
+	policyRestore    = authz.AdminOnly
+	policySetRole    = authz.AdminOnly
+
+	// Changing a password takes the current one, so in practice only the
+	// user themselves can
+	policyChangePassword = authz.SelfOrAdmin
+)
+
+// authorizedService checks the caller in the context against the policy of
+// each operation before calling the wrapped service
+type authorizedService struct {
+	next Service
+}
+
+// Authorize wraps svc so each call is allowed only by its policy: users
+// may read and edit their own account, and admins any account. Listing,
+// deactivating, restoring and changing roles are admin-only.
+func Authorize(svc Service) Service {
+	return &authorizedService{next: svc}
+}
+
+// allow returns ErrForbidden unless the caller passes policy for userID
+func allow(ctx context.Context, policy authz.Policy, userID string) error {
+	if !authz.Allow(ctx, policy, userID) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+func (s *authorizedService) Create(ctx context.Context, email, name, password string) (*User, error) {
+	return s.next.Create(ctx, email, name, password)
+}
+
+func (s *authorizedService) GetByEmail(ctx context.Context, email string) (*User, error) {
+	if err := allow(ctx, policyGetByEmail, ""); err != nil {
+		return nil, err
+	}
+	return s.next.GetByEmail(ctx, email)
+}
+
+func (s *authorizedService) GetByID(ctx context.Context, id string) (*User, error) {
+	if err := allow(ctx, policyGetByID, id); err != nil {
+		return nil, err
+	}
+	return s.next.GetByID(ctx, id)
+}
+
+func (s *authorizedService) List(ctx context.Context, limit, offset int) ([]User, error) {
+	if err := allow(ctx, policyList, ""); err != nil {
+		return nil, err
+	}
+	return s.next.List(ctx, limit, offset)
+}
+
+func (s *authorizedService) Update(ctx context.Context, id, name string, version *time.Time) (*User, error) {
+	if err := allow(ctx, policyUpdate, id); err != nil {
+		return nil, err
+	}
+	return s.next.Update(ctx, id, name, version)
+}
+
+func (s *authorizedService) Patch(ctx context.Context, id string, patch Patch) (*User, error) {
+	if err := allow(ctx, policyUpdate, id); err != nil {
+		return nil, err
+	}
+	return s.next.Patch(ctx, id, patch)
+}
+
+func (s *authorizedService) Delete(ctx context.Context, id string, version *time.Time) error {
+	if err := allow(ctx, policyDelete, id); err != nil {
+		return err
+	}
+	return s.next.Delete(ctx, id, version)
+}
+
+func (s *authorizedService) Restore(ctx context.Context, id string) error {
+	if err := allow(ctx, policyRestore, id); err != nil {
+		return err
+	}
+	return s.next.Restore(ctx, id)
+}
+
+// SetRole also refuses an admin's own role, so the last admin cannot
+// demote themselves and leave nobody able to grant it back
+func (s *authorizedService) SetRole(ctx context.Context, id string, role authz.Role) (*User, error) {
+	if err := allow(ctx, policySetRole, id); err != nil {
+		return nil, err
+	}
+	if p, _ := authz.PrincipalFromContext(ctx); strings.EqualFold(p.UserID, id) {
+		return nil, ErrForbidden
+	}
+	return s.next.SetRole(ctx, id, role)
+}
+
+func (s *authorizedService) VerifyPassword(ctx context.Context, email, password string) (*User, error) {
+	return s.next.VerifyPassword(ctx, email, password)
+}
+
+func (s *authorizedService) ChangePassword(ctx context.Context, id, current, next string) error {
+	if err := allow(ctx, policyChangePassword, id); err != nil {
+		return err
+	}
+	return s.next.ChangePassword(ctx, id, current, next)
+}
@@ -18,6 +18,21 @@ var (
 	// ErrInvalidPassword is returned when password verification fails
 	ErrInvalidPassword = errors.New("invalid email or password")
 
+	// ErrIncorrectPassword is returned when a password change gives the
+	// wrong current password
+	ErrIncorrectPassword = errors.New("current password is incorrect")
+
+	// ErrForbidden is returned when the caller's role does not allow the
+	// operation on that user
+	ErrForbidden = errors.New("forbidden")
+
 	// ErrUserInactive is returned when user is not active
 	ErrUserInactive = errors.New("user is inactive")
+
+	// ErrVersionMismatch is returned when a user changed since the caller read it
+	ErrVersionMismatch = errors.New("user was modified by another request")
+
+	// ErrConflict is returned when a write collides with concurrent changes
+	// (a unique violation or a serialization failure); it is safe to retry
+	ErrConflict = errors.New("user write conflicted with a concurrent change")
 )
@@ -1,37 +1,88 @@
 package user
 
-import "context"
+import (
+	"context"
+	"time"
 
-// Service defines user business logic contracts
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/authz"
+)
+
+// Service defines user business logic contracts.
+//
+// Writes given an expected version (the UpdatedAt the caller last read)
+// fail with ErrVersionMismatch when the user has changed since; a nil
+// version skips the check. Wrapped by Authorize, calls the caller's role
+// does not allow fail with ErrForbidden.
 type Service interface {
 	// Create creates a new user with password hashing
-	Create(ctx context.Context, email, name, password string) (map[string]interface{}, error)
+	Create(ctx context.Context, email, name, password string) (*User, error)
 
-	// GetByEmail retrieves user by email (password_hash excluded)
-	GetByEmail(ctx context.Context, email string) (map[string]interface{}, error)
+	// GetByEmail retrieves user by email
+	GetByEmail(ctx context.Context, email string) (*User, error)
 
-	// GetByID retrieves user by ID (password_hash excluded)
-	GetByID(ctx context.Context, id string) (map[string]interface{}, error)
+	// GetByID retrieves user by ID
+	GetByID(ctx context.Context, id string) (*User, error)
 
 	// List returns all active users (paginated)
-	List(ctx context.Context, limit, offset int) ([]map[string]interface{}, error)
+	List(ctx context.Context, limit, offset int) ([]User, error)
+
+	// Update updates user profile (name only) and returns the result
+	Update(ctx context.Context, id, name string, version *time.Time) (*User, error)
+
+	// Patch applies a partial profile update and returns the result
+	Patch(ctx context.Context, id string, patch Patch) (*User, error)
 
-	// Update updates user profile (name only)
-	Update(ctx context.Context, id, name string) error
+	// Delete soft-deletes a user (sets is_active = false), archives
+	// their todos and revokes their sessions in the same transaction
+	Delete(ctx context.Context, id string, version *time.Time) error
 
-	// Delete soft-deletes a user (sets is_active = false)
-	Delete(ctx context.Context, id string) error
+	// Restore reactivates a soft-deleted user together with their todos
+	// (admin operation)
+	Restore(ctx context.Context, id string) error
+
+	// SetRole changes a user's role and returns the updated user (admin
+	// operation). It takes effect on their next access token.
+	SetRole(ctx context.Context, id string, role authz.Role) (*User, error)
 
 	// VerifyPassword verifies email + password combination
-	VerifyPassword(ctx context.Context, email, password string) (map[string]interface{}, error)
+	VerifyPassword(ctx context.Context, email, password string) (*User, error)
+
+	// ChangePassword replaces a user's password, given their current one.
+	// Their sessions stay signed in; reset links sent earlier stop working.
+	ChangePassword(ctx context.Context, id, current, next string) error
+}
+
+// Sessions revokes a user's sessions. It is implemented by the session
+// repository, keeping this package independent of the session domain.
+type Sessions interface {
+	RevokeAll(ctx context.Context, userID string) error
+}
+
+// ResetTokens invalidates a user's password reset links. It is implemented
+// by the password reset repository, keeping this package independent of
+// the passwordreset domain.
+type ResetTokens interface {
+	UseAll(ctx context.Context, userID string) error
+}
+
+// Transactor runs fn atomically: repository calls made with the context
+// passed to fn commit or roll back together. It is implemented by
+// repository.UnitOfWork.
+type Transactor interface {
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
 }
 
 // Repository defines data access contracts
 type Repository interface {
-	Create(ctx context.Context, email, name, passwordHash string) (map[string]interface{}, error)
-	GetByEmail(ctx context.Context, email string) (map[string]interface{}, error)
-	GetByID(ctx context.Context, id string) (map[string]interface{}, error)
-	List(ctx context.Context, limit, offset int) ([]map[string]interface{}, error)
-	Update(ctx context.Context, id, name string) error
-	Delete(ctx context.Context, id string) error
+	Create(ctx context.Context, email, name, passwordHash string) (*User, error)
+	GetByEmail(ctx context.Context, email string) (*User, error)
+	GetByID(ctx context.Context, id string) (*User, error)
+	List(ctx context.Context, limit, offset int) ([]User, error)
+	Update(ctx context.Context, id, name string, version *time.Time) (*User, error)
+	Patch(ctx context.Context, id string, patch Patch) (*User, error)
+	Delete(ctx context.Context, id string, version *time.Time) error
+	Restore(ctx context.Context, id string) error
+	SetRole(ctx context.Context, id string, role authz.Role) (*User, error)
+	SetPassword(ctx context.Context, id, passwordHash string) error
+	IsActive(ctx context.Context, id string) (bool, error)
 }
@@ -2,53 +2,48 @@ package user
 
 import (
 	"context"
+	"time"
 
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/authz"
 	"golang.org/x/crypto/bcrypt"
 )
 
 // userService implements the Service interface
 type userService struct {
-	repo Repository
+	repo     Repository
+	sessions Sessions
+	resets   ResetTokens
+	tx       Transactor
 }
 
 // NewService creates a new user service
-func NewService(repo Repository) Service {
-	return &userService{repo: repo}
+func NewService(repo Repository, sessions Sessions, resets ResetTokens, tx Transactor) Service {
+	return &userService{repo: repo, sessions: sessions, resets: resets, tx: tx}
 }
 
 // Create creates a new user with password hashing
-func (s *userService) Create(ctx context.Context, email, name, password string) (map[string]interface{}, error) {
+func (s *userService) Create(ctx context.Context, email, name, password string) (*User, error) {
 	// Validation
 	if email == "" || name == "" || password == "" {
 		return nil, ErrInvalidInput
 	}
 
-	if len(password) < 8 {
-		return nil, ErrWeakPassword
-	}
-
-	// Hash password with bcrypt
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hash, err := HashPassword(password)
 	if err != nil {
 		return nil, err
 	}
 
-	// Create via repository
-	user, err := s.repo.Create(ctx, email, name, string(hash))
+	// Create via repository; a taken email surfaces as ErrDuplicateEmail
+	user, err := s.repo.Create(ctx, email, name, hash)
 	if err != nil {
-		// Check for duplicate email error
-		// This is database-level, we bubble up
 		return nil, err
 	}
 
-	// Remove sensitive fields
-	delete(user, "password_hash")
-
 	return user, nil
 }
 
-// GetByEmail retrieves user by email (no password returned)
-func (s *userService) GetByEmail(ctx context.Context, email string) (map[string]interface{}, error) {
+// GetByEmail retrieves user by email
+func (s *userService) GetByEmail(ctx context.Context, email string) (*User, error) {
 	if email == "" {
 		return nil, ErrInvalidInput
 	}
@@ -62,14 +57,11 @@ func (s *userService) GetByEmail(ctx context.Context, email string) (map[string]
 		return nil, ErrNotFound
 	}
 
-	// Remove sensitive fields
-	delete(user, "password_hash")
-
 	return user, nil
 }
 
-// GetByID retrieves user by ID (no password returned)
-func (s *userService) GetByID(ctx context.Context, id string) (map[string]interface{}, error) {
+// GetByID retrieves user by ID
+func (s *userService) GetByID(ctx context.Context, id string) (*User, error) {
 	if id == "" {
 		return nil, ErrInvalidInput
 	}
@@ -83,14 +75,11 @@ func (s *userService) GetByID(ctx context.Context, id string) (map[string]interf
 		return nil, ErrNotFound
 	}
 
-	// Remove sensitive fields
-	delete(user, "password_hash")
-
 	return user, nil
 }
 
 // List returns all active users (paginated)
-func (s *userService) List(ctx context.Context, limit, offset int) ([]map[string]interface{}, error) {
+func (s *userService) List(ctx context.Context, limit, offset int) ([]User, error) {
 	// Validate pagination
 	if limit <= 0 || limit > 100 {
 		limit = 10
@@ -104,34 +93,110 @@ func (s *userService) List(ctx context.Context, limit, offset int) ([]map[string
 		return nil, err
 	}
 
-	// Remove sensitive fields from all users
-	for _, user := range users {
-		delete(user, "password_hash")
-	}
-
 	return users, nil
 }
 
 // Update updates user profile (name only)
-func (s *userService) Update(ctx context.Context, id, name string) error {
+func (s *userService) Update(ctx context.Context, id, name string, version *time.Time) (*User, error) {
 	if id == "" || name == "" {
+		return nil, ErrInvalidInput
+	}
+
+	return s.repo.Update(ctx, id, name, version)
+}
+
+// Patch applies a partial profile update
+func (s *userService) Patch(ctx context.Context, id string, patch Patch) (*User, error) {
+	if id == "" {
+		return nil, ErrInvalidInput
+	}
+
+	if patch.Name != nil && *patch.Name == "" {
+		return nil, ErrInvalidInput
+	}
+
+	if patch.IsEmpty() {
+		current, err := s.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if patch.Version != nil && !current.UpdatedAt.Equal(*patch.Version) {
+			return nil, ErrVersionMismatch
+		}
+		return current, nil
+	}
+
+	return s.repo.Patch(ctx, id, patch)
+}
+
+// Delete soft-deletes a user and revokes their sessions, so none of their
+// devices can get a new access token
+func (s *userService) Delete(ctx context.Context, id string, version *time.Time) error {
+	if id == "" {
+		return ErrInvalidInput
+	}
+
+	return s.tx.WithTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Delete(ctx, id, version); err != nil {
+			return err
+		}
+		return s.sessions.RevokeAll(ctx, id)
+	})
+}
+
+// Restore reactivates a soft-deleted user together with their todos
+func (s *userService) Restore(ctx context.Context, id string) error {
+	if id == "" {
 		return ErrInvalidInput
 	}
 
-	return s.repo.Update(ctx, id, name)
+	return s.repo.Restore(ctx, id)
 }
 
-// Delete soft-deletes a user
-func (s *userService) Delete(ctx context.Context, id string) error {
+// SetRole changes a user's role
+func (s *userService) SetRole(ctx context.Context, id string, role authz.Role) (*User, error) {
 	if id == "" {
+		return nil, ErrInvalidInput
+	}
+
+	if _, err := authz.ParseRole(string(role)); err != nil {
+		return nil, ErrInvalidInput
+	}
+
+	return s.repo.SetRole(ctx, id, role)
+}
+
+// ChangePassword replaces a user's password after checking the current one
+// and invalidates the user's outstanding reset links, all or nothing
+func (s *userService) ChangePassword(ctx context.Context, id, current, next string) error {
+	if id == "" || current == "" || next == "" {
 		return ErrInvalidInput
 	}
 
-	return s.repo.Delete(ctx, id)
+	user, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(current)); err != nil {
+		return ErrIncorrectPassword
+	}
+
+	hash, err := HashPassword(next)
+	if err != nil {
+		return err
+	}
+
+	return s.tx.WithTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.SetPassword(ctx, id, hash); err != nil {
+			return err
+		}
+		return s.resets.UseAll(ctx, id)
+	})
 }
 
 // VerifyPassword verifies email + password combination
-func (s *userService) VerifyPassword(ctx context.Context, email, password string) (map[string]interface{}, error) {
+func (s *userService) VerifyPassword(ctx context.Context, email, password string) (*User, error) {
 	if email == "" || password == "" {
 		return nil, ErrInvalidInput
 	}
@@ -147,23 +212,29 @@ func (s *userService) VerifyPassword(ctx context.Context, email, password string
 	}
 
 	// Check if user is active
-	isActive, ok := user["is_active"].(bool)
-	if !ok || !isActive {
+	if !user.IsActive {
 		return nil, ErrUserInactive
 	}
 
 	// Compare password with hash
-	passwordHash, ok := user["password_hash"].(string)
-	if !ok {
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
 		return nil, ErrInvalidPassword
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(password)); err != nil {
-		return nil, ErrInvalidPassword
+	return user, nil
+}
+
+// HashPassword checks that a new password is strong enough and returns
+// the bcrypt hash it is stored as
+func HashPassword(password string) (string, error) {
+	if len(password) < 8 {
+		return "", ErrWeakPassword
 	}
 
-	// Return user without password
-	delete(user, "password_hash")
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
 
-	return user, nil
+	return string(hash), nil
 }
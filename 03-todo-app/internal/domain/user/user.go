@@ -0,0 +1,34 @@
+package user
+
+import (
+	"time"
+
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/authz"
+	"github.com/google/uuid"
+)
+
+// User is an application account (see schemas/user.cham)
+type User struct {
+	ID           uuid.UUID
+	Email        string
+	Name         string
+	PasswordHash string
+	Role         authz.Role
+	IsActive     bool
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// Patch holds the profile fields changed by a partial update; nil fields are left untouched
+type Patch struct {
+	Name *string
+
+	// Version is the UpdatedAt the caller last read. When set, the patch
+	// fails with ErrVersionMismatch if the user has changed since.
+	Version *time.Time
+}
+
+// IsEmpty reports whether the patch changes nothing
+func (p Patch) IsEmpty() bool {
+	return p.Name == nil
+}
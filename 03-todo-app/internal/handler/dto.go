@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/session"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/todo"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/user"
+)
+
+// TodoResponse is the JSON representation of a todo
+type TodoResponse struct {
+	ID          string     `json:"id"`
+	UserID      string     `json:"user_id"`
+	Title       string     `json:"title"`
+	Description *string    `json:"description"`
+	Completed   bool       `json:"completed"`
+	DueDate     *time.Time `json:"due_date"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+func newTodoResponse(t *todo.Todo) TodoResponse {
+	return TodoResponse{
+		ID:          t.ID.String(),
+		UserID:      t.UserID.String(),
+		Title:       t.Title,
+		Description: t.Description,
+		Completed:   t.Completed,
+		DueDate:     t.DueDate,
+		CreatedAt:   t.CreatedAt,
+		UpdatedAt:   t.UpdatedAt,
+	}
+}
+
+func newTodoResponses(todos []todo.Todo) []TodoResponse {
+	out := make([]TodoResponse, 0, len(todos))
+	for i := range todos {
+		out = append(out, newTodoResponse(&todos[i]))
+	}
+	return out
+}
+
+// UserResponse is the JSON representation of a user (never includes the password hash)
+type UserResponse struct {
+	ID        string    `json:"id"`
+	Email     string    `json:"email"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role"`
+	IsActive  bool      `json:"is_active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func newUserResponse(u *user.User) UserResponse {
+	return UserResponse{
+		ID:        u.ID.String(),
+		Email:     u.Email,
+		Name:      u.Name,
+		Role:      string(u.Role),
+		IsActive:  u.IsActive,
+		CreatedAt: u.CreatedAt,
+		UpdatedAt: u.UpdatedAt,
+	}
+}
+
+func newUserResponses(users []user.User) []UserResponse {
+	out := make([]UserResponse, 0, len(users))
+	for i := range users {
+		out = append(out, newUserResponse(&users[i]))
+	}
+	return out
+}
+
+// SessionResponse is the JSON representation of a session (never includes the token hash)
+type SessionResponse struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+func newSessionResponse(s *session.Session) SessionResponse {
+	return SessionResponse{
+		ID:         s.ID.String(),
+		UserAgent:  s.UserAgent,
+		IP:         s.IP,
+		CreatedAt:  s.CreatedAt,
+		LastUsedAt: s.UpdatedAt,
+		ExpiresAt:  s.ExpiresAt,
+	}
+}
+
+func newSessionResponses(sessions []session.Session) []SessionResponse {
+	out := make([]SessionResponse, 0, len(sessions))
+	for i := range sessions {
+		out = append(out, newSessionResponse(&sessions[i]))
+	}
+	return out
+}
@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// errPreconditionFailed is returned for an If-Match header that can never
+// match a current representation
+var errPreconditionFailed = errors.New("precondition failed")
+
+// etag builds the strong entity tag for a resource from its updated_at,
+// which the repositories bump on every write
+func etag(updatedAt time.Time) string {
+	return `"` + strconv.FormatInt(updatedAt.UnixMicro(), 10) + `"`
+}
+
+// setETag sets the ETag header for a resource
+func setETag(w http.ResponseWriter, updatedAt time.Time) {
+	w.Header().Set("ETag", etag(updatedAt))
+}
+
+// ifMatch returns the version required by the If-Match header, or nil when
+// the header is absent or "*" (any current representation). Only a single
+// strong entity tag is supported; weak tags and lists fail the precondition.
+func ifMatch(r *http.Request) (*time.Time, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return nil, nil
+	}
+
+	if len(header) < 2 || header[0] != '"' || header[len(header)-1] != '"' {
+		return nil, errPreconditionFailed
+	}
+
+	micros, err := strconv.ParseInt(header[1:len(header)-1], 10, 64)
+	if err != nil {
+		return nil, errPreconditionFailed
+	}
+
+	version := time.UnixMicro(micros).UTC()
+	return &version, nil
+}
+
+// respondPreconditionFailed writes the 412 response for a stale If-Match
+func respondPreconditionFailed(w http.ResponseWriter, resource string) {
+	respondError(w, http.StatusPreconditionFailed, resource+" was modified by another request; reload and retry")
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIfMatchRoundTrip(t *testing.T) {
+	updatedAt := time.Date(2026, 10, 17, 9, 30, 0, 123456000, time.UTC)
+
+	r := httptest.NewRequest("PUT", "/todos/1", nil)
+	r.Header.Set("If-Match", etag(updatedAt))
+
+	version, err := ifMatch(r)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if version == nil || !version.Equal(updatedAt) {
+		t.Errorf("Expected version %v, got %v", updatedAt, version)
+	}
+}
+
+func TestIfMatchAbsentOrAny(t *testing.T) {
+	for _, header := range []string{"", "*"} {
+		r := httptest.NewRequest("PUT", "/todos/1", nil)
+		if header != "" {
+			r.Header.Set("If-Match", header)
+		}
+
+		version, err := ifMatch(r)
+		if err != nil || version != nil {
+			t.Errorf("If-Match %q: expected no version, got %v, %v", header, version, err)
+		}
+	}
+}
+
+func TestIfMatchRejectsWeakAndMalformed(t *testing.T) {
+	for _, header := range []string{`W/"123"`, `"abc"`, `123`, `"1", "2"`} {
+		r := httptest.NewRequest("PUT", "/todos/1", nil)
+		r.Header.Set("If-Match", header)
+
+		if _, err := ifMatch(r); err != errPreconditionFailed {
+			t.Errorf("If-Match %q: expected errPreconditionFailed, got %v", header, err)
+		}
+	}
+}
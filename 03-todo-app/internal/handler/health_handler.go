@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/health"
+)
+
+// HealthHandler handles the liveness and readiness probes. Their bodies are
+// not wrapped in Response, so probes and load balancers can read them as is.
+type HealthHandler struct {
+	checker *health.Checker
+}
+
+// NewHealthHandler creates a health handler running checker on /readyz
+func NewHealthHandler(checker *health.Checker) *HealthHandler {
+	return &HealthHandler{checker: checker}
+}
+
+// GET /healthz - The process is up; dependencies are not checked
+func (h *HealthHandler) Live(w http.ResponseWriter, r *http.Request) {
+	writeProbe(w, http.StatusOK, map[string]string{"status": health.StatusOK})
+}
+
+// GET /readyz - Every dependency is reachable; 503 with per-check details otherwise
+func (h *HealthHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	report := h.checker.Run(r.Context())
+
+	status := http.StatusOK
+	if !report.OK() {
+		status = http.StatusServiceUnavailable
+	}
+
+	writeProbe(w, status, report)
+}
+
+// writeProbe writes an unwrapped, uncached JSON probe response
+func writeProbe(w http.ResponseWriter, statusCode int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+)
+
+// errPatchMediaType is returned for PATCH bodies that are not JSON Merge Patch
+var errPatchMediaType = errors.New("unsupported media type")
+
+// mergePatch is a decoded JSON Merge Patch (RFC 7396) document.
+// A key that is present with a null value asks for the field to be removed.
+type mergePatch map[string]json.RawMessage
+
+// decodeMergePatch reads a merge patch from the request body. Only fields
+// listed in allowed may appear; anything else is rejected.
+func decodeMergePatch(r *http.Request, allowed ...string) (mergePatch, error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || (mediaType != "application/merge-patch+json" && mediaType != "application/json") {
+		return nil, errPatchMediaType
+	}
+
+	var patch mergePatch
+	if err := decodeJSON(r, &patch); err != nil {
+		return nil, errors.New("Invalid request body")
+	}
+	if patch == nil {
+		return nil, errors.New("Patch must be a JSON object")
+	}
+
+	for name := range patch {
+		known := false
+		for _, a := range allowed {
+			if name == a {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("Unknown field %q", name)
+		}
+	}
+
+	return patch, nil
+}
+
+// field decodes the named member into v. present reports whether the key
+// was in the patch and null whether its value was JSON null; v is only
+// written when the value is not null.
+func (p mergePatch) field(name string, v interface{}) (present, null bool, err error) {
+	raw, ok := p[name]
+	if !ok {
+		return false, false, nil
+	}
+
+	if bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+		return true, true, nil
+	}
+
+	if err := json.Unmarshal(raw, v); err != nil {
+		return true, false, fmt.Errorf("Invalid %s", name)
+	}
+
+	return true, false, nil
+}
+
+// respondPatchError writes the response for a decodeMergePatch failure
+func respondPatchError(w http.ResponseWriter, err error) {
+	if err == errPatchMediaType {
+		w.Header().Set("Accept-Patch", "application/merge-patch+json")
+		respondError(w, http.StatusUnsupportedMediaType, "Content-Type must be application/merge-patch+json")
+		return
+	}
+	respondError(w, http.StatusBadRequest, err.Error())
+}
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDecodeMergePatchMediaType(t *testing.T) {
+	r := httptest.NewRequest("PATCH", "/todos/1", strings.NewReader(`{"title":"x"}`))
+	r.Header.Set("Content-Type", "text/plain")
+
+	if _, err := decodeMergePatch(r, "title"); err != errPatchMediaType {
+		t.Errorf("Expected errPatchMediaType, got %v", err)
+	}
+}
+
+func TestDecodeMergePatchUnknownField(t *testing.T) {
+	r := httptest.NewRequest("PATCH", "/todos/1", strings.NewReader(`{"owner":"x"}`))
+	r.Header.Set("Content-Type", "application/merge-patch+json")
+
+	if _, err := decodeMergePatch(r, "title"); err == nil {
+		t.Error("Expected error for unknown field")
+	}
+}
+
+func TestParseTodoPatch(t *testing.T) {
+	r := httptest.NewRequest("PATCH", "/todos/1", strings.NewReader(
+		`{"description":null,"due_date":null,"completed":true}`))
+	r.Header.Set("Content-Type", "application/merge-patch+json")
+
+	doc, err := decodeMergePatch(r, "title", "description", "completed", "due_date")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	patch, err := parseTodoPatch(doc)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if patch.Title != nil {
+		t.Error("Expected title untouched")
+	}
+	if !patch.ClearDescription || !patch.ClearDueDate {
+		t.Error("Expected description and due date cleared")
+	}
+	if patch.Completed == nil || !*patch.Completed {
+		t.Error("Expected completed set to true")
+	}
+}
+
+func TestParseTodoPatchNullTitle(t *testing.T) {
+	if _, err := parseTodoPatch(mergePatch{"title": []byte("null")}); err == nil {
+		t.Error("Expected error for null title")
+	}
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/passwordreset"
+)
+
+// PasswordResetHandler handles the forgot-password HTTP endpoints
+type PasswordResetHandler struct {
+	service passwordreset.Service
+}
+
+// NewPasswordResetHandler creates a new password reset handler
+func NewPasswordResetHandler(svc passwordreset.Service) *PasswordResetHandler {
+	return &PasswordResetHandler{service: svc}
+}
+
+// ForgotPasswordRequest is the request body for forgot password
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+// POST /auth/password/forgot - Email a password reset link. The answer is
+// the same whether or not the email has an account.
+func (h *PasswordResetHandler) Forgot(w http.ResponseWriter, r *http.Request) {
+	var req ForgotPasswordRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Email == "" {
+		respondError(w, http.StatusBadRequest, "Missing email")
+		return
+	}
+
+	if err := h.service.Request(r.Context(), req.Email); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to request password reset")
+		return
+	}
+
+	respondJSON(w, http.StatusAccepted, map[string]string{
+		"message": "If the email belongs to an account, a password reset link is on its way",
+	})
+}
+
+// ResetPasswordRequest is the request body for reset password
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// POST /auth/password/reset - Set a new password with a reset token and
+// sign out every session
+func (h *PasswordResetHandler) Reset(w http.ResponseWriter, r *http.Request) {
+	var req ResetPasswordRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Token == "" {
+		respondError(w, http.StatusBadRequest, "Missing reset token")
+		return
+	}
+
+	err := h.service.Reset(r.Context(), req.Token, req.Password)
+	if err != nil {
+		switch err {
+		case passwordreset.ErrInvalidToken:
+			respondError(w, http.StatusBadRequest, "Invalid reset token")
+		case passwordreset.ErrExpiredToken:
+			respondError(w, http.StatusBadRequest, "Reset token expired")
+		case passwordreset.ErrWeakPassword:
+			respondError(w, http.StatusBadRequest, "Password must be at least 8 characters")
+		case passwordreset.ErrConflict:
+			respondError(w, http.StatusConflict, "Password was reset concurrently; retry")
+		default:
+			respondError(w, http.StatusInternalServerError, "Failed to reset password")
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Password reset"})
+}
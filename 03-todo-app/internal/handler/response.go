@@ -3,14 +3,23 @@ package handler
 import (
 	"encoding/json"
 	"net/http"
+	"time"
 )
 
 // Response is the standard JSON response structure
 type Response struct {
 	Data  interface{} `json:"data,omitempty"`
+	Meta  interface{} `json:"meta,omitempty"`
 	Error string      `json:"error,omitempty"`
 }
 
+// PageMeta describes a paginated response
+type PageMeta struct {
+	Total  int `json:"total"`
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
 // respondJSON writes JSON response with status code
 func respondJSON(w http.ResponseWriter, statusCode int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
@@ -18,6 +27,13 @@ func respondJSON(w http.ResponseWriter, statusCode int, data interface{}) {
 	json.NewEncoder(w).Encode(Response{Data: data})
 }
 
+// respondPage writes a JSON response with pagination metadata
+func respondPage(w http.ResponseWriter, statusCode int, data interface{}, meta PageMeta) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(Response{Data: data, Meta: meta})
+}
+
 // respondError writes error JSON response
 func respondError(w http.ResponseWriter, statusCode int, message string) {
 	w.Header().Set("Content-Type", "application/json")
@@ -65,3 +81,23 @@ func queryBoolParam(r *http.Request, name string) *bool {
 
 	return &result
 }
+
+// queryTimeParam gets a time query parameter, either RFC 3339 or a plain
+// date (YYYY-MM-DD) taken as midnight in loc. Returns nil when absent.
+func queryTimeParam(r *http.Request, name string, loc *time.Location) (*time.Time, error) {
+	val := r.URL.Query().Get(name)
+	if val == "" {
+		return nil, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, val); err == nil {
+		return &t, nil
+	}
+
+	t, err := time.ParseInLocation(time.DateOnly, val, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/auth"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/session"
+	"github.com/go-chi/chi/v5"
+)
+
+// SessionHandler handles refresh, logout and session HTTP endpoints
+type SessionHandler struct {
+	service session.Service
+	tokens  *auth.TokenManager
+}
+
+// NewSessionHandler creates a new session handler
+func NewSessionHandler(svc session.Service, tokens *auth.TokenManager) *SessionHandler {
+	return &SessionHandler{service: svc, tokens: tokens}
+}
+
+// RefreshTokenRequest is the request body for refresh and logout
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// POST /auth/refresh - Exchange a refresh token for new access and refresh tokens
+func (h *SessionHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req RefreshTokenRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.RefreshToken == "" {
+		respondError(w, http.StatusBadRequest, "Missing refresh token")
+		return
+	}
+
+	grant, err := h.service.Refresh(r.Context(), req.RefreshToken, clientOf(r))
+	if err != nil {
+		switch err {
+		case session.ErrInvalidToken:
+			respondError(w, http.StatusUnauthorized, "Invalid refresh token")
+		case session.ErrExpiredToken:
+			respondError(w, http.StatusUnauthorized, "Refresh token expired")
+		case session.ErrTokenReused:
+			respondError(w, http.StatusUnauthorized, "Refresh token was already used; session revoked")
+		case session.ErrConflict:
+			respondError(w, http.StatusConflict, "Session was changed concurrently; retry")
+		default:
+			respondError(w, http.StatusInternalServerError, "Failed to refresh session")
+		}
+		return
+	}
+
+	respondGrant(w, h.tokens, grant, "Failed to refresh session")
+}
+
+// POST /auth/logout - Revoke the session of a refresh token
+func (h *SessionHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	var req RefreshTokenRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.RefreshToken == "" {
+		respondError(w, http.StatusBadRequest, "Missing refresh token")
+		return
+	}
+
+	if err := h.service.Logout(r.Context(), req.RefreshToken); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to log out")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Logged out"})
+}
+
+// GET /users/{id}/sessions - List a user's active sessions
+func (h *SessionHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "id")
+
+	sessions, err := h.service.ListByUser(r.Context(), userID)
+	if err != nil {
+		switch err {
+		case session.ErrForbidden:
+			respondError(w, http.StatusForbidden, "Access denied")
+		case session.ErrInvalidInput:
+			respondError(w, http.StatusBadRequest, "Invalid user ID")
+		default:
+			respondError(w, http.StatusInternalServerError, "Failed to fetch sessions")
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusOK, newSessionResponses(sessions))
+}
+
+// DELETE /users/{id}/sessions/{sessionID} - Revoke one of a user's sessions
+func (h *SessionHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "id")
+	id := chi.URLParam(r, "sessionID")
+
+	err := h.service.Revoke(r.Context(), userID, id)
+	if err != nil {
+		switch err {
+		case session.ErrForbidden:
+			respondError(w, http.StatusForbidden, "Access denied")
+		case session.ErrInvalidInput:
+			respondError(w, http.StatusBadRequest, "Invalid user or session ID")
+		case session.ErrNotFound:
+			respondError(w, http.StatusNotFound, "Session not found")
+		default:
+			respondError(w, http.StatusInternalServerError, "Failed to revoke session")
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Session revoked"})
+}
+
+// DELETE /users/{id}/sessions - Revoke every session of a user
+func (h *SessionHandler) RevokeAll(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "id")
+
+	err := h.service.RevokeAll(r.Context(), userID)
+	if err != nil {
+		switch err {
+		case session.ErrForbidden:
+			respondError(w, http.StatusForbidden, "Access denied")
+		case session.ErrInvalidInput:
+			respondError(w, http.StatusBadRequest, "Invalid user ID")
+		default:
+			respondError(w, http.StatusInternalServerError, "Failed to revoke sessions")
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Sessions revoked"})
+}
+
+// respondGrant issues an access token for a started or refreshed session
+// and writes it along with the session's next refresh token
+func respondGrant(w http.ResponseWriter, tokens *auth.TokenManager, grant *session.Grant, failure string) {
+	token, expiresAt, err := tokens.Issue(grant.User.ID.String(), grant.User.Role)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, failure)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, LoginResponse{
+		AccessToken:      token,
+		TokenType:        "Bearer",
+		ExpiresAt:        expiresAt,
+		RefreshToken:     grant.RefreshToken,
+		RefreshExpiresAt: grant.Session.ExpiresAt,
+		User:             newUserResponse(grant.User),
+	})
+}
+
+// clientOf describes the device a request comes from. RemoteAddr is the
+// client's address once middleware.RealIP has applied X-Forwarded-For.
+func clientOf(r *http.Request) session.Client {
+	ip := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	return session.Client{UserAgent: r.UserAgent(), IP: ip}
+}
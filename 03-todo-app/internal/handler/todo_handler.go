@@ -1,8 +1,11 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
+	"time"
 
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/auth"
 	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/todo"
 	"github.com/go-chi/chi/v5"
 )
@@ -17,15 +20,31 @@ func NewTodoHandler(svc todo.Service) *TodoHandler {
 	return &TodoHandler{service: svc}
 }
 
+// ownerID resolves the user whose todos the request operates on: the
+// {userID} path parameter, or the authenticated caller on routes without
+// one. Whether the caller may act for them is up to the service.
+func ownerID(r *http.Request) (string, bool) {
+	if pathID := chi.URLParam(r, "userID"); pathID != "" {
+		return pathID, true
+	}
+
+	return auth.UserIDFromContext(r.Context())
+}
+
 // CreateTodoRequest is the request body for create todo
 type CreateTodoRequest struct {
-	Title       string `json:"title"`
-	Description string `json:"description"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	DueDate     *time.Time `json:"due_date"`
 }
 
 // POST /users/{userID}/todos - Create todo
 func (h *TodoHandler) Create(w http.ResponseWriter, r *http.Request) {
-	userID := chi.URLParam(r, "userID")
+	userID, ok := ownerID(r)
+	if !ok {
+		respondError(w, http.StatusForbidden, "Access denied")
+		return
+	}
 
 	var req CreateTodoRequest
 	if err := decodeJSON(r, &req); err != nil {
@@ -33,31 +52,50 @@ func (h *TodoHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	t, err := h.service.Create(r.Context(), userID, req.Title, req.Description)
+	t, err := h.service.Create(r.Context(), userID, todo.CreateInput{
+		Title:       req.Title,
+		Description: req.Description,
+		DueDate:     req.DueDate,
+	})
 	if err != nil {
 		switch err {
+		case todo.ErrForbidden:
+			respondError(w, http.StatusForbidden, "Access denied")
 		case todo.ErrInvalidInput:
 			respondError(w, http.StatusBadRequest, "Invalid user ID or title")
 		case todo.ErrInvalidUserID:
 			respondError(w, http.StatusBadRequest, "Invalid user ID")
+		case todo.ErrOwnerInactive:
+			respondError(w, http.StatusForbidden, "User is inactive")
+		case todo.ErrConflict:
+			respondError(w, http.StatusConflict, "Todo was changed concurrently; retry")
 		default:
 			respondError(w, http.StatusInternalServerError, "Failed to create todo")
 		}
 		return
 	}
 
-	respondJSON(w, http.StatusCreated, t)
+	respondJSON(w, http.StatusCreated, newTodoResponse(t))
 }
 
 // GET /todos/{id} - Get todo by ID
 func (h *TodoHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	userID, ok := ownerID(r)
+	if !ok {
+		respondError(w, http.StatusForbidden, "Access denied")
+		return
+	}
 	id := chi.URLParam(r, "id")
 
-	t, err := h.service.GetByID(r.Context(), id)
+	t, err := h.service.GetByID(r.Context(), userID, id)
 	if err != nil {
 		switch err {
+		case todo.ErrForbidden:
+			respondError(w, http.StatusForbidden, "Access denied")
 		case todo.ErrNotFound:
 			respondError(w, http.StatusNotFound, "Todo not found")
+		case todo.ErrUnauthorized:
+			respondError(w, http.StatusForbidden, "Todo does not belong to user")
 		case todo.ErrInvalidInput:
 			respondError(w, http.StatusBadRequest, "Invalid todo ID")
 		default:
@@ -66,15 +104,29 @@ func (h *TodoHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	respondJSON(w, http.StatusOK, t)
+	setETag(w, t.UpdatedAt)
+	respondJSON(w, http.StatusOK, newTodoResponse(t))
 }
 
 // GET /users/{userID}/todos - List user's todos
+//
+// Filters: completed, due_after, due_before (RFC 3339 or YYYY-MM-DD),
+// due_today, due_this_week. Dates and "today"/"this week" are resolved in
+// the IANA time zone given by tz (default UTC); weeks start on Monday.
 func (h *TodoHandler) ListByUser(w http.ResponseWriter, r *http.Request) {
-	userID := chi.URLParam(r, "userID")
+	userID, ok := ownerID(r)
+	if !ok {
+		respondError(w, http.StatusForbidden, "Access denied")
+		return
+	}
 	limit := queryIntParam(r, "limit", 10)
 	offset := queryIntParam(r, "offset", 0)
-	completed := queryBoolParam(r, "completed")
+
+	filter, err := parseListFilter(r, time.Now())
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
 	// Clamp limit
 	if limit < 1 || limit > 100 {
@@ -84,72 +136,263 @@ func (h *TodoHandler) ListByUser(w http.ResponseWriter, r *http.Request) {
 		offset = 0
 	}
 
-	var todos []map[string]interface{}
-	var err error
+	var todos []todo.Todo
 
-	if completed != nil {
-		todos, err = h.service.ListByUserFiltered(r.Context(), userID, completed, limit, offset)
+	if !filter.IsEmpty() {
+		todos, err = h.service.ListByUserFiltered(r.Context(), userID, filter, limit, offset)
 	} else {
 		todos, err = h.service.ListByUser(r.Context(), userID, limit, offset)
 	}
 
 	if err != nil {
 		switch err {
+		case todo.ErrForbidden:
+			respondError(w, http.StatusForbidden, "Access denied")
 		case todo.ErrInvalidInput:
-			respondError(w, http.StatusBadRequest, "Invalid user ID")
+			respondError(w, http.StatusBadRequest, "Invalid user ID or filter")
 		default:
 			respondError(w, http.StatusInternalServerError, "Failed to fetch todos")
 		}
 		return
 	}
 
-	respondJSON(w, http.StatusOK, todos)
+	respondJSON(w, http.StatusOK, newTodoResponses(todos))
+}
+
+// parseListFilter builds a todo.ListFilter from the query string.
+// When several due filters are given, their ranges are intersected.
+func parseListFilter(r *http.Request, now time.Time) (todo.ListFilter, error) {
+	filter := todo.ListFilter{Completed: queryBoolParam(r, "completed")}
+
+	loc := time.UTC
+	if tz := r.URL.Query().Get("tz"); tz != "" {
+		l, err := time.LoadLocation(tz)
+		if err != nil {
+			return filter, errors.New("Invalid tz")
+		}
+		loc = l
+	}
+
+	after, err := queryTimeParam(r, "due_after", loc)
+	if err != nil {
+		return filter, errors.New("Invalid due_after")
+	}
+	before, err := queryTimeParam(r, "due_before", loc)
+	if err != nil {
+		return filter, errors.New("Invalid due_before")
+	}
+	filter.DueAfter, filter.DueBefore = after, before
+
+	now = now.In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+
+	if v := queryBoolParam(r, "due_today"); v != nil && *v {
+		filter.NarrowDue(today, today.AddDate(0, 0, 1))
+	}
+
+	if v := queryBoolParam(r, "due_this_week"); v != nil && *v {
+		// time.Weekday starts on Sunday; shift so Monday is day 0
+		monday := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+		filter.NarrowDue(monday, monday.AddDate(0, 0, 7))
+	}
+
+	return filter, nil
 }
 
-// UpdateTodoRequest is the request body for update todo
+// UpdateTodoRequest is the request body for update todo.
+// PUT replaces the todo, so an omitted or null due_date clears it.
 type UpdateTodoRequest struct {
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	Completed   bool   `json:"completed"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Completed   bool       `json:"completed"`
+	DueDate     *time.Time `json:"due_date"`
 }
 
-// PUT /todos/{id} - Update todo
+// PUT /todos/{id} - Update todo (honours If-Match)
 func (h *TodoHandler) Update(w http.ResponseWriter, r *http.Request) {
+	userID, ok := ownerID(r)
+	if !ok {
+		respondError(w, http.StatusForbidden, "Access denied")
+		return
+	}
 	id := chi.URLParam(r, "id")
 
+	version, err := ifMatch(r)
+	if err != nil {
+		respondPreconditionFailed(w, "Todo")
+		return
+	}
+
 	var req UpdateTodoRequest
 	if err := decodeJSON(r, &req); err != nil {
 		respondError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
-	err := h.service.Update(r.Context(), id, req.Title, req.Description, req.Completed)
+	updated, err := h.service.Update(r.Context(), userID, id, todo.UpdateInput{
+		Title:       req.Title,
+		Description: req.Description,
+		Completed:   req.Completed,
+		DueDate:     req.DueDate,
+		Version:     version,
+	})
 	if err != nil {
 		switch err {
+		case todo.ErrForbidden:
+			respondError(w, http.StatusForbidden, "Access denied")
 		case todo.ErrInvalidInput:
 			respondError(w, http.StatusBadRequest, "Invalid todo ID or title")
 		case todo.ErrNotFound:
 			respondError(w, http.StatusNotFound, "Todo not found")
+		case todo.ErrUnauthorized:
+			respondError(w, http.StatusForbidden, "Todo does not belong to user")
+		case todo.ErrVersionMismatch:
+			respondPreconditionFailed(w, "Todo")
+		case todo.ErrConflict:
+			respondError(w, http.StatusConflict, "Todo was changed concurrently; retry")
 		default:
 			respondError(w, http.StatusInternalServerError, "Failed to update todo")
 		}
 		return
 	}
 
+	setETag(w, updated.UpdatedAt)
 	respondJSON(w, http.StatusOK, map[string]string{"message": "Todo updated successfully"})
 }
 
-// DELETE /todos/{id} - Delete todo
+// parseTodoPatch converts a merge patch into a todo.Patch.
+// title and completed cannot be removed; a null description or due_date clears it.
+func parseTodoPatch(p mergePatch) (todo.Patch, error) {
+	var patch todo.Patch
+
+	var title string
+	if present, null, err := p.field("title", &title); err != nil {
+		return patch, err
+	} else if present {
+		if null {
+			return patch, errors.New("title cannot be null")
+		}
+		patch.Title = &title
+	}
+
+	var description string
+	if present, null, err := p.field("description", &description); err != nil {
+		return patch, err
+	} else if present {
+		if null {
+			patch.ClearDescription = true
+		} else {
+			patch.Description = &description
+		}
+	}
+
+	var completed bool
+	if present, null, err := p.field("completed", &completed); err != nil {
+		return patch, err
+	} else if present {
+		if null {
+			return patch, errors.New("completed cannot be null")
+		}
+		patch.Completed = &completed
+	}
+
+	var dueDate time.Time
+	if present, null, err := p.field("due_date", &dueDate); err != nil {
+		return patch, err
+	} else if present {
+		if null {
+			patch.ClearDueDate = true
+		} else {
+			patch.DueDate = &dueDate
+		}
+	}
+
+	return patch, nil
+}
+
+// PATCH /todos/{id} - Partially update todo (JSON Merge Patch, RFC 7396; honours If-Match)
+func (h *TodoHandler) Patch(w http.ResponseWriter, r *http.Request) {
+	userID, ok := ownerID(r)
+	if !ok {
+		respondError(w, http.StatusForbidden, "Access denied")
+		return
+	}
+	id := chi.URLParam(r, "id")
+
+	version, err := ifMatch(r)
+	if err != nil {
+		respondPreconditionFailed(w, "Todo")
+		return
+	}
+
+	doc, err := decodeMergePatch(r, "title", "description", "completed", "due_date")
+	if err != nil {
+		respondPatchError(w, err)
+		return
+	}
+
+	patch, err := parseTodoPatch(doc)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	patch.Version = version
+
+	t, err := h.service.Patch(r.Context(), userID, id, patch)
+	if err != nil {
+		switch err {
+		case todo.ErrForbidden:
+			respondError(w, http.StatusForbidden, "Access denied")
+		case todo.ErrInvalidInput:
+			respondError(w, http.StatusBadRequest, "Invalid todo ID or title")
+		case todo.ErrNotFound:
+			respondError(w, http.StatusNotFound, "Todo not found")
+		case todo.ErrUnauthorized:
+			respondError(w, http.StatusForbidden, "Todo does not belong to user")
+		case todo.ErrVersionMismatch:
+			respondPreconditionFailed(w, "Todo")
+		case todo.ErrConflict:
+			respondError(w, http.StatusConflict, "Todo was changed concurrently; retry")
+		default:
+			respondError(w, http.StatusInternalServerError, "Failed to update todo")
+		}
+		return
+	}
+
+	setETag(w, t.UpdatedAt)
+	respondJSON(w, http.StatusOK, newTodoResponse(t))
+}
+
+// DELETE /todos/{id} - Delete todo (honours If-Match)
 func (h *TodoHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, ok := ownerID(r)
+	if !ok {
+		respondError(w, http.StatusForbidden, "Access denied")
+		return
+	}
 	id := chi.URLParam(r, "id")
 
-	err := h.service.Delete(r.Context(), id)
+	version, err := ifMatch(r)
+	if err != nil {
+		respondPreconditionFailed(w, "Todo")
+		return
+	}
+
+	err = h.service.Delete(r.Context(), userID, id, version)
 	if err != nil {
 		switch err {
+		case todo.ErrForbidden:
+			respondError(w, http.StatusForbidden, "Access denied")
 		case todo.ErrInvalidInput:
 			respondError(w, http.StatusBadRequest, "Invalid todo ID")
 		case todo.ErrNotFound:
 			respondError(w, http.StatusNotFound, "Todo not found")
+		case todo.ErrUnauthorized:
+			respondError(w, http.StatusForbidden, "Todo does not belong to user")
+		case todo.ErrVersionMismatch:
+			respondPreconditionFailed(w, "Todo")
+		case todo.ErrConflict:
+			respondError(w, http.StatusConflict, "Todo was changed concurrently; retry")
 		default:
 			respondError(w, http.StatusInternalServerError, "Failed to delete todo")
 		}
@@ -159,13 +402,29 @@ func (h *TodoHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, map[string]string{"message": "Todo deleted successfully"})
 }
 
-// GET /users/{userID}/todos/overdue - Get overdue todos
+// GET /users/{userID}/todos/overdue - Get overdue todos (paginated, oldest due first)
 func (h *TodoHandler) GetOverdue(w http.ResponseWriter, r *http.Request) {
-	userID := chi.URLParam(r, "userID")
+	userID, ok := ownerID(r)
+	if !ok {
+		respondError(w, http.StatusForbidden, "Access denied")
+		return
+	}
+	limit := queryIntParam(r, "limit", 10)
+	offset := queryIntParam(r, "offset", 0)
 
-	todos, err := h.service.GetOverdue(r.Context(), userID)
+	// Clamp limit
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	todos, total, err := h.service.GetOverdue(r.Context(), userID, limit, offset)
 	if err != nil {
 		switch err {
+		case todo.ErrForbidden:
+			respondError(w, http.StatusForbidden, "Access denied")
 		case todo.ErrInvalidInput:
 			respondError(w, http.StatusBadRequest, "Invalid user ID")
 		default:
@@ -174,30 +433,37 @@ func (h *TodoHandler) GetOverdue(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	respondJSON(w, http.StatusOK, todos)
-}
-
-// ToggleCompletionRequest is the request body for toggle completion
-type ToggleCompletionRequest struct {
-	Completed bool `json:"completed"`
+	respondPage(w, http.StatusOK, newTodoResponses(todos), PageMeta{Total: total, Limit: limit, Offset: offset})
 }
 
-// PATCH /todos/{id}/toggle - Toggle todo completion
+// PATCH /todos/{id}/toggle - Toggle todo completion and return the updated todo
 func (h *TodoHandler) ToggleCompletion(w http.ResponseWriter, r *http.Request) {
+	userID, ok := ownerID(r)
+	if !ok {
+		respondError(w, http.StatusForbidden, "Access denied")
+		return
+	}
 	id := chi.URLParam(r, "id")
 
-	err := h.service.ToggleCompletion(r.Context(), id)
+	t, err := h.service.ToggleCompletion(r.Context(), userID, id)
 	if err != nil {
 		switch err {
+		case todo.ErrForbidden:
+			respondError(w, http.StatusForbidden, "Access denied")
 		case todo.ErrInvalidInput:
 			respondError(w, http.StatusBadRequest, "Invalid todo ID")
 		case todo.ErrNotFound:
 			respondError(w, http.StatusNotFound, "Todo not found")
+		case todo.ErrUnauthorized:
+			respondError(w, http.StatusForbidden, "Todo does not belong to user")
+		case todo.ErrConflict:
+			respondError(w, http.StatusConflict, "Todo was changed concurrently; retry")
 		default:
 			respondError(w, http.StatusInternalServerError, "Failed to toggle todo completion")
 		}
 		return
 	}
 
-	respondJSON(w, http.StatusOK, map[string]string{"message": "Todo toggled successfully"})
+	setETag(w, t.UpdatedAt)
+	respondJSON(w, http.StatusOK, newTodoResponse(t))
 }
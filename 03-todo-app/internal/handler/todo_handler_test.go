@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseListFilterDueToday(t *testing.T) {
+	// 23:30 UTC on a Sunday is already Monday in Tokyo
+	now := time.Date(2026, 10, 18, 23, 30, 0, 0, time.UTC)
+
+	r := httptest.NewRequest("GET", "/users/u/todos?due_today=true&tz=Asia/Tokyo", nil)
+	filter, err := parseListFilter(r, now)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	wantAfter := time.Date(2026, 10, 19, 0, 0, 0, 0, tokyo)
+
+	if filter.DueAfter == nil || !filter.DueAfter.Equal(wantAfter) {
+		t.Errorf("Expected due_after %v, got %v", wantAfter, filter.DueAfter)
+	}
+
+	if filter.DueBefore == nil || !filter.DueBefore.Equal(wantAfter.AddDate(0, 0, 1)) {
+		t.Errorf("Expected due_before %v, got %v", wantAfter.AddDate(0, 0, 1), filter.DueBefore)
+	}
+}
+
+func TestParseListFilterDueThisWeek(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC) // Sunday
+
+	r := httptest.NewRequest("GET", "/users/u/todos?due_this_week=1", nil)
+	filter, err := parseListFilter(r, now)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	monday := time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)
+	if !filter.DueAfter.Equal(monday) || !filter.DueBefore.Equal(monday.AddDate(0, 0, 7)) {
+		t.Errorf("Expected week [%v, %v), got [%v, %v)", monday, monday.AddDate(0, 0, 7), filter.DueAfter, filter.DueBefore)
+	}
+}
+
+func TestParseListFilterIntersectsRanges(t *testing.T) {
+	now := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC) // Wednesday
+
+	r := httptest.NewRequest("GET", "/users/u/todos?due_this_week=true&due_after=2026-10-13&due_before=2026-10-30T00:00:00Z", nil)
+	filter, err := parseListFilter(r, now)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	wantAfter := time.Date(2026, 10, 13, 0, 0, 0, 0, time.UTC)
+	wantBefore := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	if !filter.DueAfter.Equal(wantAfter) || !filter.DueBefore.Equal(wantBefore) {
+		t.Errorf("Expected [%v, %v), got [%v, %v)", wantAfter, wantBefore, filter.DueAfter, filter.DueBefore)
+	}
+}
+
+func TestParseListFilterRejectsBadInput(t *testing.T) {
+	for _, query := range []string{"tz=Mars/Olympus", "due_after=tomorrow", "due_before=2026-13-01"} {
+		r := httptest.NewRequest("GET", "/users/u/todos?"+query, nil)
+		if _, err := parseListFilter(r, time.Now()); err == nil {
+			t.Errorf("Expected error for %q", query)
+		}
+	}
+}
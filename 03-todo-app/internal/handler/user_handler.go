@@ -2,19 +2,25 @@ package handler
 
 import (
 	"net/http"
+	"time"
 
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/auth"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/authz"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/session"
 	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/user"
 	"github.com/go-chi/chi/v5"
 )
 
 // UserHandler handles user HTTP endpoints
 type UserHandler struct {
-	service user.Service
+	service  user.Service
+	sessions session.Service
+	tokens   *auth.TokenManager
 }
 
-// NewUserHandler creates a new user handler
-func NewUserHandler(svc user.Service) *UserHandler {
-	return &UserHandler{service: svc}
+// NewUserHandler creates a new user handler; logins start a session in sessions
+func NewUserHandler(svc user.Service, sessions session.Service, tokens *auth.TokenManager) *UserHandler {
+	return &UserHandler{service: svc, sessions: sessions, tokens: tokens}
 }
 
 // CreateRequest is the request body for create user
@@ -41,13 +47,15 @@ func (h *UserHandler) Create(w http.ResponseWriter, r *http.Request) {
 			respondError(w, http.StatusBadRequest, "Password must be at least 8 characters")
 		case user.ErrDuplicateEmail:
 			respondError(w, http.StatusConflict, "Email already exists")
+		case user.ErrConflict:
+			respondError(w, http.StatusConflict, "User was changed concurrently; retry")
 		default:
 			respondError(w, http.StatusInternalServerError, "Failed to create user")
 		}
 		return
 	}
 
-	respondJSON(w, http.StatusCreated, u)
+	respondJSON(w, http.StatusCreated, newUserResponse(u))
 }
 
 // GET /users/{id} - Get user by ID
@@ -57,6 +65,8 @@ func (h *UserHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 	u, err := h.service.GetByID(r.Context(), id)
 	if err != nil {
 		switch err {
+		case user.ErrForbidden:
+			respondError(w, http.StatusForbidden, "Access denied")
 		case user.ErrNotFound:
 			respondError(w, http.StatusNotFound, "User not found")
 		case user.ErrInvalidInput:
@@ -67,7 +77,8 @@ func (h *UserHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	respondJSON(w, http.StatusOK, u)
+	setETag(w, u.UpdatedAt)
+	respondJSON(w, http.StatusOK, newUserResponse(u))
 }
 
 // GET /users - List users
@@ -85,11 +96,16 @@ func (h *UserHandler) List(w http.ResponseWriter, r *http.Request) {
 
 	users, err := h.service.List(r.Context(), limit, offset)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to fetch users")
+		switch err {
+		case user.ErrForbidden:
+			respondError(w, http.StatusForbidden, "Access denied")
+		default:
+			respondError(w, http.StatusInternalServerError, "Failed to fetch users")
+		}
 		return
 	}
 
-	respondJSON(w, http.StatusOK, users)
+	respondJSON(w, http.StatusOK, newUserResponses(users))
 }
 
 // UpdateUserRequest is the request body for update user
@@ -97,43 +113,120 @@ type UpdateUserRequest struct {
 	Name string `json:"name"`
 }
 
-// PUT /users/{id} - Update user
+// PUT /users/{id} - Update user (honours If-Match)
 func (h *UserHandler) Update(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
+	version, err := ifMatch(r)
+	if err != nil {
+		respondPreconditionFailed(w, "User")
+		return
+	}
+
 	var req UpdateUserRequest
 	if err := decodeJSON(r, &req); err != nil {
 		respondError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
-	err := h.service.Update(r.Context(), id, req.Name)
+	updated, err := h.service.Update(r.Context(), id, req.Name, version)
 	if err != nil {
 		switch err {
+		case user.ErrForbidden:
+			respondError(w, http.StatusForbidden, "Access denied")
 		case user.ErrInvalidInput:
 			respondError(w, http.StatusBadRequest, "Invalid user ID or name")
 		case user.ErrNotFound:
 			respondError(w, http.StatusNotFound, "User not found")
+		case user.ErrVersionMismatch:
+			respondPreconditionFailed(w, "User")
+		case user.ErrConflict:
+			respondError(w, http.StatusConflict, "User was changed concurrently; retry")
 		default:
 			respondError(w, http.StatusInternalServerError, "Failed to update user")
 		}
 		return
 	}
 
+	setETag(w, updated.UpdatedAt)
 	respondJSON(w, http.StatusOK, map[string]string{"message": "User updated successfully"})
 }
 
-// DELETE /users/{id} - Delete user
+// PATCH /users/{id} - Partially update user (JSON Merge Patch, RFC 7396; honours If-Match)
+func (h *UserHandler) Patch(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	version, err := ifMatch(r)
+	if err != nil {
+		respondPreconditionFailed(w, "User")
+		return
+	}
+
+	doc, err := decodeMergePatch(r, "name")
+	if err != nil {
+		respondPatchError(w, err)
+		return
+	}
+
+	patch := user.Patch{Version: version}
+	var name string
+	if present, null, err := doc.field("name", &name); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	} else if present {
+		if null {
+			respondError(w, http.StatusBadRequest, "name cannot be null")
+			return
+		}
+		patch.Name = &name
+	}
+
+	u, err := h.service.Patch(r.Context(), id, patch)
+	if err != nil {
+		switch err {
+		case user.ErrForbidden:
+			respondError(w, http.StatusForbidden, "Access denied")
+		case user.ErrInvalidInput:
+			respondError(w, http.StatusBadRequest, "Invalid user ID or name")
+		case user.ErrNotFound:
+			respondError(w, http.StatusNotFound, "User not found")
+		case user.ErrVersionMismatch:
+			respondPreconditionFailed(w, "User")
+		case user.ErrConflict:
+			respondError(w, http.StatusConflict, "User was changed concurrently; retry")
+		default:
+			respondError(w, http.StatusInternalServerError, "Failed to update user")
+		}
+		return
+	}
+
+	setETag(w, u.UpdatedAt)
+	respondJSON(w, http.StatusOK, newUserResponse(u))
+}
+
+// DELETE /users/{id} - Delete user (honours If-Match)
 func (h *UserHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
-	err := h.service.Delete(r.Context(), id)
+	version, err := ifMatch(r)
+	if err != nil {
+		respondPreconditionFailed(w, "User")
+		return
+	}
+
+	err = h.service.Delete(r.Context(), id, version)
 	if err != nil {
 		switch err {
+		case user.ErrForbidden:
+			respondError(w, http.StatusForbidden, "Access denied")
 		case user.ErrInvalidInput:
 			respondError(w, http.StatusBadRequest, "Invalid user ID")
 		case user.ErrNotFound:
 			respondError(w, http.StatusNotFound, "User not found")
+		case user.ErrVersionMismatch:
+			respondPreconditionFailed(w, "User")
+		case user.ErrConflict:
+			respondError(w, http.StatusConflict, "User was changed concurrently; retry")
 		default:
 			respondError(w, http.StatusInternalServerError, "Failed to delete user")
 		}
@@ -143,13 +236,136 @@ func (h *UserHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, map[string]string{"message": "User deleted successfully"})
 }
 
+// POST /users/{id}/restore - Reactivate a deleted user and their todos
+func (h *UserHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	err := h.service.Restore(r.Context(), id)
+	if err != nil {
+		switch err {
+		case user.ErrForbidden:
+			respondError(w, http.StatusForbidden, "Access denied")
+		case user.ErrInvalidInput:
+			respondError(w, http.StatusBadRequest, "Invalid user ID")
+		case user.ErrNotFound:
+			respondError(w, http.StatusNotFound, "User not found or not deleted")
+		case user.ErrConflict:
+			respondError(w, http.StatusConflict, "User was changed concurrently; retry")
+		default:
+			respondError(w, http.StatusInternalServerError, "Failed to restore user")
+		}
+		return
+	}
+
+	u, err := h.service.GetByID(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch user")
+		return
+	}
+
+	setETag(w, u.UpdatedAt)
+	respondJSON(w, http.StatusOK, newUserResponse(u))
+}
+
+// SetRoleRequest is the request body for set role
+type SetRoleRequest struct {
+	Role string `json:"role"`
+}
+
+// PUT /users/{id}/role - Change a user's role (admin only)
+func (h *UserHandler) SetRole(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var req SetRoleRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	role, err := authz.ParseRole(req.Role)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Role must be user or admin")
+		return
+	}
+
+	u, err := h.service.SetRole(r.Context(), id, role)
+	if err != nil {
+		switch err {
+		case user.ErrForbidden:
+			respondError(w, http.StatusForbidden, "Access denied")
+		case user.ErrInvalidInput:
+			respondError(w, http.StatusBadRequest, "Invalid user ID")
+		case user.ErrNotFound:
+			respondError(w, http.StatusNotFound, "User not found")
+		case user.ErrConflict:
+			respondError(w, http.StatusConflict, "User was changed concurrently; retry")
+		default:
+			respondError(w, http.StatusInternalServerError, "Failed to change role")
+		}
+		return
+	}
+
+	setETag(w, u.UpdatedAt)
+	respondJSON(w, http.StatusOK, newUserResponse(u))
+}
+
+// ChangePasswordRequest is the request body for change password
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+// PUT /users/{id}/password - Change a user's password, given the current one
+func (h *UserHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var req ChangePasswordRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	err := h.service.ChangePassword(r.Context(), id, req.CurrentPassword, req.NewPassword)
+	if err != nil {
+		switch err {
+		case user.ErrForbidden:
+			respondError(w, http.StatusForbidden, "Access denied")
+		case user.ErrInvalidInput:
+			respondError(w, http.StatusBadRequest, "Invalid user ID, current or new password")
+		case user.ErrIncorrectPassword:
+			respondError(w, http.StatusBadRequest, "Current password is incorrect")
+		case user.ErrWeakPassword:
+			respondError(w, http.StatusBadRequest, "Password must be at least 8 characters")
+		case user.ErrNotFound:
+			respondError(w, http.StatusNotFound, "User not found")
+		case user.ErrConflict:
+			respondError(w, http.StatusConflict, "User was changed concurrently; retry")
+		default:
+			respondError(w, http.StatusInternalServerError, "Failed to change password")
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Password changed"})
+}
+
 // LoginRequest is the request body for login
 type LoginRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
 }
 
-// POST /login - Login user
+// LoginResponse is the response body for a successful login or refresh
+type LoginResponse struct {
+	AccessToken      string       `json:"access_token"`
+	TokenType        string       `json:"token_type"`
+	ExpiresAt        time.Time    `json:"expires_at"`
+	RefreshToken     string       `json:"refresh_token"`
+	RefreshExpiresAt time.Time    `json:"refresh_expires_at"`
+	User             UserResponse `json:"user"`
+}
+
+// POST /login - Login user, start a session and issue access and refresh tokens
 func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 	var req LoginRequest
 	if err := decodeJSON(r, &req); err != nil {
@@ -172,5 +388,11 @@ func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	respondJSON(w, http.StatusOK, u)
+	grant, err := h.sessions.Start(r.Context(), u, clientOf(r))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Login failed")
+		return
+	}
+
+	respondGrant(w, h.tokens, grant, "Login failed")
 }
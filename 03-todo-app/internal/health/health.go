@@ -0,0 +1,112 @@
+// Package health runs the readiness checks behind GET /readyz
+package health
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Check statuses
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// CheckFunc reports the state of one dependency. A non-nil error marks it
+// down; details are reported either way.
+type CheckFunc func(ctx context.Context) (details interface{}, err error)
+
+// Result is the outcome of one check
+type Result struct {
+	Status    string      `json:"status"`
+	Error     string      `json:"error,omitempty"`
+	LatencyMS int64       `json:"latency_ms"`
+	Details   interface{} `json:"details,omitempty"`
+}
+
+// Report is the outcome of all checks; Status is StatusOK only when every
+// check passed
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// OK reports whether every check passed
+func (r Report) OK() bool {
+	return r.Status == StatusOK
+}
+
+// Checker runs a fixed set of named checks concurrently
+type Checker struct {
+	timeout time.Duration
+	names   []string
+	checks  map[string]CheckFunc
+}
+
+// NewChecker creates a checker whose checks each get at most timeout
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout, checks: make(map[string]CheckFunc)}
+}
+
+// Add registers a check under name
+func (c *Checker) Add(name string, check CheckFunc) {
+	if _, ok := c.checks[name]; !ok {
+		c.names = append(c.names, name)
+		sort.Strings(c.names)
+	}
+	c.checks[name] = check
+}
+
+// Run runs every check and collects the results
+func (c *Checker) Run(ctx context.Context) Report {
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(c.names))}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, name := range c.names {
+		wg.Add(1)
+		go func(name string, check CheckFunc) {
+			defer wg.Done()
+			result := c.run(ctx, check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = result
+			if result.Status != StatusOK {
+				report.Status = StatusFail
+			}
+		}(name, c.checks[name])
+	}
+	wg.Wait()
+
+	return report
+}
+
+// run runs one check under the timeout, turning a panic into a failure
+func (c *Checker) run(ctx context.Context, check CheckFunc) (result Result) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	defer func() {
+		result.LatencyMS = time.Since(start).Milliseconds()
+		if p := recover(); p != nil {
+			result.Status, result.Error = StatusFail, fmt.Sprintf("check panicked: %v", p)
+		}
+	}()
+
+	details, err := check(ctx)
+	if err == nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+
+	result = Result{Status: StatusOK, Details: details}
+	if err != nil {
+		result.Status, result.Error = StatusFail, err.Error()
+	}
+
+	return result
+}
@@ -0,0 +1,116 @@
+package health
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckerReportsEachCheck(t *testing.T) {
+	checker := NewChecker(50 * time.Millisecond)
+	checker.Add("up", func(ctx context.Context) (interface{}, error) {
+		return "details", nil
+	})
+	checker.Add("down", func(ctx context.Context) (interface{}, error) {
+		return nil, errors.New("refused")
+	})
+	checker.Add("slow", func(ctx context.Context) (interface{}, error) {
+		<-ctx.Done()
+		return nil, nil
+	})
+	checker.Add("panics", func(ctx context.Context) (interface{}, error) {
+		panic("boom")
+	})
+
+	report := checker.Run(context.Background())
+
+	if report.OK() {
+		t.Fatalf("Expected the report to fail, got %+v", report)
+	}
+	if got := report.Checks["up"]; got.Status != StatusOK || got.Details != "details" {
+		t.Errorf("Expected up to pass with details, got %+v", got)
+	}
+	if got := report.Checks["down"]; got.Status != StatusFail || got.Error != "refused" {
+		t.Errorf("Expected down to fail, got %+v", got)
+	}
+	if got := report.Checks["slow"]; got.Status != StatusFail || got.Error != context.DeadlineExceeded.Error() {
+		t.Errorf("Expected slow to time out, got %+v", got)
+	}
+	if got := report.Checks["panics"]; got.Status != StatusFail || !strings.Contains(got.Error, "boom") {
+		t.Errorf("Expected panics to fail, got %+v", got)
+	}
+}
+
+func TestCheckerWithoutChecksIsOK(t *testing.T) {
+	if report := NewChecker(time.Second).Run(context.Background()); !report.OK() {
+		t.Errorf("Expected ok, got %+v", report)
+	}
+}
+
+func TestSchemaCheck(t *testing.T) {
+	schema := "entity User {}\n"
+	sum := sha256.Sum256([]byte(schema))
+	hash := hex.EncodeToString(sum[:])
+
+	tests := []struct {
+		name     string
+		manifest string // empty: no manifest file
+		wantErr  string
+		wantVer  string
+	}{
+		{name: "in sync", wantVer: "v002",
+			manifest: `{"migrations":[{"version":"v001","status":"applied","schema_hash":"old"},{"version":"v002","status":"applied","schema_hash":"` + hash + `"}]}`},
+		{name: "failed migration ignored", wantVer: "v001",
+			manifest: `{"migrations":[{"version":"v001","status":"applied","schema_hash":"` + hash + `"},{"version":"v002","status":"failed","schema_hash":"new"}]}`},
+		{name: "pending migration", wantVer: "v001", wantErr: "differs from applied migration v001",
+			manifest: `{"migrations":[{"version":"v001","status":"applied","schema_hash":"old"}]}`},
+		{name: "nothing applied", wantErr: "no migration applied",
+			manifest: `{"migrations":[]}`},
+		{name: "never migrated", wantErr: "read migration manifest"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			schemaPath := filepath.Join(dir, "schema.merged.cham")
+			if err := os.WriteFile(schemaPath, []byte(schema), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if tt.manifest != "" {
+				if err := os.MkdirAll(filepath.Join(dir, "migrations"), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(dir, "migrations", "manifest.json"), []byte(tt.manifest), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			details, err := SchemaCheck(schemaPath, dir)(context.Background())
+
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("Expected no error, got %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+
+			got, _ := details.(SchemaDetails)
+			if got.LoadedHash != hash || got.AppliedVersion != tt.wantVer {
+				t.Errorf("Expected loaded hash %s and version %q, got %+v", hash, tt.wantVer, got)
+			}
+		})
+	}
+}
+
+func TestSchemaCheckWithoutSchema(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := SchemaCheck(filepath.Join(dir, "missing.cham"), dir)(context.Background()); err == nil {
+		t.Error("Expected an error for a missing merged schema")
+	}
+}
@@ -0,0 +1,73 @@
+package health
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// SchemaDetails compares the schema the engine runs with and the last
+// migration `chameleon migrate` recorded as applied
+type SchemaDetails struct {
+	LoadedHash     string `json:"loaded_hash"`
+	AppliedVersion string `json:"applied_version,omitempty"`
+	AppliedHash    string `json:"applied_hash,omitempty"`
+}
+
+// migrationManifest is the part of <stateDir>/migrations/manifest.json
+// this check reads
+type migrationManifest struct {
+	Migrations []struct {
+		Version    string `json:"version"`
+		Status     string `json:"status"`
+		SchemaHash string `json:"schema_hash"`
+	} `json:"migrations"`
+}
+
+// SchemaCheck fails when the merged schema at schemaPath, which the engine
+// loads, is not the one the last applied migration in stateDir was
+// generated from, i.e. when a migration is pending
+func SchemaCheck(schemaPath, stateDir string) CheckFunc {
+	return func(ctx context.Context) (interface{}, error) {
+		content, err := os.ReadFile(schemaPath)
+		if err != nil {
+			return nil, fmt.Errorf("read merged schema: %w", err)
+		}
+
+		// The vault hashes the merged schema file the same way
+		sum := sha256.Sum256(content)
+		details := SchemaDetails{LoadedHash: hex.EncodeToString(sum[:])}
+
+		data, err := os.ReadFile(filepath.Join(stateDir, "migrations", "manifest.json"))
+		if err != nil {
+			return details, fmt.Errorf("read migration manifest: %w", err)
+		}
+
+		var manifest migrationManifest
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return details, fmt.Errorf("parse migration manifest: %w", err)
+		}
+
+		for i := len(manifest.Migrations) - 1; i >= 0; i-- {
+			if m := manifest.Migrations[i]; m.Status == "applied" {
+				details.AppliedVersion, details.AppliedHash = m.Version, m.SchemaHash
+				break
+			}
+		}
+
+		switch details.AppliedVersion {
+		case "":
+			return details, fmt.Errorf("no migration applied")
+		default:
+			if details.AppliedHash != details.LoadedHash {
+				return details, fmt.Errorf("schema differs from applied migration %s; run chameleon migrate --apply", details.AppliedVersion)
+			}
+		}
+
+		return details, nil
+	}
+}
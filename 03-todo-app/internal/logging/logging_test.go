@@ -0,0 +1,63 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := map[string]slog.Level{
+		"debug":   slog.LevelDebug,
+		"INFO":    slog.LevelInfo,
+		"":        slog.LevelInfo,
+		"warn":    slog.LevelWarn,
+		"Warning": slog.LevelWarn,
+		"error":   slog.LevelError,
+	}
+
+	for in, want := range tests {
+		got, err := ParseLevel(in)
+		if err != nil || got != want {
+			t.Errorf("ParseLevel(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("Expected an error for an unknown level")
+	}
+}
+
+func TestNewHonoursLevelAndFormat(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "warn", "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger.Info("dropped")
+	logger.Warn("kept", "key", "value")
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("Expected a single JSON record, got %q: %v", buf.String(), err)
+	}
+	if record["msg"] != "kept" || record["key"] != "value" {
+		t.Errorf("Unexpected record %v", record)
+	}
+
+	buf.Reset()
+	if logger, err = New(&buf, "debug", "text"); err != nil {
+		t.Fatal(err)
+	}
+	logger.Debug("hello")
+	if !strings.Contains(buf.String(), "level=DEBUG msg=hello") {
+		t.Errorf("Expected a text record, got %q", buf.String())
+	}
+
+	if _, err := New(&buf, "info", "xml"); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
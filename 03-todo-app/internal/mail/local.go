@@ -0,0 +1,93 @@
+package mail
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// LogMailer logs messages instead of sending them. Links in them, such as
+// password reset links, end up in the log, so it is for local use only.
+type LogMailer struct {
+	logger *slog.Logger
+}
+
+// NewLogMailer creates a mailer logging to logger
+func NewLogMailer(logger *slog.Logger) *LogMailer {
+	return &LogMailer{logger: logger}
+}
+
+// Send logs msg at info level
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	m.logger.InfoContext(ctx, "mail not sent (log transport)",
+		"to", msg.To,
+		"subject", msg.Subject,
+		"body", msg.Body,
+	)
+	return nil
+}
+
+// FileMailer appends messages to an mbox file, which mail clients can
+// open, for local use and tests
+type FileMailer struct {
+	mu   sync.Mutex
+	file *os.File
+	from string
+	now  func() time.Time
+}
+
+// NewFileMailer creates a mailer appending to the file at path
+func NewFileMailer(path, from string) (*FileMailer, error) {
+	if path == "" {
+		return nil, fmt.Errorf("mail transport %q needs a file path", TransportFile)
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open mail file: %w", err)
+	}
+
+	return &FileMailer{file: f, from: from, now: time.Now}, nil
+}
+
+// fromLine matches body lines mbox readers would take for the start of
+// the next message, with any > already escaping them (mboxrd)
+var fromLine = regexp.MustCompile(`^>*From `)
+
+// Send appends msg to the file
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	now := m.now()
+	data, err := format(m.from, msg, now)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From todo-app %s\n", now.UTC().Format(time.ANSIC))
+	lines := bufio.NewScanner(bytes.NewReader(data))
+	for lines.Scan() {
+		line := bytes.TrimSuffix(lines.Bytes(), []byte("\r"))
+		if fromLine.Match(line) {
+			buf.WriteByte('>')
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	buf.WriteByte('\n')
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, err = m.file.Write(buf.Bytes())
+	return err
+}
+
+// Close closes the file
+func (m *FileMailer) Close() error {
+	return m.file.Close()
+}
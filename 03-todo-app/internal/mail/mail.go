@@ -0,0 +1,64 @@
+// Package mail sends the emails the API writes to users, through a
+// transport chosen by config
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/mail"
+)
+
+// Transports
+const (
+	TransportLog  = "log"  // messages are logged, for local development
+	TransportFile = "file" // messages are appended to Config.File
+	TransportSMTP = "smtp" // messages are sent through Config.SMTP
+)
+
+// Message is a plain-text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers messages
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Config selects how messages are delivered
+type Config struct {
+	Transport string
+	From      string // sender address
+	File      string // for TransportFile
+	SMTP      SMTPConfig
+}
+
+// New returns the Mailer for cfg. The returned func releases it and must
+// be called before exit.
+func New(cfg Config, logger *slog.Logger) (Mailer, func() error, error) {
+	if _, err := mail.ParseAddress(cfg.From); err != nil {
+		return nil, nil, fmt.Errorf("invalid sender address %q: %w", cfg.From, err)
+	}
+
+	switch cfg.Transport {
+	case TransportLog, "":
+		return NewLogMailer(logger), func() error { return nil }, nil
+	case TransportFile:
+		m, err := NewFileMailer(cfg.File, cfg.From)
+		if err != nil {
+			return nil, nil, err
+		}
+		return m, m.Close, nil
+	case TransportSMTP:
+		m, err := NewSMTPMailer(cfg.SMTP, cfg.From)
+		if err != nil {
+			return nil, nil, err
+		}
+		return m, func() error { return nil }, nil
+	default:
+		return nil, nil, fmt.Errorf("invalid mail transport %q: want log, file or smtp", cfg.Transport)
+	}
+}
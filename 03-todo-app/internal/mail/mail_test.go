@@ -0,0 +1,152 @@
+package mail
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"log/slog"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var resetMessage = Message{
+	To:      "test@example.com",
+	Subject: "Reset your password",
+	Body:    "Open https://app.example.com/reset?token=abc\nFrom here on, it is up to you.",
+}
+
+// readBody decodes the quoted-printable body of a message
+func readBody(t *testing.T, msg *mail.Message) string {
+	t.Helper()
+
+	body, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.ReplaceAll(string(body), "\r\n", "\n")
+}
+
+func TestFileMailer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mail.mbox")
+	m, closeMailer, err := New(Config{Transport: TransportFile, File: path, From: "todo-app <no-reply@example.com>"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Send(context.Background(), resetMessage); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := closeMailer(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	separator, rest, _ := strings.Cut(string(data), "\n")
+	if !strings.HasPrefix(separator, "From todo-app ") {
+		t.Fatalf("Expected an mbox From line, got %q", separator)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(rest))
+	if err != nil {
+		t.Fatalf("Expected a parseable message, got %v", err)
+	}
+	if msg.Header.Get("To") != "<test@example.com>" || msg.Header.Get("Subject") != resetMessage.Subject {
+		t.Errorf("Unexpected headers %v", msg.Header)
+	}
+	if got := readBody(t, msg); !strings.Contains(got, "\n>From here on") {
+		t.Errorf("Expected the body with its From line escaped, got %q", got)
+	}
+}
+
+func TestSMTPMailer(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	received := make(chan string, 1)
+	go serveSMTP(ln, received)
+
+	port := ln.Addr().(*net.TCPAddr).Port
+	m, err := NewSMTPMailer(SMTPConfig{Host: "127.0.0.1", Port: port}, "no-reply@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Send(context.Background(), resetMessage); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(<-received))
+	if err != nil {
+		t.Fatalf("Expected a parseable message, got %v", err)
+	}
+	if got := readBody(t, msg); !strings.Contains(got, "token=abc") {
+		t.Errorf("Expected the link in the body, got %q", got)
+	}
+}
+
+// serveSMTP accepts one connection on ln and speaks just enough SMTP to
+// receive a message, which it sends on received
+func serveSMTP(ln net.Listener, received chan<- string) {
+	conn, err := ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+
+	reply("220 localhost ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		switch cmd := strings.ToUpper(strings.Fields(line + " x")[0]); cmd {
+		case "EHLO", "HELO", "MAIL", "RCPT":
+			reply("250 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil || line == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(line, "."))
+			}
+			received <- data.String()
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Not implemented")
+		}
+	}
+}
+
+func TestNewRejectsBadConfig(t *testing.T) {
+	for _, cfg := range []Config{
+		{Transport: "pigeon", From: "no-reply@example.com"},
+		{Transport: TransportFile, From: "no-reply@example.com"},
+		{Transport: TransportSMTP, From: "no-reply@example.com"},
+		{Transport: TransportLog, From: "not an address"},
+	} {
+		if _, _, err := New(cfg, slog.Default()); err == nil {
+			t.Errorf("New(%+v): expected an error", cfg)
+		}
+	}
+}
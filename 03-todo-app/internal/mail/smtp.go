@@ -0,0 +1,125 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// SMTPConfig locates the SMTP server. Port 465 is spoken over TLS from the
+// start; on other ports the connection is upgraded with STARTTLS when the
+// server offers it, and credentials are only sent over TLS or to localhost.
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string // no authentication when empty
+	Password string
+}
+
+// SMTPMailer sends messages through an SMTP server, one connection per
+// message
+type SMTPMailer struct {
+	cfg  SMTPConfig
+	from *mail.Address
+	now  func() time.Time
+}
+
+// NewSMTPMailer creates a mailer sending from the address from
+func NewSMTPMailer(cfg SMTPConfig, from string) (*SMTPMailer, error) {
+	if cfg.Host == "" || cfg.Port == 0 {
+		return nil, fmt.Errorf("mail transport %q needs a host and port", TransportSMTP)
+	}
+
+	sender, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender address %q: %w", from, err)
+	}
+
+	return &SMTPMailer{cfg: cfg, from: sender, now: time.Now}, nil
+}
+
+// Send delivers msg, giving up when ctx is done
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	data, err := format(m.from.String(), msg, m.now())
+	if err != nil {
+		return err
+	}
+	to, _ := mail.ParseAddress(msg.To) // checked by format
+
+	c, err := m.dial(ctx)
+	if err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	defer c.Close()
+
+	if err := m.send(c, to.Address, data); err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	return nil
+}
+
+// dial connects to the server, bounded by ctx's deadline
+func (m *SMTPMailer) dial(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(m.cfg.Host, strconv.Itoa(m.cfg.Port))
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if m.cfg.Port == 465 {
+		conn = tls.Client(conn, &tls.Config{ServerName: m.cfg.Host})
+	}
+
+	c, err := smtp.NewClient(conn, m.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// send runs one mail transaction on c
+func (m *SMTPMailer) send(c *smtp.Client, to string, data []byte) error {
+	if ok, _ := c.Extension("STARTTLS"); ok && m.cfg.Port != 465 {
+		if err := c.StartTLS(&tls.Config{ServerName: m.cfg.Host}); err != nil {
+			return err
+		}
+	}
+
+	if m.cfg.Username != "" {
+		// PlainAuth refuses to send credentials in the clear, except to localhost
+		if err := c.Auth(smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(m.from.Address); err != nil {
+		return err
+	}
+	if err := c.Rcpt(to); err != nil {
+		return err
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return c.Quit()
+}
@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+)
+
+// HTTP holds the API's request metrics
+type HTTP struct {
+	requests *CounterVec
+	duration *HistogramVec
+	inFlight *Gauge
+}
+
+// NewHTTP registers the request metrics in r
+func NewHTTP(r *Registry) *HTTP {
+	return &HTTP{
+		requests: r.NewCounterVec("http_requests_total",
+			"HTTP requests served, by method, route pattern and status.",
+			"method", "route", "status"),
+		duration: r.NewHistogramVec("http_request_duration_seconds",
+			"HTTP request latency, by method, route pattern and status.",
+			DefaultBuckets, "method", "route", "status"),
+		inFlight: r.NewGauge("http_requests_in_flight",
+			"HTTP requests being served."),
+	}
+}
+
+// Start marks a request in flight; call the returned func with its route
+// pattern and status once it is served
+func (m *HTTP) Start(method string) func(route string, status int) {
+	start := time.Now()
+	m.inFlight.Inc()
+
+	return func(route string, status int) {
+		m.inFlight.Dec()
+
+		code := strconv.Itoa(status)
+		m.requests.Inc(method, route, code)
+		m.duration.Observe(time.Since(start).Seconds(), method, route, code)
+	}
+}
+
+// DB holds the ChameleonDB operation metrics
+type DB struct {
+	duration *HistogramVec
+	errors   *CounterVec
+}
+
+// NewDB registers the database operation metrics in r
+func NewDB(r *Registry) *DB {
+	return &DB{
+		duration: r.NewHistogramVec("chameleondb_operation_duration_seconds",
+			"ChameleonDB query and mutation latency, by entity and operation.",
+			DefaultBuckets, "entity", "operation"),
+		errors: r.NewCounterVec("chameleondb_operation_errors_total",
+			"ChameleonDB queries and mutations that failed, by entity and operation.",
+			"entity", "operation"),
+	}
+}
+
+// ObserveOperation records one query or mutation
+func (m *DB) ObserveOperation(entity, operation string, elapsed time.Duration, err error) {
+	m.duration.Observe(elapsed.Seconds(), entity, operation)
+	if err != nil {
+		m.errors.Inc(entity, operation)
+	}
+}
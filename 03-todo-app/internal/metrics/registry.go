@@ -0,0 +1,311 @@
+// Package metrics implements the small subset of Prometheus instrumentation
+// the API needs, exposed in the text exposition format (version 0.0.4)
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are latency buckets in seconds, as in the Prometheus clients
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// metric is anything a Registry can expose
+type metric interface {
+	name() string
+	write(w *bufio.Writer)
+}
+
+// Registry holds metrics and serves them on /metrics
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric
+	names   map[string]bool
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]bool)}
+}
+
+// register adds m; registering a name twice is a programming error
+func (r *Registry) register(m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.names[m.name()] {
+		panic(fmt.Sprintf("metrics: %s registered twice", m.name()))
+	}
+	r.names[m.name()] = true
+	r.metrics = append(r.metrics, m)
+}
+
+// Handler serves every registered metric in registration order
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.mu.Lock()
+		metrics := append([]metric(nil), r.metrics...)
+		r.mu.Unlock()
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		buf := bufio.NewWriter(w)
+		for _, m := range metrics {
+			m.write(buf)
+		}
+		buf.Flush()
+	})
+}
+
+// desc is the name, help and label names shared by every metric type
+type desc struct {
+	metricName string
+	help       string
+	kind       string
+	labels     []string
+}
+
+func (d desc) name() string { return d.metricName }
+
+func (d desc) header(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", d.metricName, escapeHelp(d.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", d.metricName, d.kind)
+}
+
+// labelPairs renders {name="value",...} for values, plus extra pairs
+func (d desc) labelPairs(values []string, extra ...string) string {
+	if len(d.labels) == 0 && len(extra) == 0 {
+		return ""
+	}
+
+	pairs := make([]string, 0, len(d.labels)+len(extra)/2)
+	for i, label := range d.labels {
+		pairs = append(pairs, label+`="`+escapeLabel(values[i])+`"`)
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, extra[i]+`="`+escapeLabel(extra[i+1])+`"`)
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// series holds one labelled value of a vector
+type series[T any] struct {
+	values []string
+	value  T
+}
+
+// vec maps label values to series
+type vec[T any] struct {
+	desc
+	mu     sync.Mutex
+	series map[string]*series[T]
+	init   func() T
+}
+
+func newVec[T any](d desc, init func() T) vec[T] {
+	return vec[T]{desc: d, series: make(map[string]*series[T]), init: init}
+}
+
+// with runs fn on the series for values, creating it on first use
+func (v *vec[T]) with(values []string, fn func(*T)) {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: %s wants %d label values, got %d", v.metricName, len(v.labels), len(values)))
+	}
+
+	key := strings.Join(values, "\xff")
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	s, ok := v.series[key]
+	if !ok {
+		s = &series[T]{values: append([]string(nil), values...), value: v.init()}
+		v.series[key] = s
+	}
+	fn(&s.value)
+}
+
+// sorted returns the series ordered by label values, for stable output
+func (v *vec[T]) sorted() []series[T] {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	keys := make([]string, 0, len(v.series))
+	for key := range v.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	out := make([]series[T], len(keys))
+	for i, key := range keys {
+		out[i] = *v.series[key]
+	}
+	return out
+}
+
+// CounterVec is a counter partitioned by labels
+type CounterVec struct{ vec[float64] }
+
+// NewCounterVec registers a counter with the given label names
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{newVec(desc{name, help, "counter", labels}, func() float64 { return 0 })}
+	r.register(c)
+	return c
+}
+
+// Inc adds one to the series for labelValues
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds delta, which must not be negative, to the series for labelValues
+func (c *CounterVec) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		panic(fmt.Sprintf("metrics: %s cannot decrease", c.metricName))
+	}
+	c.with(labelValues, func(v *float64) { *v += delta })
+}
+
+func (c *CounterVec) write(w *bufio.Writer) {
+	c.header(w)
+	for _, s := range c.sorted() {
+		fmt.Fprintf(w, "%s%s %s\n", c.metricName, c.labelPairs(s.values), formatFloat(s.value))
+	}
+}
+
+// histogram is one series of a HistogramVec
+type histogram struct {
+	counts []uint64 // per bucket, not cumulative
+	sum    float64
+	count  uint64
+}
+
+// HistogramVec is a histogram partitioned by labels
+type HistogramVec struct {
+	vec[histogram]
+	buckets []float64
+}
+
+// NewHistogramVec registers a histogram with the given upper bounds and
+// label names
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+
+	h := &HistogramVec{buckets: buckets}
+	h.vec = newVec(desc{name, help, "histogram", labels}, func() histogram {
+		return histogram{counts: make([]uint64, len(buckets))}
+	})
+	r.register(h)
+	return h
+}
+
+// Observe records value in the series for labelValues
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	i := sort.SearchFloat64s(h.buckets, value)
+	h.with(labelValues, func(s *histogram) {
+		if i < len(s.counts) {
+			s.counts[i]++
+		}
+		s.sum += value
+		s.count++
+	})
+}
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	h.header(w)
+	for _, s := range h.sorted() {
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += s.value.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, h.labelPairs(s.values, "le", formatFloat(bound)), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, h.labelPairs(s.values, "le", "+Inf"), s.value.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.metricName, h.labelPairs(s.values), formatFloat(s.value.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.metricName, h.labelPairs(s.values), s.value.count)
+	}
+}
+
+// Gauge is a single value that can go up and down
+type Gauge struct {
+	desc
+	mu    sync.Mutex
+	value float64
+}
+
+// NewGauge registers a gauge without labels
+func (r *Registry) NewGauge(name, help string) *Gauge {
+	g := &Gauge{desc: desc{name, help, "gauge", nil}}
+	r.register(g)
+	return g
+}
+
+// Add adds delta, which may be negative
+func (g *Gauge) Add(delta float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.value += delta
+}
+
+// Inc adds one
+func (g *Gauge) Inc() { g.Add(1) }
+
+// Dec subtracts one
+func (g *Gauge) Dec() { g.Add(-1) }
+
+func (g *Gauge) write(w *bufio.Writer) {
+	g.mu.Lock()
+	value := g.value
+	g.mu.Unlock()
+
+	g.header(w)
+	fmt.Fprintf(w, "%s %s\n", g.metricName, formatFloat(value))
+}
+
+// funcMetric reads its value from a function at scrape time
+type funcMetric struct {
+	desc
+	fn func() float64
+}
+
+// NewGaugeFunc registers a gauge whose value is fn() at scrape time
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) {
+	r.register(&funcMetric{desc{name, help, "gauge", nil}, fn})
+}
+
+// NewCounterFunc registers a counter whose value is fn() at scrape time;
+// fn must never decrease
+func (r *Registry) NewCounterFunc(name, help string, fn func() float64) {
+	r.register(&funcMetric{desc{name, help, "counter", nil}, fn})
+}
+
+func (f *funcMetric) write(w *bufio.Writer) {
+	f.header(w)
+	fmt.Fprintf(w, "%s %s\n", f.metricName, formatFloat(f.fn()))
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
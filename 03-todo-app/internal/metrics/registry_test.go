@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func render(t *testing.T, r *Registry) string {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+	return string(body)
+}
+
+func TestExposition(t *testing.T) {
+	r := NewRegistry()
+
+	counter := r.NewCounterVec("jobs_total", "Jobs run.", "queue", "result")
+	counter.Inc("mail", "ok")
+	counter.Add(2, "mail", "ok")
+	counter.Inc(`we"ird\`, "fail")
+
+	histogram := r.NewHistogramVec("job_seconds", "Job latency.", []float64{1, 0.1}, "queue")
+	histogram.Observe(0.05, "mail")
+	histogram.Observe(0.1, "mail")
+	histogram.Observe(3, "mail")
+
+	gauge := r.NewGauge("jobs_running", "Jobs running\nnow.")
+	gauge.Inc()
+	gauge.Inc()
+	gauge.Dec()
+
+	r.NewCounterFunc("ticks_total", "Ticks.", func() float64 { return 42 })
+
+	want := `# HELP jobs_total Jobs run.
+# TYPE jobs_total counter
+jobs_total{queue="mail",result="ok"} 3
+jobs_total{queue="we\"ird\\",result="fail"} 1
+# HELP job_seconds Job latency.
+# TYPE job_seconds histogram
+job_seconds_bucket{queue="mail",le="0.1"} 2
+job_seconds_bucket{queue="mail",le="1"} 2
+job_seconds_bucket{queue="mail",le="+Inf"} 3
+job_seconds_sum{queue="mail"} 3.15
+job_seconds_count{queue="mail"} 3
+# HELP jobs_running Jobs running\nnow.
+# TYPE jobs_running gauge
+jobs_running 1
+# HELP ticks_total Ticks.
+# TYPE ticks_total counter
+ticks_total 42
+`
+	if got := render(t, r); got != want {
+		t.Errorf("Unexpected exposition:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRegisterTwicePanics(t *testing.T) {
+	r := NewRegistry()
+	r.NewGauge("up", "Up.")
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a panic for a duplicate name")
+		}
+	}()
+	r.NewGaugeFunc("up", "Up again.", func() float64 { return 1 })
+}
+
+func TestDBMetrics(t *testing.T) {
+	r := NewRegistry()
+	db := NewDB(r)
+
+	db.ObserveOperation("Todo", "query", 20*time.Millisecond, nil)
+	db.ObserveOperation("Todo", "query", time.Millisecond, errors.New("timeout"))
+
+	out := render(t, r)
+	for _, line := range []string{
+		`chameleondb_operation_duration_seconds_count{entity="Todo",operation="query"} 2`,
+		`chameleondb_operation_duration_seconds_bucket{entity="Todo",operation="query",le="0.005"} 1`,
+		`chameleondb_operation_errors_total{entity="Todo",operation="query"} 1`,
+	} {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("Expected %s in\n%s", line, out)
+		}
+	}
+}
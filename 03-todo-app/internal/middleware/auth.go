@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/auth"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/authz"
+)
+
+// RequireAuth rejects requests without a valid Bearer access token and
+// stores the authenticated user ID and their authz.Principal in the
+// request context
+func RequireAuth(tokens *auth.TokenManager) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get("Authorization")
+			token, ok := strings.CutPrefix(header, "Bearer ")
+			if !ok || token == "" {
+				unauthorized(w, "Missing bearer token")
+				return
+			}
+
+			claims, err := tokens.Verify(token)
+			if err != nil {
+				switch err {
+				case auth.ErrExpiredToken:
+					unauthorized(w, "Token expired")
+				default:
+					unauthorized(w, "Invalid token")
+				}
+				return
+			}
+
+			logUserID(r.Context(), claims.Subject)
+			ctx := auth.WithUserID(r.Context(), claims.Subject)
+			ctx = authz.WithPrincipal(ctx, authz.Principal{UserID: claims.Subject, Role: claims.Role})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// unauthorized writes a 401 using the same JSON shape as the handlers
+func unauthorized(w http.ResponseWriter, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("WWW-Authenticate", `Bearer realm="todo-app"`)
+	w.WriteHeader(http.StatusUnauthorized)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
@@ -1,29 +1,109 @@
 package middleware
 
-import "net/http"
+import (
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
 
-// CORS middleware para permitir peticiones del frontend
-// SOLO PARA DESARROLLO, NO USAR EN PRODUCCIÓN SIN CONFIGURAR ORÍGENES ESPECÍFICOS!
-func CORS(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Permitir cualquier origen (para desarrollo)
-		w.Header().Set("Access-Control-Allow-Origin", "*")
+// CORSPolicy configures the CORS middleware
+type CORSPolicy struct {
+	// AllowedOrigins are exact origins ("http://localhost:5500") or
+	// patterns where * matches within the host or port
+	// ("http://localhost:*", "https://*.example.com"). A lone "*" allows
+	// any origin, but then credentials are never allowed.
+	AllowedOrigins []string
 
-		// Métodos permitidos
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
 
-		// Headers permitidos
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+	// MaxAge is how long browsers may cache a preflight response; zero
+	// leaves it to the browser
+	MaxAge time.Duration
+}
+
+// CORS applies policy. The matching origin is echoed back rather than
+// "*", so responses vary by Origin. Preflight requests are answered here
+// and never reach the router.
+func CORS(policy CORSPolicy) func(http.Handler) http.Handler {
+	anyOrigin := false
+	for _, origin := range policy.AllowedOrigins {
+		if origin == "*" {
+			anyOrigin = true
+		}
+	}
 
-		// Permitir credenciales (cookies, auth headers)
-		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	methods := strings.Join(policy.AllowedMethods, ", ")
+	headers := strings.Join(policy.AllowedHeaders, ", ")
+	exposed := strings.Join(policy.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(policy.MaxAge.Seconds()))
 
-		// Manejar preflight requests (OPTIONS)
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
+	allowed := func(origin string) bool {
+		if anyOrigin {
+			return true
 		}
+		origin = strings.ToLower(origin)
+		for _, pattern := range policy.AllowedOrigins {
+			if ok, _ := path.Match(strings.ToLower(pattern), origin); ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			origin := r.Header.Get("Origin")
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+			if !anyOrigin {
+				h.Add("Vary", "Origin")
+			}
+			if preflight {
+				h.Add("Vary", "Access-Control-Request-Method")
+				h.Add("Vary", "Access-Control-Request-Headers")
+			}
+
+			if origin == "" || !allowed(origin) {
+				// Without CORS headers the browser blocks the response
+				if preflight {
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if anyOrigin {
+				h.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				h.Set("Access-Control-Allow-Origin", origin)
+				if policy.AllowCredentials {
+					h.Set("Access-Control-Allow-Credentials", "true")
+				}
+			}
+
+			if !preflight {
+				if exposed != "" {
+					h.Set("Access-Control-Expose-Headers", exposed)
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
 
-		next.ServeHTTP(w, r)
-	})
+			h.Set("Access-Control-Allow-Methods", methods)
+			if headers != "" {
+				h.Set("Access-Control-Allow-Headers", headers)
+			}
+			if policy.MaxAge > 0 {
+				h.Set("Access-Control-Max-Age", maxAge)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
 }
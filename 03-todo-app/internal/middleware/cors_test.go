@@ -0,0 +1,124 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCORS(t *testing.T) {
+	policy := CORSPolicy{
+		AllowedOrigins: []string{"http://localhost:*", "https://*.example.com", "https://app.test"},
+		AllowedMethods: []string{"GET", "POST"},
+		AllowedHeaders: []string{"Content-Type", "Authorization"},
+		ExposedHeaders: []string{"ETag"},
+		MaxAge:         10 * time.Minute,
+	}
+
+	tests := []struct {
+		name       string
+		policy     CORSPolicy
+		method     string
+		origin     string
+		preflight  bool
+		wantStatus int
+		want       map[string]string // "" means the header must be absent
+		wantVary   []string
+	}{
+		{name: "allowed pattern echoed", policy: policy, method: "GET", origin: "http://localhost:5500",
+			wantStatus: http.StatusTeapot,
+			want: map[string]string{
+				"Access-Control-Allow-Origin":      "http://localhost:5500",
+				"Access-Control-Expose-Headers":    "ETag",
+				"Access-Control-Allow-Credentials": "",
+				"Access-Control-Allow-Methods":     "",
+			},
+			wantVary: []string{"Origin"}},
+		{name: "subdomain pattern", policy: policy, method: "GET", origin: "https://api.example.com",
+			wantStatus: http.StatusTeapot,
+			want:       map[string]string{"Access-Control-Allow-Origin": "https://api.example.com"}},
+		{name: "exact origin, any case", policy: policy, method: "GET", origin: "https://APP.test",
+			wantStatus: http.StatusTeapot,
+			want:       map[string]string{"Access-Control-Allow-Origin": "https://APP.test"}},
+		{name: "other origin gets no headers", policy: policy, method: "GET", origin: "https://evil.test",
+			wantStatus: http.StatusTeapot,
+			want:       map[string]string{"Access-Control-Allow-Origin": "", "Access-Control-Expose-Headers": ""},
+			wantVary:   []string{"Origin"}},
+		{name: "pattern does not cross a slash", policy: policy, method: "GET", origin: "http://localhost:1/x",
+			wantStatus: http.StatusTeapot,
+			want:       map[string]string{"Access-Control-Allow-Origin": ""}},
+		{name: "same-origin request passes", policy: policy, method: "GET",
+			wantStatus: http.StatusTeapot,
+			want:       map[string]string{"Access-Control-Allow-Origin": ""}},
+		{name: "preflight answered", policy: policy, method: "OPTIONS", origin: "http://localhost:3000", preflight: true,
+			wantStatus: http.StatusNoContent,
+			want: map[string]string{
+				"Access-Control-Allow-Origin":   "http://localhost:3000",
+				"Access-Control-Allow-Methods":  "GET, POST",
+				"Access-Control-Allow-Headers":  "Content-Type, Authorization",
+				"Access-Control-Max-Age":        "600",
+				"Access-Control-Expose-Headers": "",
+			},
+			wantVary: []string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"}},
+		{name: "preflight from other origin", policy: policy, method: "OPTIONS", origin: "https://evil.test", preflight: true,
+			wantStatus: http.StatusNoContent,
+			want:       map[string]string{"Access-Control-Allow-Origin": "", "Access-Control-Allow-Methods": ""}},
+		{name: "plain OPTIONS reaches the handler", policy: policy, method: "OPTIONS", origin: "http://localhost:3000",
+			wantStatus: http.StatusTeapot,
+			want:       map[string]string{"Access-Control-Allow-Origin": "http://localhost:3000"}},
+		{name: "credentials echo the origin", method: "GET", origin: "https://app.test",
+			policy:     CORSPolicy{AllowedOrigins: []string{"https://app.test"}, AllowCredentials: true},
+			wantStatus: http.StatusTeapot,
+			want: map[string]string{
+				"Access-Control-Allow-Origin":      "https://app.test",
+				"Access-Control-Allow-Credentials": "true",
+			}},
+		{name: "wildcard never allows credentials", method: "GET", origin: "https://anywhere.test",
+			policy:     CORSPolicy{AllowedOrigins: []string{"*"}, AllowCredentials: true},
+			wantStatus: http.StatusTeapot,
+			want: map[string]string{
+				"Access-Control-Allow-Origin":      "*",
+				"Access-Control-Allow-Credentials": "",
+				"Vary":                             "",
+			}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := CORS(tt.policy)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusTeapot)
+			}))
+
+			req := httptest.NewRequest(tt.method, "/todos", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.preflight {
+				req.Header.Set("Access-Control-Request-Method", "POST")
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, rec.Code)
+			}
+			for name, want := range tt.want {
+				if got := rec.Header().Get(name); got != want {
+					t.Errorf("Expected %s %q, got %q", name, want, got)
+				}
+			}
+			if tt.wantVary != nil {
+				got := rec.Header().Values("Vary")
+				if len(got) != len(tt.wantVary) {
+					t.Fatalf("Expected Vary %v, got %v", tt.wantVary, got)
+				}
+				for i := range got {
+					if got[i] != tt.wantVary[i] {
+						t.Errorf("Expected Vary %v, got %v", tt.wantVary, got)
+					}
+				}
+			}
+		})
+	}
+}
@@ -1,41 +1,95 @@
 package middleware
 
 import (
-	"fmt"
+	"context"
+	"log/slog"
 	"net/http"
 	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// RequestLogger logs HTTP requests
-func RequestLogger(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
-
-		// Wrap response writer to capture status code
-		wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
-
-		// Call next handler
-		next.ServeHTTP(wrapped, r)
-
-		// Log request
-		duration := time.Since(start)
-		fmt.Printf("[%s] %s %s %d %v\n",
-			r.Method,
-			r.RequestURI,
-			wrapped.statusCode,
-			duration.Milliseconds(),
-			"ms",
-		)
-	})
+// RequestLogger logs one record per request with its ID, trace ID, the
+// authenticated user, status, response size and latency. 5xx responses are logged at
+// error level, everything else at info.
+func RequestLogger(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			// Wrap response writer to capture status code and size
+			wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+			fields := &requestFields{}
+			ctx := context.WithValue(r.Context(), requestFieldsKey{}, fields)
+
+			next.ServeHTTP(wrapped, r.WithContext(ctx))
+
+			level := slog.LevelInfo
+			if wrapped.statusCode >= http.StatusInternalServerError {
+				level = slog.LevelError
+			}
+
+			attrs := []slog.Attr{
+				slog.String("request_id", middleware.GetReqID(r.Context())),
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", wrapped.statusCode),
+				slog.Int64("bytes", wrapped.bytes),
+				slog.Duration("latency", time.Since(start)),
+				slog.String("remote_addr", r.RemoteAddr),
+			}
+			if fields.userID != "" {
+				attrs = append(attrs, slog.String("user_id", fields.userID))
+			}
+			if sc := trace.SpanContextFromContext(r.Context()); sc.IsValid() {
+				attrs = append(attrs, slog.String("trace_id", sc.TraceID().String()))
+			}
+
+			logger.LogAttrs(r.Context(), level, "request", attrs...)
+		})
+	}
+}
+
+// requestFields collects what inner middleware learns about a request for
+// RequestLogger, which cannot see the contexts they derive
+type requestFields struct {
+	userID string
 }
 
-// responseWriter wraps http.ResponseWriter to capture status code
+type requestFieldsKey struct{}
+
+// logUserID records the authenticated user for the request log line
+func logUserID(ctx context.Context, userID string) {
+	if fields, ok := ctx.Value(requestFieldsKey{}).(*requestFields); ok {
+		fields.userID = userID
+	}
+}
+
+// responseWriter wraps http.ResponseWriter to capture status code and size
 type responseWriter struct {
 	http.ResponseWriter
-	statusCode int
+	statusCode  int
+	bytes       int64
+	wroteHeader bool
 }
 
 func (w *responseWriter) WriteHeader(statusCode int) {
-	w.statusCode = statusCode
+	if !w.wroteHeader {
+		w.statusCode = statusCode
+		w.wroteHeader = true
+	}
 	w.ResponseWriter.WriteHeader(statusCode)
 }
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/auth"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/authz"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+func TestRequestLoggerFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	tokens := auth.NewTokenManager([]byte("secret"), time.Minute)
+
+	handler := middleware.RequestID(RequestLogger(logger)(RequireAuth(tokens)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte("hello"))
+		}),
+	)))
+
+	token, _, err := tokens.Issue("user-1", authz.RoleUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/things?x=1", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("Expected one JSON record, got %q: %v", buf.String(), err)
+	}
+
+	want := map[string]interface{}{
+		"level":   "INFO",
+		"msg":     "request",
+		"method":  "POST",
+		"path":    "/things",
+		"status":  float64(http.StatusCreated),
+		"bytes":   float64(5),
+		"user_id": "user-1",
+	}
+	for key, value := range want {
+		if record[key] != value {
+			t.Errorf("Expected %s=%v, got %v", key, value, record[key])
+		}
+	}
+	if id, _ := record["request_id"].(string); id == "" {
+		t.Errorf("Expected a request_id, got %v", record)
+	}
+	if _, ok := record["latency"]; !ok {
+		t.Errorf("Expected a latency, got %v", record)
+	}
+}
+
+func TestRequestLoggerServerErrors(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	handler := RequestLogger(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		w.WriteHeader(http.StatusOK) // superfluous, ignored by net/http
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatal(err)
+	}
+	if record["level"] != "ERROR" || record["status"] != float64(http.StatusBadGateway) {
+		t.Errorf("Expected an error record for 502, got %v", record)
+	}
+	if _, ok := record["user_id"]; ok {
+		t.Errorf("Expected no user_id for an anonymous request, got %v", record)
+	}
+}
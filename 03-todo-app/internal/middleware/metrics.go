@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/metrics"
+)
+
+// unmatchedRoute labels requests no route matched, so unknown paths cannot
+// grow the number of series
+const unmatchedRoute = "unmatched"
+
+// otherMethod labels requests with a method outside standardMethods, so
+// clients cannot grow the number of series with made-up methods
+const otherMethod = "OTHER"
+
+// standardMethods are the request methods recorded under their own name
+var standardMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodConnect: true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+}
+
+// methodLabel returns the method label for r
+func methodLabel(r *http.Request) string {
+	if standardMethods[r.Method] {
+		return r.Method
+	}
+	return otherMethod
+}
+
+// Metrics records request counts, latency and in-flight requests by chi
+// route pattern rather than raw path, and by method with non-standard ones
+// folded into OTHER
+func Metrics(m *metrics.HTTP) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			done := m.Start(methodLabel(r))
+			wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+
+			defer func() {
+				route := unmatchedRoute
+				if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+					route = rctx.RoutePattern()
+				}
+				done(route, wrapped.statusCode)
+			}()
+
+			next.ServeHTTP(wrapped, r)
+		})
+	}
+}
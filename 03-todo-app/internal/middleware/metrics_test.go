@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/metrics"
+)
+
+func TestMetricsMethodLabel(t *testing.T) {
+	registry := metrics.NewRegistry()
+	handler := Metrics(metrics.NewHTTP(registry))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, method := range []string{http.MethodGet, "PROPFIND", "X-" + strings.Repeat("A", 40)} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, "/things", nil))
+	}
+
+	rec := httptest.NewRecorder()
+	registry.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+	exposition := string(body)
+
+	for _, want := range []string{
+		`http_requests_total{method="GET",route="unmatched",status="200"} 1`,
+		`http_requests_total{method="OTHER",route="unmatched",status="200"} 2`,
+	} {
+		if !strings.Contains(exposition, want) {
+			t.Errorf("Expected %q in:\n%s", want, exposition)
+		}
+	}
+	if strings.Contains(exposition, "PROPFIND") {
+		t.Errorf("Expected non-standard methods folded into OTHER, got:\n%s", exposition)
+	}
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/passwordreset"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/session"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/todo"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/user"
+	"github.com/chameleon-db/chameleondb/chameleon/pkg/engine"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// dbErrorKind classifies a failed database call
+type dbErrorKind int
+
+const (
+	dbErrOther         dbErrorKind = iota
+	dbErrUnique                    // unique_violation (23505)
+	dbErrNotNull                   // not_null_violation (23502) or a nil required field
+	dbErrForeignKey                // foreign_key_violation (23503)
+	dbErrSerialization             // serialization_failure (40001) or deadlock_detected (40P01)
+	dbErrInvalidValue              // invalid_text_representation (22P02), e.g. a malformed UUID
+)
+
+// PostgreSQL error codes, see https://www.postgresql.org/docs/current/errcodes-appendix.html
+var pgErrorKinds = map[string]dbErrorKind{
+	"23505": dbErrUnique,
+	"23502": dbErrNotNull,
+	"23503": dbErrForeignKey,
+	"40001": dbErrSerialization,
+	"40P01": dbErrSerialization,
+	"22P02": dbErrInvalidValue,
+}
+
+// classify inspects an error returned by the engine or by pgx and reports
+// its kind and, when known, the offending field (or constraint name)
+func classify(err error) (dbErrorKind, string) {
+	if err == nil {
+		return dbErrOther, ""
+	}
+
+	// Constraint violations the engine already mapped to its own types
+	var unique *engine.UniqueConstraintError
+	if errors.As(err, &unique) {
+		return dbErrUnique, unique.Field
+	}
+	var notNull *engine.NotNullError
+	if errors.As(err, &notNull) {
+		return dbErrNotNull, notNull.Field
+	}
+	var foreignKey *engine.ForeignKeyError
+	if errors.As(err, &foreignKey) {
+		return dbErrForeignKey, foreignKey.Field
+	}
+	var format *engine.FieldFormatError
+	if errors.As(err, &format) {
+		return dbErrInvalidValue, format.Field
+	}
+
+	// Raw pgx errors, from SQL run outside the engine
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		if kind, ok := pgErrorKinds[pgErr.Code]; ok {
+			if pgErr.ColumnName != "" {
+				return kind, pgErr.ColumnName
+			}
+			return kind, pgErr.ConstraintName
+		}
+		return dbErrOther, ""
+	}
+
+	// The engine flattens other PostgreSQL errors into "... (code: XXXXX)"
+	msg := err.Error()
+	if i := strings.LastIndex(msg, "(code: "); i >= 0 {
+		code := strings.TrimSuffix(msg[i+len("(code: "):], ")")
+		if kind, ok := pgErrorKinds[code]; ok {
+			return kind, ""
+		}
+	}
+
+	return dbErrOther, ""
+}
+
+// todoError translates a database error into a todo domain error.
+// Errors without a domain meaning are wrapped with the failed operation.
+func todoError(op string, err error) error {
+	switch kind, _ := classify(err); kind {
+	case dbErrUnique, dbErrSerialization:
+		return todo.ErrConflict
+	case dbErrForeignKey:
+		return todo.ErrInvalidUserID
+	case dbErrNotNull, dbErrInvalidValue:
+		return todo.ErrInvalidInput
+	}
+	return fmt.Errorf("failed to %s: %w", op, err)
+}
+
+// userError translates a database error into a user domain error.
+// Errors without a domain meaning are wrapped with the failed operation.
+func userError(op string, err error) error {
+	switch kind, field := classify(err); kind {
+	case dbErrUnique:
+		if strings.Contains(field, "email") {
+			return user.ErrDuplicateEmail
+		}
+		return user.ErrConflict
+	case dbErrSerialization:
+		return user.ErrConflict
+	case dbErrNotNull, dbErrForeignKey, dbErrInvalidValue:
+		return user.ErrInvalidInput
+	}
+	return fmt.Errorf("failed to %s: %w", op, err)
+}
+
+// sessionError translates a database error into a session domain error.
+// Errors without a domain meaning are wrapped with the failed operation.
+func sessionError(op string, err error) error {
+	switch kind, _ := classify(err); kind {
+	case dbErrUnique, dbErrSerialization:
+		return session.ErrConflict
+	case dbErrNotNull, dbErrForeignKey, dbErrInvalidValue:
+		return session.ErrInvalidInput
+	}
+	return fmt.Errorf("failed to %s: %w", op, err)
+}
+
+// passwordResetError translates a database error into a passwordreset
+// domain error. Errors without a domain meaning are wrapped with the failed
+// operation.
+func passwordResetError(op string, err error) error {
+	switch kind, _ := classify(err); kind {
+	case dbErrUnique, dbErrSerialization:
+		return passwordreset.ErrConflict
+	case dbErrNotNull, dbErrForeignKey, dbErrInvalidValue:
+		return passwordreset.ErrInvalidInput
+	}
+	return fmt.Errorf("failed to %s: %w", op, err)
+}
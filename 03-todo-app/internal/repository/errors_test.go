@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/todo"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/user"
+	"github.com/chameleon-db/chameleondb/chameleon/pkg/engine"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name  string
+		err   error
+		kind  dbErrorKind
+		field string
+	}{
+		{"engine unique", fmt.Errorf("insert: %w", &engine.UniqueConstraintError{Field: "email"}), dbErrUnique, "email"},
+		{"engine not null", &engine.NotNullError{Field: "title"}, dbErrNotNull, "title"},
+		{"engine foreign key", &engine.ForeignKeyError{Field: "user_id"}, dbErrForeignKey, "user_id"},
+		{"engine format", &engine.FieldFormatError{Field: "id", Format: "UUID"}, dbErrInvalidValue, "id"},
+		{"pgx unique", &pgconn.PgError{Code: "23505", ConstraintName: "users_email_key"}, dbErrUnique, "users_email_key"},
+		{"pgx serialization", fmt.Errorf("commit: %w", &pgconn.PgError{Code: "40001"}), dbErrSerialization, ""},
+		{"pgx deadlock", &pgconn.PgError{Code: "40P01"}, dbErrSerialization, ""},
+		{"flattened serialization", errors.New("UPDATE failed: could not serialize access (code: 40001)"), dbErrSerialization, ""},
+		{"unrelated pgx", &pgconn.PgError{Code: "42601"}, dbErrOther, ""},
+		{"unrelated", errors.New("connection refused"), dbErrOther, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kind, field := classify(tt.err)
+			if kind != tt.kind || field != tt.field {
+				t.Errorf("Expected (%d, %q), got (%d, %q)", tt.kind, tt.field, kind, field)
+			}
+		})
+	}
+}
+
+func TestUserErrorMapsDuplicateEmail(t *testing.T) {
+	err := userError("create user", &engine.UniqueConstraintError{Field: "email"})
+	if err != user.ErrDuplicateEmail {
+		t.Errorf("Expected ErrDuplicateEmail, got %v", err)
+	}
+
+	err = userError("update user", &pgconn.PgError{Code: "40001"})
+	if err != user.ErrConflict {
+		t.Errorf("Expected ErrConflict, got %v", err)
+	}
+}
+
+func TestTodoErrorMapping(t *testing.T) {
+	if err := todoError("create todo", &engine.ForeignKeyError{Field: "user_id"}); err != todo.ErrInvalidUserID {
+		t.Errorf("Expected ErrInvalidUserID, got %v", err)
+	}
+
+	if err := todoError("create todo", &engine.NotNullError{Field: "title"}); err != todo.ErrInvalidInput {
+		t.Errorf("Expected ErrInvalidInput, got %v", err)
+	}
+
+	cause := errors.New("connection refused")
+	if err := todoError("create todo", cause); !errors.Is(err, cause) {
+		t.Errorf("Expected unclassified error to be wrapped, got %v", err)
+	}
+}
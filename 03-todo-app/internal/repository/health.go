@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/health"
+	"github.com/chameleon-db/chameleondb/chameleon/pkg/engine"
+)
+
+// PoolStats is the connection pool snapshot reported by DatabaseCheck
+type PoolStats struct {
+	TotalConns    int32 `json:"total_conns"`
+	IdleConns     int32 `json:"idle_conns"`
+	AcquiredConns int32 `json:"acquired_conns"`
+	MaxConns      int32 `json:"max_conns"`
+	AcquireCount  int64 `json:"acquire_count"`
+	EmptyAcquires int64 `json:"empty_acquire_count"`
+}
+
+// DatabaseCheck pings the engine's connection and reports pool stats
+func DatabaseCheck(eng *engine.Engine) health.CheckFunc {
+	return func(ctx context.Context) (interface{}, error) {
+		if !eng.IsConnected() {
+			return nil, fmt.Errorf("not connected")
+		}
+
+		stat := eng.Connector().Pool().Stat()
+		stats := PoolStats{
+			TotalConns:    stat.TotalConns(),
+			IdleConns:     stat.IdleConns(),
+			AcquiredConns: stat.AcquiredConns(),
+			MaxConns:      stat.MaxConns(),
+			AcquireCount:  stat.AcquireCount(),
+			EmptyAcquires: stat.EmptyAcquireCount(),
+		}
+
+		if err := eng.Ping(ctx); err != nil {
+			return stats, fmt.Errorf("ping failed: %w", err)
+		}
+
+		return stats, nil
+	}
+}
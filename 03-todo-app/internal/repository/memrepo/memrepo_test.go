@@ -0,0 +1,80 @@
+package memrepo
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/todo"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/repository/repotest"
+)
+
+func newRepos(t *testing.T) repotest.Repos {
+	store := NewStore()
+	return repotest.Repos{
+		Todos:          NewTodoRepository(store),
+		Users:          NewUserRepository(store),
+		Sessions:       NewSessionRepository(store),
+		PasswordResets: NewPasswordResetRepository(store),
+	}
+}
+
+func TestConformance(t *testing.T) {
+	repotest.Run(t, newRepos)
+}
+
+func TestWithTxRollsBack(t *testing.T) {
+	store := NewStore()
+	users := NewUserRepository(store)
+	todos := NewTodoRepository(store)
+	ctx := context.Background()
+
+	u, _ := users.Create(ctx, "test@example.com", "Test User", "hash")
+	errAbort := errors.New("abort")
+
+	err := store.WithTx(ctx, func(ctx context.Context) error {
+		if _, err := todos.Create(ctx, u.ID.String(), todo.CreateInput{Title: "Rolled back"}); err != nil {
+			return err
+		}
+		if err := users.Delete(ctx, u.ID.String(), nil); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if err != errAbort {
+		t.Fatalf("Expected errAbort, got %v", err)
+	}
+
+	if active, _ := users.IsActive(ctx, u.ID.String()); !active {
+		t.Errorf("Expected delete rolled back")
+	}
+	if list, _ := todos.ListByUser(ctx, u.ID.String(), 0, 0); len(list) != 0 {
+		t.Errorf("Expected create rolled back, got %d todos", len(list))
+	}
+}
+
+func TestConcurrentToggles(t *testing.T) {
+	store := NewStore()
+	users := NewUserRepository(store)
+	todos := NewTodoRepository(store)
+	ctx := context.Background()
+
+	u, _ := users.Create(ctx, "test@example.com", "Test User", "hash")
+	created, _ := todos.Create(ctx, u.ID.String(), todo.CreateInput{Title: "Flip"})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 11; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			todos.Toggle(ctx, created.ID.String(), u.ID.String())
+		}()
+	}
+	wg.Wait()
+
+	got, _ := todos.GetByID(ctx, created.ID.String())
+	if !got.Completed {
+		t.Errorf("Expected completed after an odd number of toggles")
+	}
+}
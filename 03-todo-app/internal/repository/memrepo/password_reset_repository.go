@@ -0,0 +1,117 @@
+package memrepo
+
+import (
+	"context"
+	"time"
+
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/passwordreset"
+	"github.com/google/uuid"
+)
+
+// PasswordResetRepository implements passwordreset.Repository in memory
+type PasswordResetRepository struct {
+	store *Store
+}
+
+// NewPasswordResetRepository creates a password reset repository backed by store
+func NewPasswordResetRepository(store *Store) passwordreset.Repository {
+	return &PasswordResetRepository{store: store}
+}
+
+// Create inserts a new reset token; userID must name an existing user and
+// token hashes are unique
+func (r *PasswordResetRepository) Create(ctx context.Context, userID, tokenHash string, expiresAt time.Time) (*passwordreset.Token, error) {
+	defer r.store.lock(ctx)()
+
+	owner, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, passwordreset.ErrInvalidInput
+	}
+	if _, ok := r.store.users[owner]; !ok {
+		return nil, passwordreset.ErrInvalidInput
+	}
+
+	for _, row := range r.store.resets {
+		if row.TokenHash == tokenHash {
+			return nil, passwordreset.ErrConflict
+		}
+	}
+
+	row := &passwordreset.Token{
+		ID:        uuid.New(),
+		UserID:    owner,
+		TokenHash: tokenHash,
+		ExpiresAt: *storedTime(&expiresAt),
+		CreatedAt: r.store.now(),
+	}
+	r.store.resets[row.ID] = row
+
+	return cloneResetToken(row), nil
+}
+
+// GetByID retrieves a reset token by ID, used or not
+func (r *PasswordResetRepository) GetByID(ctx context.Context, id string) (*passwordreset.Token, error) {
+	defer r.store.lock(ctx)()
+
+	tokenID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, passwordreset.ErrInvalidInput
+	}
+
+	row, ok := r.store.resets[tokenID]
+	if !ok {
+		return nil, passwordreset.ErrNotFound
+	}
+
+	return cloneResetToken(row), nil
+}
+
+// Use marks an unused reset token used
+func (r *PasswordResetRepository) Use(ctx context.Context, id string) error {
+	defer r.store.lock(ctx)()
+
+	tokenID, err := uuid.Parse(id)
+	if err != nil {
+		return passwordreset.ErrInvalidInput
+	}
+
+	row, ok := r.store.resets[tokenID]
+	if !ok || row.UsedAt != nil {
+		return passwordreset.ErrNotFound
+	}
+
+	usedAt := r.store.now()
+	row.UsedAt = &usedAt
+
+	return nil
+}
+
+// UseAll marks every unused reset token of userID used
+func (r *PasswordResetRepository) UseAll(ctx context.Context, userID string) error {
+	defer r.store.lock(ctx)()
+
+	owner, err := uuid.Parse(userID)
+	if err != nil {
+		return passwordreset.ErrInvalidInput
+	}
+
+	now := r.store.now()
+	for _, row := range r.store.resets {
+		if row.UserID == owner && row.UsedAt == nil {
+			usedAt := now
+			row.UsedAt = &usedAt
+		}
+	}
+
+	return nil
+}
+
+// cloneResetToken returns a copy of row that shares no pointers with the store
+func cloneResetToken(row *passwordreset.Token) *passwordreset.Token {
+	t := *row
+	if t.UsedAt != nil {
+		usedAt := *t.UsedAt
+		t.UsedAt = &usedAt
+	}
+	return &t
+}
@@ -0,0 +1,187 @@
+package memrepo
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/session"
+	"github.com/google/uuid"
+)
+
+// SessionRepository implements session.Repository in memory
+type SessionRepository struct {
+	store *Store
+}
+
+// NewSessionRepository creates a session repository backed by store
+func NewSessionRepository(store *Store) session.Repository {
+	return &SessionRepository{store: store}
+}
+
+// Create inserts a new session; userID must name an existing user and
+// token hashes are unique
+func (r *SessionRepository) Create(ctx context.Context, userID, tokenHash string, client session.Client, expiresAt time.Time) (*session.Session, error) {
+	defer r.store.lock(ctx)()
+
+	owner, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, session.ErrInvalidInput
+	}
+	if _, ok := r.store.users[owner]; !ok {
+		return nil, session.ErrInvalidInput
+	}
+
+	for _, row := range r.store.sessions {
+		if row.TokenHash == tokenHash {
+			return nil, session.ErrConflict
+		}
+	}
+
+	now := r.store.now()
+	row := &session.Session{
+		ID:        uuid.New(),
+		UserID:    owner,
+		TokenHash: tokenHash,
+		UserAgent: client.UserAgent,
+		IP:        client.IP,
+		ExpiresAt: *storedTime(&expiresAt),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	r.store.sessions[row.ID] = row
+
+	return cloneSession(row), nil
+}
+
+// GetByID retrieves a session by ID, revoked or not
+func (r *SessionRepository) GetByID(ctx context.Context, id string) (*session.Session, error) {
+	defer r.store.lock(ctx)()
+
+	row, err := r.find(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return cloneSession(row), nil
+}
+
+// ListActive returns user's unrevoked sessions expiring after now, most
+// recently refreshed first
+func (r *SessionRepository) ListActive(ctx context.Context, userID string, now time.Time) ([]session.Session, error) {
+	defer r.store.lock(ctx)()
+
+	owner, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, session.ErrInvalidInput
+	}
+
+	var rows []*session.Session
+	for _, row := range r.store.sessions {
+		if row.UserID == owner && row.Active(now) {
+			rows = append(rows, row)
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].UpdatedAt.After(rows[j].UpdatedAt) })
+
+	out := make([]session.Session, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, *cloneSession(row))
+	}
+
+	return out, nil
+}
+
+// Rotate replaces the token hash of an unrevoked session still holding oldHash
+func (r *SessionRepository) Rotate(ctx context.Context, id, oldHash, newHash string, client session.Client, expiresAt time.Time) (*session.Session, error) {
+	defer r.store.lock(ctx)()
+
+	row, err := r.find(id)
+	if err != nil {
+		return nil, err
+	}
+	if row.RevokedAt != nil || row.TokenHash != oldHash {
+		return nil, session.ErrNotFound
+	}
+
+	row.TokenHash = newHash
+	row.UserAgent = client.UserAgent
+	row.IP = client.IP
+	row.ExpiresAt = *storedTime(&expiresAt)
+	row.UpdatedAt = r.store.now()
+
+	return cloneSession(row), nil
+}
+
+// Revoke revokes an unrevoked session of userID
+func (r *SessionRepository) Revoke(ctx context.Context, id, userID string) error {
+	defer r.store.lock(ctx)()
+
+	owner, err := uuid.Parse(userID)
+	if err != nil {
+		return session.ErrInvalidInput
+	}
+
+	row, err := r.find(id)
+	if err != nil {
+		return err
+	}
+	if row.RevokedAt != nil || row.UserID != owner {
+		return session.ErrNotFound
+	}
+
+	r.revoke(row, r.store.now())
+
+	return nil
+}
+
+// RevokeAll revokes every unrevoked session of userID
+func (r *SessionRepository) RevokeAll(ctx context.Context, userID string) error {
+	defer r.store.lock(ctx)()
+
+	owner, err := uuid.Parse(userID)
+	if err != nil {
+		return session.ErrInvalidInput
+	}
+
+	now := r.store.now()
+	for _, row := range r.store.sessions {
+		if row.UserID == owner && row.RevokedAt == nil {
+			r.revoke(row, now)
+		}
+	}
+
+	return nil
+}
+
+// find returns the session with id. The caller holds the store lock.
+func (r *SessionRepository) find(id string) (*session.Session, error) {
+	sessionID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, session.ErrInvalidInput
+	}
+
+	row, ok := r.store.sessions[sessionID]
+	if !ok {
+		return nil, session.ErrNotFound
+	}
+
+	return row, nil
+}
+
+// revoke marks row revoked at now. The caller holds the store lock.
+func (r *SessionRepository) revoke(row *session.Session, now time.Time) {
+	revokedAt := now
+	row.RevokedAt = &revokedAt
+	row.UpdatedAt = now
+}
+
+// cloneSession returns a copy of row that shares no pointers with the store
+func cloneSession(row *session.Session) *session.Session {
+	s := *row
+	if s.RevokedAt != nil {
+		revokedAt := *s.RevokedAt
+		s.RevokedAt = &revokedAt
+	}
+	return &s
+}
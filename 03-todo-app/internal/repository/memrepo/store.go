@@ -0,0 +1,188 @@
+// Package memrepo implements todo.Repository, user.Repository,
+// session.Repository and passwordreset.Repository in memory.
+//
+// The repositories follow the same semantics as the ChameleonDB ones in
+// package repository (soft delete, pagination, filters, version checks and
+// domain errors), which repotest verifies for both. They are meant for
+// fast, offline tests of services and handlers.
+package memrepo
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/passwordreset"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/session"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/todo"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/user"
+	"github.com/google/uuid"
+)
+
+// Store holds the users, todos, sessions and password reset tokens shared
+// by the repositories, so that deleting a user archives their todos as the
+// database does. It is safe for concurrent use.
+type Store struct {
+	mu       sync.Mutex
+	users    map[uuid.UUID]*userRow
+	todos    map[uuid.UUID]*todoRow
+	sessions map[uuid.UUID]*session.Session
+	resets   map[uuid.UUID]*passwordreset.Token
+	seq      int       // insertion counter, for a stable listing order
+	last     time.Time // last timestamp handed out
+}
+
+// userRow is a stored user
+type userRow struct {
+	user.User
+	seq int
+}
+
+// todoRow is a stored todo plus the archived column the domain type hides
+type todoRow struct {
+	todo.Todo
+	archived bool
+	seq      int
+}
+
+// NewStore creates an empty store
+func NewStore() *Store {
+	return &Store{
+		users:    make(map[uuid.UUID]*userRow),
+		todos:    make(map[uuid.UUID]*todoRow),
+		sessions: make(map[uuid.UUID]*session.Session),
+		resets:   make(map[uuid.UUID]*passwordreset.Token),
+	}
+}
+
+// txKey marks a context running inside Store.WithTx
+type txKey struct{}
+
+// lock locks the store and returns the matching unlock. Inside WithTx the
+// store is already locked for the whole transaction.
+func (s *Store) lock(ctx context.Context) func() {
+	if ctx.Value(txKey{}) != nil {
+		return func() {}
+	}
+	s.mu.Lock()
+	return s.mu.Unlock
+}
+
+// WithTx runs fn atomically, implementing todo.Transactor: the store stays
+// locked while fn runs and its writes are undone unless fn returns nil.
+// As with a database transaction, the context passed to fn must not be
+// used from several goroutines at once.
+func (s *Store) WithTx(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if ctx.Value(txKey{}) != nil {
+		return fn(ctx)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	saved := s.snapshot()
+	committed := false
+	defer func() {
+		if !committed {
+			s.users, s.todos, s.sessions, s.resets, s.seq = saved.users, saved.todos, saved.sessions, saved.resets, saved.seq
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, true)); err != nil {
+		return err
+	}
+
+	committed = true
+	return nil
+}
+
+// snapshot is a copy of the stored rows, taken so a transaction can be
+// rolled back
+type snapshot struct {
+	users    map[uuid.UUID]*userRow
+	todos    map[uuid.UUID]*todoRow
+	sessions map[uuid.UUID]*session.Session
+	resets   map[uuid.UUID]*passwordreset.Token
+	seq      int
+}
+
+// snapshot copies the stored rows
+func (s *Store) snapshot() snapshot {
+	return snapshot{
+		users:    copyRows(s.users),
+		todos:    copyRows(s.todos),
+		sessions: copyRows(s.sessions),
+		resets:   copyRows(s.resets),
+		seq:      s.seq,
+	}
+}
+
+// copyRows shallow-copies every row of a table; the repositories replace
+// pointer fields rather than writing through them
+func copyRows[T any](rows map[uuid.UUID]*T) map[uuid.UUID]*T {
+	out := make(map[uuid.UUID]*T, len(rows))
+	for id, row := range rows {
+		copied := *row
+		out[id] = &copied
+	}
+	return out
+}
+
+// now returns the timestamp for a write, at the database's microsecond
+// precision. Timestamps strictly increase, so every write gets a new
+// version even when two land within the same microsecond.
+func (s *Store) now() time.Time {
+	t := time.Now().UTC().Truncate(time.Microsecond)
+	if !t.After(s.last) {
+		t = s.last.Add(time.Microsecond)
+	}
+	s.last = t
+	return t
+}
+
+// nextSeq returns the insertion position for a new row
+func (s *Store) nextSeq() int {
+	s.seq++
+	return s.seq
+}
+
+// page applies limit and offset the way the SQL repositories do: values
+// below one are not applied
+func page[T any](items []T, limit, offset int) []T {
+	if offset > 0 {
+		if offset >= len(items) {
+			return []T{}
+		}
+		items = items[offset:]
+	}
+	if limit > 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
+
+// sortTodos orders rows by insertion
+func sortTodos(rows []*todoRow) {
+	sort.Slice(rows, func(i, j int) bool { return rows[i].seq < rows[j].seq })
+}
+
+// cloneTodo returns a copy of row that shares no pointers with the store
+func cloneTodo(row *todoRow) *todo.Todo {
+	t := row.Todo
+	if t.Description != nil {
+		description := *t.Description
+		t.Description = &description
+	}
+	if t.DueDate != nil {
+		due := *t.DueDate
+		t.DueDate = &due
+	}
+	return &t
+}
+
+// cloneUser returns a copy of row
+func cloneUser(row *userRow) *user.User {
+	u := row.User
+	return &u
+}
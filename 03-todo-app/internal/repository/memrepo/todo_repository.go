@@ -0,0 +1,289 @@
+package memrepo
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/todo"
+	"github.com/google/uuid"
+)
+
+// TodoRepository implements todo.Repository in memory
+type TodoRepository struct {
+	store *Store
+}
+
+// NewTodoRepository creates a todo repository backed by store
+func NewTodoRepository(store *Store) todo.Repository {
+	return &TodoRepository{store: store}
+}
+
+// Create inserts a new todo; userID must name an existing user
+func (r *TodoRepository) Create(ctx context.Context, userID string, in todo.CreateInput) (*todo.Todo, error) {
+	defer r.store.lock(ctx)()
+
+	owner, err := parseTodoID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := r.store.users[owner]; !ok {
+		return nil, todo.ErrInvalidUserID
+	}
+
+	now := r.store.now()
+	description := in.Description
+	row := &todoRow{
+		Todo: todo.Todo{
+			ID:          uuid.New(),
+			UserID:      owner,
+			Title:       in.Title,
+			Description: &description,
+			DueDate:     storedTime(in.DueDate),
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		},
+		seq: r.store.nextSeq(),
+	}
+	r.store.todos[row.ID] = row
+
+	return cloneTodo(row), nil
+}
+
+// GetByID retrieves todo by ID
+func (r *TodoRepository) GetByID(ctx context.Context, id string) (*todo.Todo, error) {
+	defer r.store.lock(ctx)()
+
+	row, err := r.find(id, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return cloneTodo(row), nil
+}
+
+// GetByIDForUser retrieves todo and validates it belongs to user
+func (r *TodoRepository) GetByIDForUser(ctx context.Context, id, userID string) (*todo.Todo, error) {
+	defer r.store.lock(ctx)()
+
+	row, err := r.find(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return cloneTodo(row), nil
+}
+
+// ListByUser returns user's todos (paginated)
+func (r *TodoRepository) ListByUser(ctx context.Context, userID string, limit, offset int) ([]todo.Todo, error) {
+	return r.ListByUserFiltered(ctx, userID, todo.ListFilter{}, limit, offset)
+}
+
+// ListByUserFiltered returns user's todos matching filter (paginated)
+func (r *TodoRepository) ListByUserFiltered(ctx context.Context, userID string, filter todo.ListFilter, limit, offset int) ([]todo.Todo, error) {
+	defer r.store.lock(ctx)()
+
+	rows, err := r.ownedBy(userID, func(row *todoRow) bool {
+		if filter.Completed != nil && row.Completed != *filter.Completed {
+			return false
+		}
+		// A NULL due date never matches a range, as in SQL
+		if (filter.DueAfter != nil || filter.DueBefore != nil) && row.DueDate == nil {
+			return false
+		}
+		if filter.DueAfter != nil && row.DueDate.Before(*filter.DueAfter) {
+			return false
+		}
+		if filter.DueBefore != nil && !row.DueDate.Before(*filter.DueBefore) {
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return todos(page(rows, limit, offset)), nil
+}
+
+// Update replaces the fields of a todo owned by userID and returns the
+// updated todo
+func (r *TodoRepository) Update(ctx context.Context, id, userID string, in todo.UpdateInput) (*todo.Todo, error) {
+	defer r.store.lock(ctx)()
+
+	row, err := r.writable(id, userID, in.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	description := in.Description
+	row.Title = in.Title
+	row.Description = &description
+	row.Completed = in.Completed
+	row.DueDate = storedTime(in.DueDate)
+	row.UpdatedAt = r.store.now()
+
+	return cloneTodo(row), nil
+}
+
+// Patch updates only the fields set in patch on a todo owned by userID
+// and returns the updated todo
+func (r *TodoRepository) Patch(ctx context.Context, id, userID string, patch todo.Patch) (*todo.Todo, error) {
+	defer r.store.lock(ctx)()
+
+	row, err := r.writable(id, userID, patch.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	if patch.Title != nil {
+		row.Title = *patch.Title
+	}
+	if patch.Description != nil {
+		description := *patch.Description
+		row.Description = &description
+	} else if patch.ClearDescription {
+		row.Description = nil
+	}
+	if patch.Completed != nil {
+		row.Completed = *patch.Completed
+	}
+	if patch.DueDate != nil {
+		row.DueDate = storedTime(patch.DueDate)
+	} else if patch.ClearDueDate {
+		row.DueDate = nil
+	}
+	row.UpdatedAt = r.store.now()
+
+	return cloneTodo(row), nil
+}
+
+// Delete deletes a todo owned by userID
+func (r *TodoRepository) Delete(ctx context.Context, id, userID string, version *time.Time) error {
+	defer r.store.lock(ctx)()
+
+	row, err := r.writable(id, userID, version)
+	if err != nil {
+		return err
+	}
+
+	delete(r.store.todos, row.ID)
+	return nil
+}
+
+// Toggle flips completed on a todo owned by userID and returns the updated todo
+func (r *TodoRepository) Toggle(ctx context.Context, id, userID string) (*todo.Todo, error) {
+	defer r.store.lock(ctx)()
+
+	row, err := r.find(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	row.Completed = !row.Completed
+	row.UpdatedAt = r.store.now()
+
+	return cloneTodo(row), nil
+}
+
+// GetOverdue returns a page of incomplete todos due before now, oldest
+// due first, plus the total number of such todos
+func (r *TodoRepository) GetOverdue(ctx context.Context, userID string, now time.Time, limit, offset int) ([]todo.Todo, int, error) {
+	defer r.store.lock(ctx)()
+
+	rows, err := r.ownedBy(userID, func(row *todoRow) bool {
+		return !row.Completed && row.DueDate != nil && row.DueDate.Before(now)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].DueDate.Before(*rows[j].DueDate) })
+
+	return todos(page(rows, limit, offset)), len(rows), nil
+}
+
+// find returns the visible todo with id, owned by userID unless userID is
+// empty. The caller holds the store lock.
+func (r *TodoRepository) find(id, userID string) (*todoRow, error) {
+	todoID, err := parseTodoID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var owner uuid.UUID
+	if userID != "" {
+		if owner, err = parseTodoID(userID); err != nil {
+			return nil, err
+		}
+	}
+
+	row, ok := r.store.todos[todoID]
+	if !ok || row.archived || (userID != "" && row.UserID != owner) {
+		return nil, todo.ErrNotFound
+	}
+
+	return row, nil
+}
+
+// writable returns the todo a conditional write applies to, failing with
+// ErrVersionMismatch when it changed after the caller read version
+func (r *TodoRepository) writable(id, userID string, version *time.Time) (*todoRow, error) {
+	row, err := r.find(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if version != nil && !row.UpdatedAt.Equal(*version) {
+		return nil, todo.ErrVersionMismatch
+	}
+
+	return row, nil
+}
+
+// ownedBy returns the visible todos of userID that match keep, in
+// insertion order. The caller holds the store lock.
+func (r *TodoRepository) ownedBy(userID string, keep func(*todoRow) bool) ([]*todoRow, error) {
+	owner, err := parseTodoID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []*todoRow
+	for _, row := range r.store.todos {
+		if row.UserID == owner && !row.archived && keep(row) {
+			rows = append(rows, row)
+		}
+	}
+	sortTodos(rows)
+
+	return rows, nil
+}
+
+// parseTodoID parses a todo or user ID given to the todo repository,
+// failing like the database does on malformed input
+func parseTodoID(id string) (uuid.UUID, error) {
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, todo.ErrInvalidInput
+	}
+	return parsed, nil
+}
+
+// storedTime returns t as a timestamp column stores it
+func storedTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	stored := t.UTC().Truncate(time.Microsecond)
+	return &stored
+}
+
+// todos copies rows out of the store
+func todos(rows []*todoRow) []todo.Todo {
+	out := make([]todo.Todo, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, *cloneTodo(row))
+	}
+	return out
+}
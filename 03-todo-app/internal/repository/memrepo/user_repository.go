@@ -0,0 +1,260 @@
+package memrepo
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/authz"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/user"
+	"github.com/google/uuid"
+)
+
+// UserRepository implements user.Repository in memory
+type UserRepository struct {
+	store *Store
+}
+
+// NewUserRepository creates a user repository backed by store
+func NewUserRepository(store *Store) user.Repository {
+	return &UserRepository{store: store}
+}
+
+// Create inserts a new active user with the user role; emails are unique across all users,
+// deleted ones included
+func (r *UserRepository) Create(ctx context.Context, email, name, passwordHash string) (*user.User, error) {
+	defer r.store.lock(ctx)()
+
+	for _, row := range r.store.users {
+		if row.Email == email {
+			return nil, user.ErrDuplicateEmail
+		}
+	}
+
+	now := r.store.now()
+	row := &userRow{
+		User: user.User{
+			ID:           uuid.New(),
+			Email:        email,
+			Name:         name,
+			PasswordHash: passwordHash,
+			Role:         authz.RoleUser,
+			IsActive:     true,
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		},
+		seq: r.store.nextSeq(),
+	}
+	r.store.users[row.ID] = row
+
+	return cloneUser(row), nil
+}
+
+// GetByEmail retrieves an active user by email
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*user.User, error) {
+	defer r.store.lock(ctx)()
+
+	for _, row := range r.store.users {
+		if row.Email == email && row.IsActive {
+			return cloneUser(row), nil
+		}
+	}
+
+	return nil, user.ErrNotFound
+}
+
+// GetByID retrieves an active user by ID
+func (r *UserRepository) GetByID(ctx context.Context, id string) (*user.User, error) {
+	defer r.store.lock(ctx)()
+
+	row, err := r.find(id)
+	if err != nil {
+		return nil, err
+	}
+	if !row.IsActive {
+		return nil, user.ErrNotFound
+	}
+
+	return cloneUser(row), nil
+}
+
+// List returns active users in creation order (paginated)
+func (r *UserRepository) List(ctx context.Context, limit, offset int) ([]user.User, error) {
+	defer r.store.lock(ctx)()
+
+	var rows []*userRow
+	for _, row := range r.store.users {
+		if row.IsActive {
+			rows = append(rows, row)
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].seq < rows[j].seq })
+
+	rows = page(rows, limit, offset)
+	out := make([]user.User, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, *cloneUser(row))
+	}
+
+	return out, nil
+}
+
+// Update updates user (name only) and returns the updated user
+func (r *UserRepository) Update(ctx context.Context, id, name string, version *time.Time) (*user.User, error) {
+	defer r.store.lock(ctx)()
+
+	row, err := r.writable(id, version)
+	if err != nil {
+		return nil, err
+	}
+
+	row.Name = name
+	row.UpdatedAt = r.store.now()
+
+	return cloneUser(row), nil
+}
+
+// Patch updates only the profile fields set in patch and returns the updated user
+func (r *UserRepository) Patch(ctx context.Context, id string, patch user.Patch) (*user.User, error) {
+	defer r.store.lock(ctx)()
+
+	row, err := r.writable(id, patch.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	if patch.Name != nil {
+		row.Name = *patch.Name
+	}
+	row.UpdatedAt = r.store.now()
+
+	return cloneUser(row), nil
+}
+
+// Delete soft-deletes user and archives their todos
+func (r *UserRepository) Delete(ctx context.Context, id string, version *time.Time) error {
+	defer r.store.lock(ctx)()
+
+	row, err := r.writable(id, version)
+	if err != nil {
+		return err
+	}
+
+	now := r.store.now()
+	row.IsActive = false
+	row.UpdatedAt = now
+	r.archiveTodos(row.ID, true, now)
+
+	return nil
+}
+
+// Restore reactivates a soft-deleted user and unarchives their todos
+func (r *UserRepository) Restore(ctx context.Context, id string) error {
+	defer r.store.lock(ctx)()
+
+	row, err := r.find(id)
+	if err != nil {
+		return err
+	}
+	if row.IsActive {
+		return user.ErrNotFound
+	}
+
+	now := r.store.now()
+	row.IsActive = true
+	row.UpdatedAt = now
+	r.archiveTodos(row.ID, false, now)
+
+	return nil
+}
+
+// SetRole changes an active user's role and returns the updated user
+func (r *UserRepository) SetRole(ctx context.Context, id string, role authz.Role) (*user.User, error) {
+	defer r.store.lock(ctx)()
+
+	row, err := r.writable(id, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	row.Role = role
+	row.UpdatedAt = r.store.now()
+
+	return cloneUser(row), nil
+}
+
+// SetPassword replaces an active user's password hash
+func (r *UserRepository) SetPassword(ctx context.Context, id, passwordHash string) error {
+	defer r.store.lock(ctx)()
+
+	row, err := r.writable(id, nil)
+	if err != nil {
+		return err
+	}
+
+	row.PasswordHash = passwordHash
+	row.UpdatedAt = r.store.now()
+
+	return nil
+}
+
+// IsActive reports whether user exists and is active
+func (r *UserRepository) IsActive(ctx context.Context, id string) (bool, error) {
+	defer r.store.lock(ctx)()
+
+	row, err := r.find(id)
+	if err != nil {
+		return false, nil
+	}
+
+	return row.IsActive, nil
+}
+
+// find returns the user with id, active or not. The caller holds the store lock.
+func (r *UserRepository) find(id string) (*userRow, error) {
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, user.ErrInvalidInput
+	}
+
+	row, ok := r.store.users[userID]
+	if !ok {
+		return nil, user.ErrNotFound
+	}
+
+	return row, nil
+}
+
+// writable returns the active user a conditional write applies to
+func (r *UserRepository) writable(id string, version *time.Time) (*userRow, error) {
+	row, err := r.find(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !row.IsActive || (version != nil && !row.UpdatedAt.Equal(*version)) {
+		return nil, r.missed(row)
+	}
+
+	return row, nil
+}
+
+// missed explains a conditional write on row that matched nothing, the
+// way the SQL repository's re-read does
+func (r *UserRepository) missed(row *userRow) error {
+	if !row.IsActive {
+		return user.ErrNotFound
+	}
+	return user.ErrVersionMismatch
+}
+
+// archiveTodos sets the archived flag on every todo of userID that does
+// not have it yet. The caller holds the store lock.
+func (r *UserRepository) archiveTodos(userID uuid.UUID, archived bool, now time.Time) {
+	for _, row := range r.store.todos {
+		if row.UserID == userID && row.archived != archived {
+			row.archived = archived
+			row.UpdatedAt = now
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/chameleon-db/chameleondb/chameleon/pkg/engine"
+)
+
+// clock is the time source for created_at and updated_at; tests replace it
+var clock = time.Now
+
+// timestamp returns the current time as stored in timestamp columns.
+// Postgres keeps microseconds and pgx drops the zone of timestamp values,
+// so the value is truncated and in UTC to round-trip exactly (updated_at
+// doubles as the row version behind ETags).
+func timestamp() time.Time {
+	return clock().UTC().Truncate(time.Microsecond)
+}
+
+// touch is the hook applied to every UPDATE in this package: it bumps
+// updated_at so the column always reflects the last write
+func touch(m engine.UpdateMutation) engine.UpdateMutation {
+	return m.Set("updated_at", timestamp())
+}
+
+// stamp is the hook applied to every INSERT in this package: created_at and
+// updated_at come from the same clock as later writes, so updated_at never
+// predates created_at
+func stamp(m engine.InsertMutation) engine.InsertMutation {
+	now := timestamp()
+	return m.Set("created_at", now).Set("updated_at", now)
+}
+
+// debugSQL reports whether eng was configured to log SQL. Queries follow
+// eng.Debug on their own; mutation builders only log when asked to.
+func debugSQL(eng *engine.Engine) bool {
+	return eng.Debug != nil && eng.Debug.Level >= engine.DebugSQL
+}
+
+// newUpdate starts an UPDATE on entity with the touch hook applied, bound
+// to the context's transaction if any and reported to the observer.
+// Repositories must build updates through it rather than engine.Update.
+func newUpdate(ctx context.Context, eng *engine.Engine, entity string) engine.UpdateMutation {
+	var m engine.UpdateMutation
+	if tx, ok := txFromContext(ctx); ok {
+		m = &txUpdate{newTxMutation(tx, eng, entity)}
+	} else {
+		m = eng.Update(entity)
+		if debugSQL(eng) {
+			m = m.Debug()
+		}
+	}
+	return touch(observedUpdate{UpdateMutation: m, entity: entity})
+}
+
+// newInsert starts an INSERT on entity with the stamp hook applied, bound
+// to the context's transaction if any and reported to the observer.
+// Repositories must build inserts through it rather than engine.Insert.
+func newInsert(ctx context.Context, eng *engine.Engine, entity string) engine.InsertMutation {
+	var m engine.InsertMutation
+	if tx, ok := txFromContext(ctx); ok {
+		m = &txInsert{newTxMutation(tx, eng, entity)}
+	} else {
+		m = eng.Insert(entity)
+		if debugSQL(eng) {
+			m = m.Debug()
+		}
+	}
+	return stamp(observedInsert{InsertMutation: m, entity: entity})
+}
+
+// newDelete starts a DELETE on entity, bound to the context's transaction
+// if any and reported to the observer. Repositories must build deletes
+// through it rather than engine.Delete.
+func newDelete(ctx context.Context, eng *engine.Engine, entity string) engine.DeleteMutation {
+	var m engine.DeleteMutation
+	if tx, ok := txFromContext(ctx); ok {
+		m = &txDelete{newTxMutation(tx, eng, entity)}
+	} else {
+		m = eng.Delete(entity)
+		if debugSQL(eng) {
+			m = m.Debug()
+		}
+	}
+	return observedDelete{DeleteMutation: m, entity: entity}
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/chameleon-db/chameleondb/chameleon/pkg/engine"
+)
+
+// recordingMutation captures the fields set on a mutation builder
+type recordingMutation struct {
+	sets map[string]interface{}
+}
+
+func newRecordingMutation() *recordingMutation {
+	return &recordingMutation{sets: make(map[string]interface{})}
+}
+
+func (m *recordingMutation) Set(field string, value interface{}) engine.UpdateMutation {
+	m.sets[field] = value
+	return m
+}
+
+func (m *recordingMutation) Filter(field, op string, value interface{}) engine.UpdateMutation {
+	return m
+}
+
+func (m *recordingMutation) Debug() engine.UpdateMutation { return m }
+
+func (m *recordingMutation) Execute(ctx context.Context) (*engine.UpdateResult, error) {
+	return &engine.UpdateResult{}, nil
+}
+
+// recordingInsert adapts recordingMutation to engine.InsertMutation
+type recordingInsert struct{ *recordingMutation }
+
+func (m recordingInsert) Set(field string, value interface{}) engine.InsertMutation {
+	m.sets[field] = value
+	return m
+}
+
+func (m recordingInsert) Debug() engine.InsertMutation { return m }
+
+func (m recordingInsert) Execute(ctx context.Context) (*engine.InsertResult, error) {
+	return &engine.InsertResult{}, nil
+}
+
+func withClock(t *testing.T, now time.Time) {
+	t.Helper()
+	prev := clock
+	clock = func() time.Time { return now }
+	t.Cleanup(func() { clock = prev })
+}
+
+func TestTouchSetsUpdatedAt(t *testing.T) {
+	tokyo := time.FixedZone("JST", 9*60*60)
+	withClock(t, time.Date(2026, 10, 17, 18, 30, 0, 123456789, tokyo))
+
+	m := newRecordingMutation()
+	touch(m)
+
+	want := time.Date(2026, 10, 17, 9, 30, 0, 123456000, time.UTC)
+	got, ok := m.sets["updated_at"].(time.Time)
+	if !ok {
+		t.Fatalf("Expected updated_at to be set, got %v", m.sets["updated_at"])
+	}
+
+	if !got.Equal(want) || got.Location() != time.UTC {
+		t.Errorf("Expected updated_at %v in UTC truncated to microseconds, got %v", want, got)
+	}
+}
+
+func TestStampSetsCreatedAndUpdatedAt(t *testing.T) {
+	withClock(t, time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC))
+
+	m := recordingInsert{newRecordingMutation()}
+	stamp(m)
+
+	if m.sets["created_at"] == nil || m.sets["created_at"] != m.sets["updated_at"] {
+		t.Errorf("Expected equal created_at and updated_at, got %v and %v", m.sets["created_at"], m.sets["updated_at"])
+	}
+}
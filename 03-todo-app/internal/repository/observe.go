@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/metrics"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/tracing"
+	"github.com/chameleon-db/chameleondb/chameleon/pkg/engine"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Operations reported to the Observer
+const (
+	opQuery  = "query"
+	opInsert = "insert"
+	opUpdate = "update"
+	opDelete = "delete"
+)
+
+// Observer is told the duration and outcome of every query and mutation
+// the repositories run; *metrics.DB implements it
+type Observer interface {
+	ObserveOperation(entity, operation string, elapsed time.Duration, err error)
+}
+
+type nopObserver struct{}
+
+func (nopObserver) ObserveOperation(string, string, time.Duration, error) {}
+
+// observer is set once at startup, before any repository is used
+var observer Observer = nopObserver{}
+
+// SetObserver makes every repository report to o. Call it before serving.
+func SetObserver(o Observer) {
+	if o == nil {
+		o = nopObserver{}
+	}
+	observer = o
+}
+
+// observe runs fn in a client span for operation on entity, with the
+// filter fields and operators (never values) as attributes, and reports it
+// to the observer. A single-row read that finds nothing succeeded as far as
+// the database is concerned.
+func observe(ctx context.Context, entity, operation string, filters []string, fn func(ctx context.Context) error) error {
+	ctx, span := tracing.Tracer().Start(ctx, "chameleondb."+operation+" "+entity,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system.name", "postgresql"),
+			attribute.String("db.operation.name", operation),
+			attribute.String("chameleondb.entity", entity),
+			attribute.StringSlice("chameleondb.filters", filters),
+		),
+	)
+
+	start := time.Now()
+	err := fn(ctx)
+
+	reported := err
+	if errors.Is(err, pgx.ErrNoRows) {
+		reported = nil
+	}
+	observer.ObserveOperation(entity, operation, time.Since(start), reported)
+	tracing.End(span, reported)
+
+	return err
+}
+
+// observedTx runs fn through inTx and reports the whole transaction as
+// operation on entity
+func observedTx(ctx context.Context, eng *engine.Engine, entity, operation string, filters []string, fn func(tx pgx.Tx) error) error {
+	return observe(ctx, entity, operation, filters, func(ctx context.Context) error {
+		return inTx(ctx, eng, fn)
+	})
+}
+
+// observedInsert reports Execute on the wrapped mutation
+type observedInsert struct {
+	engine.InsertMutation
+	entity string
+}
+
+func (m observedInsert) Set(field string, value interface{}) engine.InsertMutation {
+	m.InsertMutation = m.InsertMutation.Set(field, value)
+	return m
+}
+
+func (m observedInsert) Debug() engine.InsertMutation {
+	m.InsertMutation = m.InsertMutation.Debug()
+	return m
+}
+
+func (m observedInsert) Execute(ctx context.Context) (result *engine.InsertResult, err error) {
+	err = observe(ctx, m.entity, opInsert, nil, func(ctx context.Context) error {
+		result, err = m.InsertMutation.Execute(ctx)
+		return err
+	})
+	return result, err
+}
+
+// observedUpdate reports Execute on the wrapped mutation
+type observedUpdate struct {
+	engine.UpdateMutation
+	entity  string
+	filters []string
+}
+
+func (m observedUpdate) Set(field string, value interface{}) engine.UpdateMutation {
+	m.UpdateMutation = m.UpdateMutation.Set(field, value)
+	return m
+}
+
+func (m observedUpdate) Filter(field, op string, value interface{}) engine.UpdateMutation {
+	m.UpdateMutation = m.UpdateMutation.Filter(field, op, value)
+	m.filters = append(m.filters[:len(m.filters):len(m.filters)], field+" "+op)
+	return m
+}
+
+func (m observedUpdate) Debug() engine.UpdateMutation {
+	m.UpdateMutation = m.UpdateMutation.Debug()
+	return m
+}
+
+func (m observedUpdate) Execute(ctx context.Context) (result *engine.UpdateResult, err error) {
+	err = observe(ctx, m.entity, opUpdate, m.filters, func(ctx context.Context) error {
+		result, err = m.UpdateMutation.Execute(ctx)
+		return err
+	})
+	return result, err
+}
+
+// observedDelete reports Execute on the wrapped mutation
+type observedDelete struct {
+	engine.DeleteMutation
+	entity  string
+	filters []string
+}
+
+func (m observedDelete) Filter(field, op string, value interface{}) engine.DeleteMutation {
+	m.DeleteMutation = m.DeleteMutation.Filter(field, op, value)
+	m.filters = append(m.filters[:len(m.filters):len(m.filters)], field+" "+op)
+	return m
+}
+
+func (m observedDelete) Debug() engine.DeleteMutation {
+	m.DeleteMutation = m.DeleteMutation.Debug()
+	return m
+}
+
+func (m observedDelete) Execute(ctx context.Context) (result *engine.DeleteResult, err error) {
+	err = observe(ctx, m.entity, opDelete, m.filters, func(ctx context.Context) error {
+		result, err = m.DeleteMutation.Execute(ctx)
+		return err
+	})
+	return result, err
+}
+
+type poolStat = *pgxpool.Stat
+
+// RegisterPoolMetrics exposes the engine's connection pool stats in r,
+// read at scrape time
+func RegisterPoolMetrics(r *metrics.Registry, eng *engine.Engine) {
+	stat := func(fn func(s poolStat) float64) func() float64 {
+		return func() float64 {
+			conn := eng.Connector()
+			if conn == nil || !conn.IsConnected() {
+				return 0
+			}
+			return fn(conn.Pool().Stat())
+		}
+	}
+
+	r.NewGaugeFunc("chameleondb_pool_total_conns", "Connections in the pool.",
+		stat(func(s poolStat) float64 { return float64(s.TotalConns()) }))
+	r.NewGaugeFunc("chameleondb_pool_idle_conns", "Idle connections in the pool.",
+		stat(func(s poolStat) float64 { return float64(s.IdleConns()) }))
+	r.NewGaugeFunc("chameleondb_pool_acquired_conns", "Connections currently checked out of the pool.",
+		stat(func(s poolStat) float64 { return float64(s.AcquiredConns()) }))
+	r.NewGaugeFunc("chameleondb_pool_max_conns", "Maximum size of the pool.",
+		stat(func(s poolStat) float64 { return float64(s.MaxConns()) }))
+	r.NewCounterFunc("chameleondb_pool_acquires_total", "Connections acquired from the pool.",
+		stat(func(s poolStat) float64 { return float64(s.AcquireCount()) }))
+	r.NewCounterFunc("chameleondb_pool_empty_acquires_total", "Acquires that had to wait for a connection.",
+		stat(func(s poolStat) float64 { return float64(s.EmptyAcquireCount()) }))
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/chameleon-db/chameleondb/chameleon/pkg/engine"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordingObserver captures what the repositories report
+type recordingObserver struct {
+	ops  []string
+	errs []error
+}
+
+func (o *recordingObserver) ObserveOperation(entity, operation string, elapsed time.Duration, err error) {
+	o.ops = append(o.ops, entity+" "+operation)
+	o.errs = append(o.errs, err)
+}
+
+func withObserver(t *testing.T) *recordingObserver {
+	t.Helper()
+	o := &recordingObserver{}
+	prev := observer
+	SetObserver(o)
+	t.Cleanup(func() { observer = prev })
+	return o
+}
+
+func TestObservedMutationReportsExecute(t *testing.T) {
+	o := withObserver(t)
+
+	m := newRecordingMutation()
+	update := touch(observedUpdate{UpdateMutation: m, entity: "Todo"}).Filter("id", "eq", "1").Set("title", "x")
+	if _, err := update.Execute(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(o.ops) != 1 || o.ops[0] != "Todo update" || o.errs[0] != nil {
+		t.Errorf("Expected one successful Todo update, got %v %v", o.ops, o.errs)
+	}
+	if m.sets["title"] != "x" || m.sets["updated_at"] == nil {
+		t.Errorf("Expected the wrapped mutation to receive every Set, got %v", m.sets)
+	}
+}
+
+func TestObserveErrors(t *testing.T) {
+	o := withObserver(t)
+	failure := errors.New("boom")
+
+	ctx := context.Background()
+	if err := observe(ctx, "User", opQuery, nil, func(context.Context) error { return failure }); err != failure {
+		t.Errorf("Expected the error to be returned, got %v", err)
+	}
+	if err := observe(ctx, "User", opQuery, nil, func(context.Context) error { return pgx.ErrNoRows }); err != pgx.ErrNoRows {
+		t.Errorf("Expected ErrNoRows to be returned, got %v", err)
+	}
+
+	if o.errs[0] != failure || o.errs[1] != nil {
+		t.Errorf("Expected only the failure to be reported, got %v", o.errs)
+	}
+}
+
+func TestObserveTracesFilters(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	m := newRecordingMutation()
+	del := observedDelete{DeleteMutation: recordingDelete{m}, entity: "Todo"}.
+		Filter("id", "eq", "secret-id").
+		Filter("user_id", "eq", "secret-user")
+	if _, err := del.Execute(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 || spans[0].Name() != "chameleondb.delete Todo" {
+		t.Fatalf("Expected one chameleondb.delete Todo span, got %v", spans)
+	}
+
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range spans[0].Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	if got := attrs["chameleondb.entity"].AsString(); got != "Todo" {
+		t.Errorf("Expected entity Todo, got %q", got)
+	}
+	if got := attrs["chameleondb.filters"].AsStringSlice(); len(got) != 2 || got[0] != "id eq" || got[1] != "user_id eq" {
+		t.Errorf("Expected filter fields without values, got %v", got)
+	}
+}
+
+// recordingDelete adapts recordingMutation to engine.DeleteMutation
+type recordingDelete struct{ *recordingMutation }
+
+func (m recordingDelete) Filter(field, op string, value interface{}) engine.DeleteMutation { return m }
+
+func (m recordingDelete) Debug() engine.DeleteMutation { return m }
+
+func (m recordingDelete) Execute(ctx context.Context) (*engine.DeleteResult, error) {
+	return &engine.DeleteResult{}, nil
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/passwordreset"
+	"github.com/chameleon-db/chameleondb/chameleon/pkg/engine"
+	_ "github.com/chameleon-db/chameleondb/chameleon/pkg/engine/mutation"
+	"github.com/google/uuid"
+)
+
+// PasswordResetRepository implements passwordreset.Repository.
+//
+// Use is conditional on used_at being unset, which the engine's filters
+// cannot express, so it runs as raw SQL like the session writes.
+type PasswordResetRepository struct {
+	engine *engine.Engine
+}
+
+// NewPasswordResetRepository creates a new password reset repository
+func NewPasswordResetRepository(eng *engine.Engine) passwordreset.Repository {
+	return &PasswordResetRepository{engine: eng}
+}
+
+// Create inserts new reset token via ChameleonDB
+func (r *PasswordResetRepository) Create(ctx context.Context, userID, tokenHash string, expiresAt time.Time) (*passwordreset.Token, error) {
+	result, err := newInsert(ctx, r.engine, "PasswordReset").
+		Set("id", uuid.New().String()).
+		Set("user_id", userID).
+		Set("token_hash", tokenHash).
+		Set("expires_at", expiresAt.UTC()).
+		Execute(ctx)
+
+	if err != nil {
+		return nil, passwordResetError("create password reset", err)
+	}
+
+	if result == nil {
+		return nil, fmt.Errorf("failed to create password reset: empty result")
+	}
+
+	if result.Record == nil {
+		return nil, fmt.Errorf("failed to create password reset: missing record")
+	}
+
+	return rowToResetToken(result.Record)
+}
+
+// GetByID retrieves reset token by ID, used or not
+func (r *PasswordResetRepository) GetByID(ctx context.Context, id string) (*passwordreset.Token, error) {
+	query := newQuery(r.engine, "PasswordReset").
+		Filter("id", "eq", id)
+
+	result, err := runQuery(ctx, query)
+
+	if err != nil {
+		return nil, passwordResetError("query password reset", err)
+	}
+
+	if result == nil || result.IsEmpty() {
+		return nil, passwordreset.ErrNotFound
+	}
+
+	return rowToResetToken(result.Rows[0])
+}
+
+// Use marks an unused reset token used; of two concurrent resets with the
+// same token only one matches
+func (r *PasswordResetRepository) Use(ctx context.Context, id string) error {
+	affected, err := rawExec(ctx, r.engine, "PasswordReset", []string{"id eq", "used_at null"},
+		`UPDATE password_resets SET used_at = $2, updated_at = $2
+		 WHERE id = $1 AND used_at IS NULL`,
+		id, timestamp(),
+	)
+	if err != nil {
+		return passwordResetError("use password reset", err)
+	}
+
+	if affected == 0 {
+		return passwordreset.ErrNotFound
+	}
+
+	return nil
+}
+
+// UseAll marks every unused reset token of userID used
+func (r *PasswordResetRepository) UseAll(ctx context.Context, userID string) error {
+	_, err := rawExec(ctx, r.engine, "PasswordReset", []string{"user_id eq", "used_at null"},
+		`UPDATE password_resets SET used_at = $2, updated_at = $2
+		 WHERE user_id = $1 AND used_at IS NULL`,
+		userID, timestamp(),
+	)
+	if err != nil {
+		return passwordResetError("use password resets", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/chameleon-db/chameleondb/chameleon/pkg/engine"
+)
+
+// query wraps an engine query, remembering its entity and filters so
+// runQuery can report them. Repositories must start queries with newQuery
+// rather than engine.Query.
+type query struct {
+	builder *engine.QueryBuilder
+	entity  string
+	filters []string // "field op", without values
+}
+
+func newQuery(eng *engine.Engine, entity string) *query {
+	return &query{builder: eng.Query(entity), entity: entity}
+}
+
+func (q *query) Filter(field, op string, value interface{}) *query {
+	q.builder = q.builder.Filter(field, op, value)
+	q.filters = append(q.filters, field+" "+op)
+	return q
+}
+
+func (q *query) OrderBy(field, direction string) *query {
+	q.builder = q.builder.OrderBy(field, direction)
+	return q
+}
+
+func (q *query) Limit(n uint64) *query {
+	q.builder = q.builder.Limit(n)
+	return q
+}
+
+func (q *query) Offset(n uint64) *query {
+	q.builder = q.builder.Offset(n)
+	return q
+}
+
+// condition is one "field op value" filter
+type condition struct {
+	field string
+	op    string
+	value interface{}
+}
+
+// conditions is a filter written once and used both on an engine query
+// and in raw SQL, so the two always select the same rows
+type conditions []condition
+
+// apply adds the conditions to q
+func (cs conditions) apply(q *query) *query {
+	for _, c := range cs {
+		value := c.value
+		if t, ok := value.(time.Time); ok {
+			value = timestampLiteral(t)
+		}
+		q = q.Filter(c.field, c.op, value)
+	}
+	return q
+}
+
+// sqlOperators are the filter operators the repositories use, as SQL
+var sqlOperators = map[string]string{
+	"eq":  "=",
+	"neq": "<>",
+	"gt":  ">",
+	"gte": ">=",
+	"lt":  "<",
+	"lte": "<=",
+}
+
+// where renders the conditions as a parameterized SQL condition whose
+// first placeholder is $first, and returns the matching arguments
+func (cs conditions) where(first int) (string, []interface{}, error) {
+	clauses := make([]string, len(cs))
+	args := make([]interface{}, len(cs))
+	for i, c := range cs {
+		op, ok := sqlOperators[c.op]
+		if !ok {
+			return "", nil, fmt.Errorf("unsupported filter operator: %s", c.op)
+		}
+		clauses[i] = fmt.Sprintf("%s %s $%d", c.field, op, first+i)
+
+		args[i] = c.value
+		if t, ok := c.value.(time.Time); ok {
+			args[i] = t.UTC()
+		}
+	}
+	return strings.Join(clauses, " AND "), args, nil
+}
+
+// names returns the conditions as "field op", for the observer
+func (cs conditions) names() []string {
+	names := make([]string, len(cs))
+	for i, c := range cs {
+		names[i] = c.field + " " + c.op
+	}
+	return names
+}
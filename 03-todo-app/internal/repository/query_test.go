@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConditionsWhere(t *testing.T) {
+	now := time.Date(2026, 3, 1, 9, 30, 0, 0, time.FixedZone("CET", 3600))
+
+	where, args, err := overdue("user-1", now).where(2)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if want := "archived = $2 AND user_id = $3 AND completed = $4 AND due_date < $5"; where != want {
+		t.Errorf("where = %q, want %q", where, want)
+	}
+	if len(args) != 4 || args[0] != false || args[1] != "user-1" || args[2] != false {
+		t.Errorf("Unexpected args %v", args)
+	}
+	if due, ok := args[3].(time.Time); !ok || !due.Equal(now) || due.Location() != time.UTC {
+		t.Errorf("Expected the due date in UTC, got %v", args[3])
+	}
+
+	if _, _, err := (conditions{{field: "title", op: "like", value: "x"}}).where(1); err == nil {
+		t.Error("Expected an error for an unsupported operator")
+	}
+}
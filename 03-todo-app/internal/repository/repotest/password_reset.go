@@ -0,0 +1,113 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/passwordreset"
+	"github.com/google/uuid"
+)
+
+func runPasswordResetTests(t *testing.T, newRepos func(t *testing.T) Repos) {
+	ctx := context.Background()
+
+	// Whole seconds, so the expiry round-trips through any storage exactly
+	expires := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	create := func(t *testing.T, repos Repos, userID uuid.UUID) *passwordreset.Token {
+		t.Helper()
+
+		created, err := repos.PasswordResets.Create(ctx, userID.String(), "hash-"+uuid.NewString(), expires)
+		if err != nil {
+			t.Fatalf("Create reset token: expected no error, got %v", err)
+		}
+		return created
+	}
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repos := newRepos(t)
+		u := createUser(t, repos.Users)
+		created := create(t, repos, u.ID)
+
+		if created.UserID != u.ID || !created.ExpiresAt.Equal(expires) || created.UsedAt != nil {
+			t.Errorf("Expected a new reset token, got %+v", created)
+		}
+
+		got, err := repos.PasswordResets.GetByID(ctx, created.ID.String())
+		if err != nil || got.TokenHash != created.TokenHash {
+			t.Errorf("GetByID: expected %s, got %+v (%v)", created.TokenHash, got, err)
+		}
+
+		if _, err := repos.PasswordResets.GetByID(ctx, uuid.NewString()); err != passwordreset.ErrNotFound {
+			t.Errorf("GetByID unknown: expected ErrNotFound, got %v", err)
+		}
+		if _, err := repos.PasswordResets.GetByID(ctx, "not-a-uuid"); err != passwordreset.ErrInvalidInput {
+			t.Errorf("GetByID malformed: expected ErrInvalidInput, got %v", err)
+		}
+	})
+
+	t.Run("CreateForUnknownUser", func(t *testing.T) {
+		repos := newRepos(t)
+
+		_, err := repos.PasswordResets.Create(ctx, uuid.NewString(), "hash-"+uuid.NewString(), expires)
+		if err != passwordreset.ErrInvalidInput {
+			t.Errorf("Expected ErrInvalidInput, got %v", err)
+		}
+	})
+
+	t.Run("DuplicateHash", func(t *testing.T) {
+		repos := newRepos(t)
+		u := createUser(t, repos.Users)
+		created := create(t, repos, u.ID)
+
+		if _, err := repos.PasswordResets.Create(ctx, u.ID.String(), created.TokenHash, expires); err != passwordreset.ErrConflict {
+			t.Errorf("Expected ErrConflict, got %v", err)
+		}
+	})
+
+	t.Run("Use", func(t *testing.T) {
+		repos := newRepos(t)
+		u := createUser(t, repos.Users)
+		created := create(t, repos, u.ID)
+
+		if err := repos.PasswordResets.Use(ctx, created.ID.String()); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		got, _ := repos.PasswordResets.GetByID(ctx, created.ID.String())
+		if got == nil || got.UsedAt == nil {
+			t.Errorf("Expected the token marked used, got %+v", got)
+		}
+
+		if err := repos.PasswordResets.Use(ctx, created.ID.String()); err != passwordreset.ErrNotFound {
+			t.Errorf("Used twice: expected ErrNotFound, got %v", err)
+		}
+		if err := repos.PasswordResets.Use(ctx, uuid.NewString()); err != passwordreset.ErrNotFound {
+			t.Errorf("Unknown token: expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("UseAll", func(t *testing.T) {
+		repos := newRepos(t)
+		u := createUser(t, repos.Users)
+		other := createUser(t, repos.Users)
+
+		first := create(t, repos, u.ID)
+		second := create(t, repos, u.ID)
+		kept := create(t, repos, other.ID)
+
+		if err := repos.PasswordResets.UseAll(ctx, u.ID.String()); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		for _, token := range []*passwordreset.Token{first, second} {
+			if err := repos.PasswordResets.Use(ctx, token.ID.String()); err != passwordreset.ErrNotFound {
+				t.Errorf("Token %s: expected it used, got %v", token.ID, err)
+			}
+		}
+		if got, _ := repos.PasswordResets.GetByID(ctx, kept.ID.String()); got == nil || got.UsedAt != nil {
+			t.Errorf("Expected another user's token untouched, got %+v", got)
+		}
+	})
+}
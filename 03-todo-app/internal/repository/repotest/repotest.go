@@ -0,0 +1,73 @@
+// Package repotest is a conformance suite for implementations of
+// todo.Repository, user.Repository, session.Repository and
+// passwordreset.Repository. Every implementation runs it, so the in-memory
+// repositories used in fast tests behave like the real ones.
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/passwordreset"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/session"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/todo"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/user"
+	"github.com/google/uuid"
+)
+
+// Repos is a set of repositories over the same data
+type Repos struct {
+	Todos          todo.Repository
+	Users          user.Repository
+	Sessions       session.Repository
+	PasswordResets passwordreset.Repository
+}
+
+// Run runs the whole suite. newRepos is called once per subtest and must
+// return repositories over empty storage.
+func Run(t *testing.T, newRepos func(t *testing.T) Repos) {
+	t.Run("Todo", func(t *testing.T) { runTodoTests(t, newRepos) })
+	t.Run("User", func(t *testing.T) { runUserTests(t, newRepos) })
+	t.Run("Session", func(t *testing.T) { runSessionTests(t, newRepos) })
+	t.Run("PasswordReset", func(t *testing.T) { runPasswordResetTests(t, newRepos) })
+}
+
+// createUser creates an active user with a unique email
+func createUser(t *testing.T, users user.Repository) *user.User {
+	t.Helper()
+
+	email := "user-" + uuid.NewString() + "@example.com"
+	u, err := users.Create(context.Background(), email, "Test User", "hash")
+	if err != nil {
+		t.Fatalf("Create user: expected no error, got %v", err)
+	}
+
+	return u
+}
+
+// createTodo creates a todo for userID
+func createTodo(t *testing.T, todos todo.Repository, userID uuid.UUID, in todo.CreateInput) *todo.Todo {
+	t.Helper()
+
+	created, err := todos.Create(context.Background(), userID.String(), in)
+	if err != nil {
+		t.Fatalf("Create todo: expected no error, got %v", err)
+	}
+
+	return created
+}
+
+// ids returns the IDs of todos, for order-independent comparisons
+func ids(todos []todo.Todo) map[uuid.UUID]bool {
+	out := make(map[uuid.UUID]bool, len(todos))
+	for _, t := range todos {
+		out[t.ID] = true
+	}
+	return out
+}
+
+// day returns midnight UTC of the given date
+func day(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
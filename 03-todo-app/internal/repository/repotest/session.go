@@ -0,0 +1,151 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/session"
+	"github.com/google/uuid"
+)
+
+func runSessionTests(t *testing.T, newRepos func(t *testing.T) Repos) {
+	ctx := context.Background()
+	client := session.Client{UserAgent: "repotest", IP: "192.0.2.1"}
+
+	// Whole seconds, so the expiry round-trips through any storage exactly
+	expires := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	create := func(t *testing.T, repos Repos, userID uuid.UUID) *session.Session {
+		t.Helper()
+
+		created, err := repos.Sessions.Create(ctx, userID.String(), "hash-"+uuid.NewString(), client, expires)
+		if err != nil {
+			t.Fatalf("Create session: expected no error, got %v", err)
+		}
+		return created
+	}
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repos := newRepos(t)
+		u := createUser(t, repos.Users)
+		created := create(t, repos, u.ID)
+
+		if created.UserID != u.ID || created.UserAgent != client.UserAgent || created.IP != client.IP ||
+			!created.ExpiresAt.Equal(expires) || created.RevokedAt != nil {
+			t.Errorf("Expected a new session, got %+v", created)
+		}
+
+		got, err := repos.Sessions.GetByID(ctx, created.ID.String())
+		if err != nil || got.TokenHash != created.TokenHash {
+			t.Errorf("GetByID: expected %s, got %+v (%v)", created.TokenHash, got, err)
+		}
+
+		if _, err := repos.Sessions.GetByID(ctx, uuid.NewString()); err != session.ErrNotFound {
+			t.Errorf("GetByID unknown: expected ErrNotFound, got %v", err)
+		}
+		if _, err := repos.Sessions.GetByID(ctx, "not-a-uuid"); err != session.ErrInvalidInput {
+			t.Errorf("GetByID malformed: expected ErrInvalidInput, got %v", err)
+		}
+	})
+
+	t.Run("CreateForUnknownUser", func(t *testing.T) {
+		repos := newRepos(t)
+
+		_, err := repos.Sessions.Create(ctx, uuid.NewString(), "hash-"+uuid.NewString(), client, expires)
+		if err != session.ErrInvalidInput {
+			t.Errorf("Expected ErrInvalidInput, got %v", err)
+		}
+	})
+
+	t.Run("ListActive", func(t *testing.T) {
+		repos := newRepos(t)
+		u := createUser(t, repos.Users)
+		other := createUser(t, repos.Users)
+
+		older := create(t, repos, u.ID)
+		newer := create(t, repos, u.ID)
+		revoked := create(t, repos, u.ID)
+		repos.Sessions.Revoke(ctx, revoked.ID.String(), u.ID.String())
+		create(t, repos, other.ID)
+
+		got, err := repos.Sessions.ListActive(ctx, u.ID.String(), time.Now())
+		if err != nil || len(got) != 2 {
+			t.Fatalf("Expected 2 active sessions, got %d (%v)", len(got), err)
+		}
+		if got[0].ID != newer.ID || got[1].ID != older.ID {
+			t.Errorf("Expected the newest session first, got %v then %v", got[0].ID, got[1].ID)
+		}
+
+		if expired, _ := repos.Sessions.ListActive(ctx, u.ID.String(), expires); len(expired) != 0 {
+			t.Errorf("Expected no sessions active at their expiry, got %d", len(expired))
+		}
+	})
+
+	t.Run("Rotate", func(t *testing.T) {
+		repos := newRepos(t)
+		u := createUser(t, repos.Users)
+		created := create(t, repos, u.ID)
+
+		later := expires.Add(time.Hour)
+		moved := session.Client{UserAgent: "elsewhere", IP: "198.51.100.7"}
+		rotated, err := repos.Sessions.Rotate(ctx, created.ID.String(), created.TokenHash, "next", moved, later)
+		if err != nil || rotated.TokenHash != "next" || rotated.IP != moved.IP || !rotated.ExpiresAt.Equal(later) ||
+			rotated.UpdatedAt.Before(created.UpdatedAt) {
+			t.Fatalf("Expected a rotated session, got %+v (%v)", rotated, err)
+		}
+
+		if _, err := repos.Sessions.Rotate(ctx, created.ID.String(), created.TokenHash, "again", client, later); err != session.ErrNotFound {
+			t.Errorf("Rotate with the old hash: expected ErrNotFound, got %v", err)
+		}
+
+		repos.Sessions.Revoke(ctx, created.ID.String(), u.ID.String())
+		if _, err := repos.Sessions.Rotate(ctx, created.ID.String(), "next", "again", client, later); err != session.ErrNotFound {
+			t.Errorf("Rotate revoked: expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("Revoke", func(t *testing.T) {
+		repos := newRepos(t)
+		u := createUser(t, repos.Users)
+		other := createUser(t, repos.Users)
+		created := create(t, repos, u.ID)
+
+		if err := repos.Sessions.Revoke(ctx, created.ID.String(), other.ID.String()); err != session.ErrNotFound {
+			t.Errorf("Revoke as another user: expected ErrNotFound, got %v", err)
+		}
+
+		if err := repos.Sessions.Revoke(ctx, created.ID.String(), u.ID.String()); err != nil {
+			t.Fatalf("Revoke: expected no error, got %v", err)
+		}
+
+		got, _ := repos.Sessions.GetByID(ctx, created.ID.String())
+		if got == nil || got.RevokedAt == nil {
+			t.Errorf("Expected a revoked session, got %+v", got)
+		}
+
+		if err := repos.Sessions.Revoke(ctx, created.ID.String(), u.ID.String()); err != session.ErrNotFound {
+			t.Errorf("Revoke again: expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("RevokeAll", func(t *testing.T) {
+		repos := newRepos(t)
+		u := createUser(t, repos.Users)
+		other := createUser(t, repos.Users)
+		create(t, repos, u.ID)
+		create(t, repos, u.ID)
+		kept := create(t, repos, other.ID)
+
+		if err := repos.Sessions.RevokeAll(ctx, u.ID.String()); err != nil {
+			t.Fatalf("RevokeAll: expected no error, got %v", err)
+		}
+
+		if active, _ := repos.Sessions.ListActive(ctx, u.ID.String(), time.Now()); len(active) != 0 {
+			t.Errorf("Expected no active sessions, got %d", len(active))
+		}
+		if active, _ := repos.Sessions.ListActive(ctx, other.ID.String(), time.Now()); len(active) != 1 || active[0].ID != kept.ID {
+			t.Errorf("Expected the other user's session kept, got %+v", active)
+		}
+	})
+}
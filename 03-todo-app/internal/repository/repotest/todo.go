@@ -0,0 +1,293 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/todo"
+	"github.com/google/uuid"
+)
+
+func runTodoTests(t *testing.T, newRepos func(t *testing.T) Repos) {
+	ctx := context.Background()
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repos := newRepos(t)
+		u := createUser(t, repos.Users)
+
+		due := time.Date(2026, 10, 20, 9, 30, 0, 123456789, time.FixedZone("JST", 9*60*60))
+		created := createTodo(t, repos.Todos, u.ID, todo.CreateInput{Title: "Write tests", Description: "All of them", DueDate: &due})
+
+		if created.UserID != u.ID || created.Title != "Write tests" || created.Completed {
+			t.Errorf("Expected new incomplete todo for user, got %+v", created)
+		}
+		if created.Description == nil || *created.Description != "All of them" {
+			t.Errorf("Expected description %q, got %v", "All of them", created.Description)
+		}
+		if want := due.UTC().Truncate(time.Microsecond); created.DueDate == nil || !created.DueDate.Equal(want) {
+			t.Errorf("Expected due date %v, got %v", want, created.DueDate)
+		}
+		if created.CreatedAt.IsZero() || !created.UpdatedAt.Equal(created.CreatedAt) {
+			t.Errorf("Expected updated_at = created_at, got %v and %v", created.UpdatedAt, created.CreatedAt)
+		}
+
+		got, err := repos.Todos.GetByID(ctx, created.ID.String())
+		if err != nil {
+			t.Fatalf("GetByID: expected no error, got %v", err)
+		}
+		if got.Title != created.Title || !got.UpdatedAt.Equal(created.UpdatedAt) {
+			t.Errorf("Expected %+v, got %+v", created, got)
+		}
+
+		if _, err := repos.Todos.GetByIDForUser(ctx, created.ID.String(), u.ID.String()); err != nil {
+			t.Errorf("GetByIDForUser: expected no error, got %v", err)
+		}
+	})
+
+	t.Run("CreateForUnknownUser", func(t *testing.T) {
+		repos := newRepos(t)
+
+		_, err := repos.Todos.Create(ctx, uuid.NewString(), todo.CreateInput{Title: "Orphan"})
+		if err != todo.ErrInvalidUserID {
+			t.Errorf("Expected ErrInvalidUserID, got %v", err)
+		}
+	})
+
+	t.Run("GetMissing", func(t *testing.T) {
+		repos := newRepos(t)
+		owner := createUser(t, repos.Users)
+		other := createUser(t, repos.Users)
+		created := createTodo(t, repos.Todos, owner.ID, todo.CreateInput{Title: "Private"})
+
+		if _, err := repos.Todos.GetByID(ctx, uuid.NewString()); err != todo.ErrNotFound {
+			t.Errorf("GetByID unknown: expected ErrNotFound, got %v", err)
+		}
+		if _, err := repos.Todos.GetByID(ctx, "not-a-uuid"); err != todo.ErrInvalidInput {
+			t.Errorf("GetByID malformed: expected ErrInvalidInput, got %v", err)
+		}
+		if _, err := repos.Todos.GetByIDForUser(ctx, created.ID.String(), other.ID.String()); err != todo.ErrNotFound {
+			t.Errorf("GetByIDForUser other user: expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("ListPagination", func(t *testing.T) {
+		repos := newRepos(t)
+		u := createUser(t, repos.Users)
+		other := createUser(t, repos.Users)
+		createTodo(t, repos.Todos, other.ID, todo.CreateInput{Title: "Not mine"})
+
+		for i := 0; i < 5; i++ {
+			createTodo(t, repos.Todos, u.ID, todo.CreateInput{Title: "Todo"})
+		}
+
+		all, err := repos.Todos.ListByUser(ctx, u.ID.String(), 0, 0)
+		if err != nil || len(all) != 5 {
+			t.Fatalf("Expected 5 todos, got %d (%v)", len(all), err)
+		}
+
+		seen := map[uuid.UUID]bool{}
+		for offset, want := range map[int]int{0: 2, 2: 2, 4: 1, 6: 0} {
+			got, err := repos.Todos.ListByUser(ctx, u.ID.String(), 2, offset)
+			if err != nil || len(got) != want {
+				t.Errorf("Offset %d: expected %d todos, got %d (%v)", offset, want, len(got), err)
+			}
+			for id := range ids(got) {
+				seen[id] = true
+			}
+		}
+		if len(seen) != 5 {
+			t.Errorf("Expected pages to cover 5 todos, got %d", len(seen))
+		}
+	})
+
+	t.Run("ListFiltered", func(t *testing.T) {
+		repos := newRepos(t)
+		u := createUser(t, repos.Users)
+
+		oct20, oct21 := day(2026, 10, 20), day(2026, 10, 21)
+		first := createTodo(t, repos.Todos, u.ID, todo.CreateInput{Title: "On the 20th", DueDate: &oct20})
+		second := createTodo(t, repos.Todos, u.ID, todo.CreateInput{Title: "On the 21st", DueDate: &oct21})
+		undated := createTodo(t, repos.Todos, u.ID, todo.CreateInput{Title: "Someday"})
+
+		if _, err := repos.Todos.Toggle(ctx, second.ID.String(), u.ID.String()); err != nil {
+			t.Fatalf("Toggle: expected no error, got %v", err)
+		}
+
+		completed := true
+		got, err := repos.Todos.ListByUserFiltered(ctx, u.ID.String(), todo.ListFilter{Completed: &completed}, 0, 0)
+		if err != nil || len(got) != 1 || !ids(got)[second.ID] {
+			t.Errorf("Completed filter: expected only %v, got %v (%v)", second.ID, got, err)
+		}
+
+		// DueAfter is inclusive, DueBefore exclusive, NULL never matches
+		got, err = repos.Todos.ListByUserFiltered(ctx, u.ID.String(), todo.ListFilter{DueAfter: &oct20, DueBefore: &oct21}, 0, 0)
+		if err != nil || len(got) != 1 || !ids(got)[first.ID] {
+			t.Errorf("Due range: expected only %v, got %v (%v)", first.ID, got, err)
+		}
+
+		got, err = repos.Todos.ListByUserFiltered(ctx, u.ID.String(), todo.ListFilter{DueBefore: &oct21}, 0, 0)
+		if err != nil || len(got) != 1 || ids(got)[undated.ID] {
+			t.Errorf("Due before: expected only %v, got %v (%v)", first.ID, got, err)
+		}
+
+		got, err = repos.Todos.ListByUserFiltered(ctx, u.ID.String(), todo.ListFilter{}, 0, 0)
+		if err != nil || len(got) != 3 {
+			t.Errorf("No filter: expected 3 todos, got %d (%v)", len(got), err)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		repos := newRepos(t)
+		u := createUser(t, repos.Users)
+		other := createUser(t, repos.Users)
+
+		due := day(2026, 10, 20)
+		created := createTodo(t, repos.Todos, u.ID, todo.CreateInput{Title: "Old", DueDate: &due})
+
+		updated, err := repos.Todos.Update(ctx, created.ID.String(), u.ID.String(), todo.UpdateInput{
+			Title:       "New",
+			Description: "Details",
+			Completed:   true,
+			Version:     &created.UpdatedAt,
+		})
+		if err != nil {
+			t.Fatalf("Update: expected no error, got %v", err)
+		}
+
+		got, _ := repos.Todos.GetByID(ctx, created.ID.String())
+		if updated.Title != got.Title || !updated.UpdatedAt.Equal(got.UpdatedAt) {
+			t.Errorf("Expected Update to return the stored todo %+v, got %+v", got, updated)
+		}
+		if got.Title != "New" || !got.Completed || got.Description == nil || *got.Description != "Details" {
+			t.Errorf("Expected updated fields, got %+v", got)
+		}
+		if got.DueDate != nil {
+			t.Errorf("Expected nil due date to clear it, got %v", got.DueDate)
+		}
+		if !got.UpdatedAt.After(created.UpdatedAt) {
+			t.Errorf("Expected updated_at bumped past %v, got %v", created.UpdatedAt, got.UpdatedAt)
+		}
+
+		// The version read before the update is now stale
+		_, err = repos.Todos.Update(ctx, created.ID.String(), u.ID.String(), todo.UpdateInput{Title: "Stale", Version: &created.UpdatedAt})
+		if err != todo.ErrVersionMismatch {
+			t.Errorf("Stale version: expected ErrVersionMismatch, got %v", err)
+		}
+
+		if _, err := repos.Todos.Update(ctx, created.ID.String(), other.ID.String(), todo.UpdateInput{Title: "Hijacked"}); err != todo.ErrNotFound {
+			t.Errorf("Other user: expected ErrNotFound, got %v", err)
+		}
+		if _, err := repos.Todos.Update(ctx, uuid.NewString(), u.ID.String(), todo.UpdateInput{Title: "Missing"}); err != todo.ErrNotFound {
+			t.Errorf("Unknown todo: expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("Patch", func(t *testing.T) {
+		repos := newRepos(t)
+		u := createUser(t, repos.Users)
+
+		due := day(2026, 10, 20)
+		created := createTodo(t, repos.Todos, u.ID, todo.CreateInput{Title: "Keep", Description: "Drop", DueDate: &due})
+
+		completed := true
+		patched, err := repos.Todos.Patch(ctx, created.ID.String(), u.ID.String(), todo.Patch{
+			Completed:        &completed,
+			ClearDescription: true,
+			Version:          &created.UpdatedAt,
+		})
+		if err != nil {
+			t.Fatalf("Patch: expected no error, got %v", err)
+		}
+
+		if patched.Title != "Keep" || !patched.Completed || patched.Description != nil {
+			t.Errorf("Expected only completed and description changed, got %+v", patched)
+		}
+		if patched.DueDate == nil || !patched.DueDate.Equal(due) {
+			t.Errorf("Expected due date kept, got %v", patched.DueDate)
+		}
+
+		if _, err := repos.Todos.Patch(ctx, created.ID.String(), u.ID.String(), todo.Patch{Completed: &completed, Version: &created.UpdatedAt}); err != todo.ErrVersionMismatch {
+			t.Errorf("Stale version: expected ErrVersionMismatch, got %v", err)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		repos := newRepos(t)
+		u := createUser(t, repos.Users)
+		created := createTodo(t, repos.Todos, u.ID, todo.CreateInput{Title: "Doomed"})
+
+		stale := created.UpdatedAt.Add(-time.Second)
+		if err := repos.Todos.Delete(ctx, created.ID.String(), u.ID.String(), &stale); err != todo.ErrVersionMismatch {
+			t.Errorf("Stale version: expected ErrVersionMismatch, got %v", err)
+		}
+
+		if err := repos.Todos.Delete(ctx, created.ID.String(), u.ID.String(), &created.UpdatedAt); err != nil {
+			t.Fatalf("Delete: expected no error, got %v", err)
+		}
+
+		if _, err := repos.Todos.GetByID(ctx, created.ID.String()); err != todo.ErrNotFound {
+			t.Errorf("GetByID after delete: expected ErrNotFound, got %v", err)
+		}
+		if err := repos.Todos.Delete(ctx, created.ID.String(), u.ID.String(), nil); err != todo.ErrNotFound {
+			t.Errorf("Delete again: expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("Toggle", func(t *testing.T) {
+		repos := newRepos(t)
+		u := createUser(t, repos.Users)
+		other := createUser(t, repos.Users)
+		created := createTodo(t, repos.Todos, u.ID, todo.CreateInput{Title: "Flip"})
+
+		toggled, err := repos.Todos.Toggle(ctx, created.ID.String(), u.ID.String())
+		if err != nil {
+			t.Fatalf("Toggle: expected no error, got %v", err)
+		}
+		if !toggled.Completed || toggled.Title != "Flip" || !toggled.UpdatedAt.After(created.UpdatedAt) {
+			t.Errorf("Expected completed todo with new version, got %+v", toggled)
+		}
+
+		toggled, _ = repos.Todos.Toggle(ctx, created.ID.String(), u.ID.String())
+		if toggled == nil || toggled.Completed {
+			t.Errorf("Expected second toggle to reopen the todo, got %+v", toggled)
+		}
+
+		if _, err := repos.Todos.Toggle(ctx, created.ID.String(), other.ID.String()); err != todo.ErrNotFound {
+			t.Errorf("Other user: expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("GetOverdue", func(t *testing.T) {
+		repos := newRepos(t)
+		u := createUser(t, repos.Users)
+
+		now := day(2026, 10, 17)
+		oct10, oct12, oct15, oct20 := day(2026, 10, 10), day(2026, 10, 12), day(2026, 10, 15), day(2026, 10, 20)
+
+		// Created out of due order to check the sort
+		middle := createTodo(t, repos.Todos, u.ID, todo.CreateInput{Title: "Middle", DueDate: &oct12})
+		oldest := createTodo(t, repos.Todos, u.ID, todo.CreateInput{Title: "Oldest", DueDate: &oct10})
+		newest := createTodo(t, repos.Todos, u.ID, todo.CreateInput{Title: "Newest", DueDate: &oct15})
+		createTodo(t, repos.Todos, u.ID, todo.CreateInput{Title: "Future", DueDate: &oct20})
+		createTodo(t, repos.Todos, u.ID, todo.CreateInput{Title: "Undated"})
+		done := createTodo(t, repos.Todos, u.ID, todo.CreateInput{Title: "Done", DueDate: &oct10})
+		repos.Todos.Toggle(ctx, done.ID.String(), u.ID.String())
+
+		got, total, err := repos.Todos.GetOverdue(ctx, u.ID.String(), now, 2, 0)
+		if err != nil {
+			t.Fatalf("GetOverdue: expected no error, got %v", err)
+		}
+		if total != 3 {
+			t.Errorf("Expected total 3, got %d", total)
+		}
+		if len(got) != 2 || got[0].ID != oldest.ID || got[1].ID != middle.ID {
+			t.Errorf("Expected [Oldest Middle], got %v", got)
+		}
+
+		got, _, _ = repos.Todos.GetOverdue(ctx, u.ID.String(), now, 2, 2)
+		if len(got) != 1 || got[0].ID != newest.ID {
+			t.Errorf("Expected [Newest] on second page, got %v", got)
+		}
+	})
+}
@@ -0,0 +1,246 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/authz"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/todo"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/user"
+	"github.com/google/uuid"
+)
+
+func runUserTests(t *testing.T, newRepos func(t *testing.T) Repos) {
+	ctx := context.Background()
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repos := newRepos(t)
+		created := createUser(t, repos.Users)
+
+		if !created.IsActive || created.PasswordHash != "hash" || created.Role != authz.RoleUser || !created.UpdatedAt.Equal(created.CreatedAt) {
+			t.Errorf("Expected new active user, got %+v", created)
+		}
+
+		got, err := repos.Users.GetByID(ctx, created.ID.String())
+		if err != nil || got.Email != created.Email {
+			t.Errorf("GetByID: expected %s, got %v (%v)", created.Email, got, err)
+		}
+
+		got, err = repos.Users.GetByEmail(ctx, created.Email)
+		if err != nil || got.ID != created.ID {
+			t.Errorf("GetByEmail: expected %v, got %v (%v)", created.ID, got, err)
+		}
+
+		if _, err := repos.Users.GetByID(ctx, uuid.NewString()); err != user.ErrNotFound {
+			t.Errorf("GetByID unknown: expected ErrNotFound, got %v", err)
+		}
+		if _, err := repos.Users.GetByID(ctx, "not-a-uuid"); err != user.ErrInvalidInput {
+			t.Errorf("GetByID malformed: expected ErrInvalidInput, got %v", err)
+		}
+		if _, err := repos.Users.GetByEmail(ctx, "nobody@example.com"); err != user.ErrNotFound {
+			t.Errorf("GetByEmail unknown: expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("SetRole", func(t *testing.T) {
+		repos := newRepos(t)
+		created := createUser(t, repos.Users)
+
+		promoted, err := repos.Users.SetRole(ctx, created.ID.String(), authz.RoleAdmin)
+		if err != nil || promoted.Role != authz.RoleAdmin || promoted.UpdatedAt.Before(created.UpdatedAt) {
+			t.Fatalf("Expected an admin, got %+v (%v)", promoted, err)
+		}
+
+		got, _ := repos.Users.GetByID(ctx, created.ID.String())
+		if got == nil || got.Role != authz.RoleAdmin {
+			t.Errorf("Expected the role to persist, got %+v", got)
+		}
+
+		repos.Users.Delete(ctx, created.ID.String(), nil)
+		if _, err := repos.Users.SetRole(ctx, created.ID.String(), authz.RoleUser); err != user.ErrNotFound {
+			t.Errorf("Deleted user: expected ErrNotFound, got %v", err)
+		}
+		if _, err := repos.Users.SetRole(ctx, uuid.NewString(), authz.RoleUser); err != user.ErrNotFound {
+			t.Errorf("Unknown user: expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("SetPassword", func(t *testing.T) {
+		repos := newRepos(t)
+		created := createUser(t, repos.Users)
+
+		if err := repos.Users.SetPassword(ctx, created.ID.String(), "new-hash"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		got, _ := repos.Users.GetByID(ctx, created.ID.String())
+		if got == nil || got.PasswordHash != "new-hash" || got.UpdatedAt.Before(created.UpdatedAt) {
+			t.Errorf("Expected the new hash to persist, got %+v", got)
+		}
+
+		repos.Users.Delete(ctx, created.ID.String(), nil)
+		if err := repos.Users.SetPassword(ctx, created.ID.String(), "hash"); err != user.ErrNotFound {
+			t.Errorf("Deleted user: expected ErrNotFound, got %v", err)
+		}
+		if err := repos.Users.SetPassword(ctx, uuid.NewString(), "hash"); err != user.ErrNotFound {
+			t.Errorf("Unknown user: expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("DuplicateEmail", func(t *testing.T) {
+		repos := newRepos(t)
+		created := createUser(t, repos.Users)
+
+		if _, err := repos.Users.Create(ctx, created.Email, "Twin", "hash"); err != user.ErrDuplicateEmail {
+			t.Errorf("Expected ErrDuplicateEmail, got %v", err)
+		}
+
+		// Deleted users keep their email
+		repos.Users.Delete(ctx, created.ID.String(), nil)
+		if _, err := repos.Users.Create(ctx, created.Email, "Twin", "hash"); err != user.ErrDuplicateEmail {
+			t.Errorf("After delete: expected ErrDuplicateEmail, got %v", err)
+		}
+	})
+
+	t.Run("ListPagination", func(t *testing.T) {
+		repos := newRepos(t)
+
+		created := make(map[uuid.UUID]bool)
+		for i := 0; i < 3; i++ {
+			created[createUser(t, repos.Users).ID] = true
+		}
+		deleted := createUser(t, repos.Users)
+		repos.Users.Delete(ctx, deleted.ID.String(), nil)
+
+		all, err := repos.Users.List(ctx, 0, 0)
+		if err != nil || len(all) != 3 {
+			t.Fatalf("Expected 3 active users, got %d (%v)", len(all), err)
+		}
+		for _, u := range all {
+			if !created[u.ID] {
+				t.Errorf("Unexpected user %v in list", u.ID)
+			}
+		}
+
+		first, _ := repos.Users.List(ctx, 2, 0)
+		rest, _ := repos.Users.List(ctx, 2, 2)
+		if len(first) != 2 || len(rest) != 1 {
+			t.Errorf("Expected pages of 2 and 1, got %d and %d", len(first), len(rest))
+		}
+	})
+
+	t.Run("UpdateAndPatch", func(t *testing.T) {
+		repos := newRepos(t)
+		created := createUser(t, repos.Users)
+
+		updated, err := repos.Users.Update(ctx, created.ID.String(), "Renamed", &created.UpdatedAt)
+		if err != nil {
+			t.Fatalf("Update: expected no error, got %v", err)
+		}
+
+		got, _ := repos.Users.GetByID(ctx, created.ID.String())
+		if got.Name != "Renamed" || !got.UpdatedAt.After(created.UpdatedAt) {
+			t.Errorf("Expected renamed user with new version, got %+v", got)
+		}
+		if updated.Name != got.Name || !updated.UpdatedAt.Equal(got.UpdatedAt) {
+			t.Errorf("Expected Update to return the stored user %+v, got %+v", got, updated)
+		}
+
+		if _, err := repos.Users.Update(ctx, created.ID.String(), "Stale", &created.UpdatedAt); err != user.ErrVersionMismatch {
+			t.Errorf("Update stale version: expected ErrVersionMismatch, got %v", err)
+		}
+
+		name := "Patched"
+		patched, err := repos.Users.Patch(ctx, created.ID.String(), user.Patch{Name: &name, Version: &got.UpdatedAt})
+		if err != nil || patched.Name != name || patched.Email != created.Email {
+			t.Errorf("Patch: expected name %q, got %+v (%v)", name, patched, err)
+		}
+
+		if _, err := repos.Users.Patch(ctx, created.ID.String(), user.Patch{Name: &name, Version: &got.UpdatedAt}); err != user.ErrVersionMismatch {
+			t.Errorf("Patch stale version: expected ErrVersionMismatch, got %v", err)
+		}
+		if _, err := repos.Users.Patch(ctx, uuid.NewString(), user.Patch{Name: &name}); err != user.ErrNotFound {
+			t.Errorf("Patch unknown: expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("DeleteArchivesTodos", func(t *testing.T) {
+		repos := newRepos(t)
+		u := createUser(t, repos.Users)
+		kept := createTodo(t, repos.Todos, u.ID, todo.CreateInput{Title: "Archived with the user"})
+
+		stale := u.UpdatedAt.Add(-time.Second)
+		if err := repos.Users.Delete(ctx, u.ID.String(), &stale); err != user.ErrVersionMismatch {
+			t.Errorf("Stale version: expected ErrVersionMismatch, got %v", err)
+		}
+
+		if err := repos.Users.Delete(ctx, u.ID.String(), &u.UpdatedAt); err != nil {
+			t.Fatalf("Delete: expected no error, got %v", err)
+		}
+
+		if _, err := repos.Users.GetByID(ctx, u.ID.String()); err != user.ErrNotFound {
+			t.Errorf("GetByID after delete: expected ErrNotFound, got %v", err)
+		}
+		if _, err := repos.Users.GetByEmail(ctx, u.Email); err != user.ErrNotFound {
+			t.Errorf("GetByEmail after delete: expected ErrNotFound, got %v", err)
+		}
+		if active, err := repos.Users.IsActive(ctx, u.ID.String()); err != nil || active {
+			t.Errorf("IsActive after delete: expected false, got %v (%v)", active, err)
+		}
+		if _, err := repos.Todos.GetByID(ctx, kept.ID.String()); err != todo.ErrNotFound {
+			t.Errorf("Todo after delete: expected ErrNotFound, got %v", err)
+		}
+		if todos, _ := repos.Todos.ListByUser(ctx, u.ID.String(), 0, 0); len(todos) != 0 {
+			t.Errorf("Expected no visible todos after delete, got %d", len(todos))
+		}
+		if _, err := repos.Users.Update(ctx, u.ID.String(), "Renamed", nil); err != user.ErrNotFound {
+			t.Errorf("Update after delete: expected ErrNotFound, got %v", err)
+		}
+
+		if err := repos.Users.Delete(ctx, u.ID.String(), nil); err != user.ErrNotFound {
+			t.Errorf("Delete again: expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("Restore", func(t *testing.T) {
+		repos := newRepos(t)
+		u := createUser(t, repos.Users)
+		kept := createTodo(t, repos.Todos, u.ID, todo.CreateInput{Title: "Back again"})
+
+		if err := repos.Users.Restore(ctx, u.ID.String()); err != user.ErrNotFound {
+			t.Errorf("Restore active user: expected ErrNotFound, got %v", err)
+		}
+
+		repos.Users.Delete(ctx, u.ID.String(), nil)
+		if err := repos.Users.Restore(ctx, u.ID.String()); err != nil {
+			t.Fatalf("Restore: expected no error, got %v", err)
+		}
+
+		if active, _ := repos.Users.IsActive(ctx, u.ID.String()); !active {
+			t.Errorf("Expected user active after restore")
+		}
+		if _, err := repos.Todos.GetByID(ctx, kept.ID.String()); err != nil {
+			t.Errorf("Todo after restore: expected no error, got %v", err)
+		}
+
+		if err := repos.Users.Restore(ctx, uuid.NewString()); err != user.ErrNotFound {
+			t.Errorf("Restore unknown: expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("IsActive", func(t *testing.T) {
+		repos := newRepos(t)
+		u := createUser(t, repos.Users)
+
+		for id, want := range map[string]bool{
+			u.ID.String():    true,
+			uuid.NewString(): false,
+			"not-a-uuid":     false,
+		} {
+			if active, err := repos.Users.IsActive(ctx, id); err != nil || active != want {
+				t.Errorf("IsActive(%q): expected %v, got %v (%v)", id, want, active, err)
+			}
+		}
+	})
+}
@@ -1,17 +1,203 @@
 package repository
 
 import (
+	"fmt"
+	"time"
+
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/authz"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/passwordreset"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/session"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/todo"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/user"
 	"github.com/chameleon-db/chameleondb/chameleon/pkg/engine"
+	"github.com/google/uuid"
 )
 
-func rowToMap(row engine.Row) map[string]interface{} {
-	return map[string]interface{}(row)
+// rowReader decodes typed values from an engine.Row, keeping the first
+// error so a mapping function can read every column and check once
+type rowReader struct {
+	row engine.Row
+	err error
+}
+
+func (r *rowReader) fail(field string, v interface{}, want string) {
+	if r.err == nil {
+		r.err = fmt.Errorf("column %q: expected %s, got %T", field, want, v)
+	}
+}
+
+func (r *rowReader) uuid(field string) uuid.UUID {
+	switch v := r.row[field].(type) {
+	case [16]byte:
+		return v
+	case uuid.UUID:
+		return v
+	case string:
+		id, err := uuid.Parse(v)
+		if err != nil {
+			r.fail(field, v, "uuid")
+		}
+		return id
+	default:
+		r.fail(field, v, "uuid")
+		return uuid.Nil
+	}
+}
+
+func (r *rowReader) string(field string) string {
+	v, ok := r.row[field].(string)
+	if !ok {
+		r.fail(field, r.row[field], "string")
+	}
+	return v
+}
+
+func (r *rowReader) stringPtr(field string) *string {
+	if r.row[field] == nil {
+		return nil
+	}
+	v := r.string(field)
+	return &v
+}
+
+func (r *rowReader) bool(field string) bool {
+	v, ok := r.row[field].(bool)
+	if !ok {
+		r.fail(field, r.row[field], "bool")
+	}
+	return v
+}
+
+func (r *rowReader) time(field string) time.Time {
+	v, ok := r.row[field].(time.Time)
+	if !ok {
+		r.fail(field, r.row[field], "timestamp")
+	}
+	return v
+}
+
+func (r *rowReader) timePtr(field string) *time.Time {
+	if r.row[field] == nil {
+		return nil
+	}
+	v := r.time(field)
+	return &v
+}
+
+// timestampLiteral formats t for a query filter on a timestamp column.
+// Timestamps are stored in UTC (pgx drops the zone when writing), so the
+// literal is converted to UTC and written without an offset.
+func timestampLiteral(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04:05.999999")
 }
 
-func rowsToMaps(rows []engine.Row) []map[string]interface{} {
-	out := make([]map[string]interface{}, 0, len(rows))
+// rowToTodo maps a Todo row to the domain model
+func rowToTodo(row engine.Row) (*todo.Todo, error) {
+	rd := rowReader{row: row}
+	t := &todo.Todo{
+		ID:          rd.uuid("id"),
+		UserID:      rd.uuid("user_id"),
+		Title:       rd.string("title"),
+		Description: rd.stringPtr("description"),
+		Completed:   rd.bool("completed"),
+		DueDate:     rd.timePtr("due_date"),
+		CreatedAt:   rd.time("created_at"),
+		UpdatedAt:   rd.time("updated_at"),
+	}
+	if rd.err != nil {
+		return nil, fmt.Errorf("failed to decode todo: %w", rd.err)
+	}
+	return t, nil
+}
+
+func rowsToTodos(rows []engine.Row) ([]todo.Todo, error) {
+	out := make([]todo.Todo, 0, len(rows))
+	for _, row := range rows {
+		t, err := rowToTodo(row)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *t)
+	}
+	return out, nil
+}
+
+// rowToUser maps a User row to the domain model
+func rowToUser(row engine.Row) (*user.User, error) {
+	rd := rowReader{row: row}
+	u := &user.User{
+		ID:           rd.uuid("id"),
+		Email:        rd.string("email"),
+		Name:         rd.string("name"),
+		PasswordHash: rd.string("password_hash"),
+		Role:         authz.Role(rd.string("role")),
+		IsActive:     rd.bool("is_active"),
+		CreatedAt:    rd.time("created_at"),
+		UpdatedAt:    rd.time("updated_at"),
+	}
+	if rd.err != nil {
+		return nil, fmt.Errorf("failed to decode user: %w", rd.err)
+	}
+	return u, nil
+}
+
+func rowsToUsers(rows []engine.Row) ([]user.User, error) {
+	out := make([]user.User, 0, len(rows))
 	for _, row := range rows {
-		out = append(out, rowToMap(row))
+		u, err := rowToUser(row)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *u)
+	}
+	return out, nil
+}
+
+// rowToSession maps a Session row to the domain model
+func rowToSession(row engine.Row) (*session.Session, error) {
+	rd := rowReader{row: row}
+	s := &session.Session{
+		ID:        rd.uuid("id"),
+		UserID:    rd.uuid("user_id"),
+		TokenHash: rd.string("token_hash"),
+		UserAgent: rd.string("user_agent"),
+		IP:        rd.string("ip"),
+		ExpiresAt: rd.time("expires_at"),
+		RevokedAt: rd.timePtr("revoked_at"),
+		CreatedAt: rd.time("created_at"),
+		UpdatedAt: rd.time("updated_at"),
+	}
+	if rd.err != nil {
+		return nil, fmt.Errorf("failed to decode session: %w", rd.err)
+	}
+	return s, nil
+}
+
+func rowsToSessions(rows []engine.Row) ([]session.Session, error) {
+	out := make([]session.Session, 0, len(rows))
+	for _, row := range rows {
+		s, err := rowToSession(row)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *s)
+	}
+	return out, nil
+}
+
+// rowToResetToken maps a PasswordReset row to the domain model
+func rowToResetToken(row engine.Row) (*passwordreset.Token, error) {
+	rd := rowReader{row: row}
+	t := &passwordreset.Token{
+		ID:        rd.uuid("id"),
+		UserID:    rd.uuid("user_id"),
+		TokenHash: rd.string("token_hash"),
+		ExpiresAt: rd.time("expires_at"),
+		UsedAt:    rd.timePtr("used_at"),
+		CreatedAt: rd.time("created_at"),
+	}
+	if rd.err != nil {
+		return nil, fmt.Errorf("failed to decode password reset: %w", rd.err)
 	}
-	return out
+	return t, nil
 }
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/chameleon-db/chameleondb/chameleon/pkg/engine"
+)
+
+// column describes how a mapper in this package reads a field
+type column struct {
+	kind     string // engine.FieldType kind: "UUID", "String", "Bool", "Timestamp"
+	nullable bool
+}
+
+// mappedEntities lists every field read by rowToTodo / rowToUser /
+// rowToSession / rowToResetToken or filtered on by the repositories.
+// Keep in sync with schemas/*.cham; VerifySchema fails on drift.
+var mappedEntities = map[string]map[string]column{
+	"Todo": {
+		"id":          {kind: "UUID"},
+		"user_id":     {kind: "UUID"},
+		"title":       {kind: "String"},
+		"description": {kind: "String", nullable: true},
+		"completed":   {kind: "Bool"},
+		"archived":    {kind: "Bool"},
+		"due_date":    {kind: "Timestamp", nullable: true},
+		"created_at":  {kind: "Timestamp"},
+		"updated_at":  {kind: "Timestamp"},
+	},
+	"User": {
+		"id":            {kind: "UUID"},
+		"email":         {kind: "String"},
+		"name":          {kind: "String"},
+		"password_hash": {kind: "String"},
+		"role":          {kind: "String"},
+		"is_active":     {kind: "Bool"},
+		"created_at":    {kind: "Timestamp"},
+		"updated_at":    {kind: "Timestamp"},
+	},
+	"Session": {
+		"id":         {kind: "UUID"},
+		"user_id":    {kind: "UUID"},
+		"token_hash": {kind: "String"},
+		"user_agent": {kind: "String"},
+		"ip":         {kind: "String"},
+		"expires_at": {kind: "Timestamp"},
+		"revoked_at": {kind: "Timestamp", nullable: true},
+		"created_at": {kind: "Timestamp"},
+		"updated_at": {kind: "Timestamp"},
+	},
+	"PasswordReset": {
+		"id":         {kind: "UUID"},
+		"user_id":    {kind: "UUID"},
+		"token_hash": {kind: "String"},
+		"expires_at": {kind: "Timestamp"},
+		"used_at":    {kind: "Timestamp", nullable: true},
+		"created_at": {kind: "Timestamp"},
+	},
+}
+
+// tables names the table of every entity in mappedEntities, as the engine's
+// migrations create it, for the SQL this package writes itself
+var tables = map[string]string{
+	"Todo":          "todos",
+	"User":          "users",
+	"Session":       "sessions",
+	"PasswordReset": "password_resets",
+}
+
+// tableOf returns the table of entity
+func tableOf(entity string) (string, error) {
+	table, ok := tables[entity]
+	if !ok {
+		return "", fmt.Errorf("no table known for entity %s", entity)
+	}
+	return table, nil
+}
+
+// VerifySchema checks that the loaded schema matches the typed domain
+// models, so drift between schemas/*.cham and the mappers fails at startup
+// instead of on the first request that touches a changed column
+func VerifySchema(schema *engine.Schema) error {
+	if schema == nil {
+		return fmt.Errorf("schema not loaded")
+	}
+
+	entities := make([]string, 0, len(mappedEntities))
+	for name := range mappedEntities {
+		entities = append(entities, name)
+	}
+	sort.Strings(entities)
+
+	for _, name := range entities {
+		entity := schema.GetEntity(name)
+		if entity == nil {
+			return fmt.Errorf("schema drift: entity %s not found", name)
+		}
+
+		for field, want := range mappedEntities[name] {
+			got, ok := entity.Fields[field]
+			if !ok {
+				return fmt.Errorf("schema drift: %s.%s not found", name, field)
+			}
+			if got.Type.Kind != want.kind {
+				return fmt.Errorf("schema drift: %s.%s is %s, mapper expects %s", name, field, got.Type.Kind, want.kind)
+			}
+			if got.Nullable != want.nullable {
+				return fmt.Errorf("schema drift: %s.%s nullable=%t, mapper expects nullable=%t", name, field, got.Nullable, want.nullable)
+			}
+		}
+	}
+
+	return nil
+}
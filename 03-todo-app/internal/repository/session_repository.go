@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/session"
+	"github.com/chameleon-db/chameleondb/chameleon/pkg/engine"
+	_ "github.com/chameleon-db/chameleondb/chameleon/pkg/engine/mutation"
+	"github.com/google/uuid"
+)
+
+// SessionRepository implements session.Repository.
+//
+// The engine's filters cannot test for NULL, so the writes conditional on
+// revoked_at being unset run as raw SQL, inside the context's transaction
+// when there is one.
+type SessionRepository struct {
+	engine *engine.Engine
+}
+
+// NewSessionRepository creates a new session repository
+func NewSessionRepository(eng *engine.Engine) session.Repository {
+	return &SessionRepository{engine: eng}
+}
+
+// Create inserts new session via ChameleonDB
+func (r *SessionRepository) Create(ctx context.Context, userID, tokenHash string, client session.Client, expiresAt time.Time) (*session.Session, error) {
+	result, err := newInsert(ctx, r.engine, "Session").
+		Set("id", uuid.New().String()).
+		Set("user_id", userID).
+		Set("token_hash", tokenHash).
+		Set("user_agent", client.UserAgent).
+		Set("ip", client.IP).
+		Set("expires_at", expiresAt.UTC()).
+		Execute(ctx)
+
+	if err != nil {
+		return nil, sessionError("create session", err)
+	}
+
+	if result == nil {
+		return nil, fmt.Errorf("failed to create session: empty result")
+	}
+
+	if result.Record == nil {
+		return nil, fmt.Errorf("failed to create session: missing record")
+	}
+
+	return rowToSession(result.Record)
+}
+
+// GetByID retrieves session by ID, revoked or not
+func (r *SessionRepository) GetByID(ctx context.Context, id string) (*session.Session, error) {
+	query := newQuery(r.engine, "Session").
+		Filter("id", "eq", id)
+
+	result, err := runQuery(ctx, query)
+
+	if err != nil {
+		return nil, sessionError("query session", err)
+	}
+
+	if result == nil || result.IsEmpty() {
+		return nil, session.ErrNotFound
+	}
+
+	return rowToSession(result.Rows[0])
+}
+
+// ListActive returns user's unrevoked sessions expiring after now, most
+// recently refreshed first
+func (r *SessionRepository) ListActive(ctx context.Context, userID string, now time.Time) ([]session.Session, error) {
+	rows, err := rawQuery(ctx, r.engine, "Session", opQuery, []string{"user_id eq", "revoked_at null", "expires_at gt"},
+		`SELECT * FROM sessions
+		 WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2
+		 ORDER BY updated_at DESC`,
+		userID, now.UTC(),
+	)
+	if err != nil {
+		return nil, sessionError("list sessions", err)
+	}
+
+	return rowsToSessions(rows)
+}
+
+// Rotate replaces the token hash of an unrevoked session still holding
+// oldHash; of two concurrent rotations only one matches
+func (r *SessionRepository) Rotate(ctx context.Context, id, oldHash, newHash string, client session.Client, expiresAt time.Time) (*session.Session, error) {
+	rows, err := rawQuery(ctx, r.engine, "Session", opUpdate, []string{"id eq", "token_hash eq", "revoked_at null"},
+		`UPDATE sessions
+		 SET token_hash = $3, user_agent = $4, ip = $5, expires_at = $6, updated_at = $7
+		 WHERE id = $1 AND token_hash = $2 AND revoked_at IS NULL
+		 RETURNING *`,
+		id, oldHash, newHash, client.UserAgent, client.IP, expiresAt.UTC(), timestamp(),
+	)
+	if err != nil {
+		return nil, sessionError("rotate session", err)
+	}
+
+	if len(rows) == 0 {
+		return nil, session.ErrNotFound
+	}
+
+	return rowToSession(rows[0])
+}
+
+// Revoke revokes an unrevoked session of userID
+func (r *SessionRepository) Revoke(ctx context.Context, id, userID string) error {
+	affected, err := rawExec(ctx, r.engine, "Session", []string{"id eq", "user_id eq", "revoked_at null"},
+		`UPDATE sessions SET revoked_at = $3, updated_at = $3
+		 WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`,
+		id, userID, timestamp(),
+	)
+	if err != nil {
+		return sessionError("revoke session", err)
+	}
+
+	if affected == 0 {
+		return session.ErrNotFound
+	}
+
+	return nil
+}
+
+// RevokeAll revokes every unrevoked session of userID
+func (r *SessionRepository) RevokeAll(ctx context.Context, userID string) error {
+	_, err := rawExec(ctx, r.engine, "Session", []string{"user_id eq", "revoked_at null"},
+		`UPDATE sessions SET revoked_at = $2, updated_at = $2
+		 WHERE user_id = $1 AND revoked_at IS NULL`,
+		userID, timestamp(),
+	)
+	if err != nil {
+		return sessionError("revoke sessions", err)
+	}
+
+	return nil
+}
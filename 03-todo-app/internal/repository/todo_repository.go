@@ -21,56 +21,79 @@ func NewTodoRepository(eng *engine.Engine) todo.Repository {
 	return &TodoRepository{engine: eng}
 }
 
+// notArchived skips archived todos, which belong to deactivated users and
+// stay hidden until the user is restored
+var notArchived = condition{field: "archived", op: "eq", value: false}
+
+// visible starts a Todo query that skips archived todos
+func (r *TodoRepository) visible() *query {
+	return conditions{notArchived}.apply(newQuery(r.engine, "Todo"))
+}
+
+// overdue selects userID's visible, incomplete todos due before now. The
+// engine has no IS NOT NULL filter, but "due_date < now" never matches a
+// NULL due_date in SQL, so todos without one are excluded.
+func overdue(userID string, now time.Time) conditions {
+	return conditions{
+		notArchived,
+		{field: "user_id", op: "eq", value: userID},
+		{field: "completed", op: "eq", value: false},
+		{field: "due_date", op: "lt", value: now},
+	}
+}
+
 // Create inserts new todo via ChameleonDB
-func (r *TodoRepository) Create(ctx context.Context, userID, title, description string) (map[string]interface{}, error) {
-	result, err := r.engine.Insert("Todo").
+func (r *TodoRepository) Create(ctx context.Context, userID string, in todo.CreateInput) (*todo.Todo, error) {
+	insert := newInsert(ctx, r.engine, "Todo").
 		Set("id", uuid.New().String()).
 		Set("user_id", userID).
-		Set("title", title).
-		Set("description", description).
+		Set("title", in.Title).
+		Set("description", in.Description).
 		Set("completed", false).
-		Debug().
-		Execute(ctx)
+		Set("archived", false)
+
+	if in.DueDate != nil {
+		insert = insert.Set("due_date", in.DueDate.UTC())
+	}
+
+	result, err := insert.Execute(ctx)
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to create todo: %w", err)
+		return nil, todoError("create todo", err)
 	}
 
 	if result == nil {
 		return nil, fmt.Errorf("failed to create todo: empty result")
 	}
 
-	if result.Record != nil {
-		return result.Record, nil
-	}
-
-	if result.ID != nil {
-		return map[string]interface{}{"id": result.ID}, nil
+	if result.Record == nil {
+		return nil, fmt.Errorf("failed to create todo: missing record")
 	}
 
-	return nil, fmt.Errorf("failed to create todo: missing record")
+	return rowToTodo(result.Record)
 }
 
 // GetByID retrieves todo by ID
-func (r *TodoRepository) GetByID(ctx context.Context, id string) (map[string]interface{}, error) {
-	result, err := r.engine.Query("Todo").
-		Filter("id", "eq", id).
-		Execute(ctx)
+func (r *TodoRepository) GetByID(ctx context.Context, id string) (*todo.Todo, error) {
+	query := r.visible().
+		Filter("id", "eq", id)
+
+	result, err := runQuery(ctx, query)
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to query todo: %w", err)
+		return nil, todoError("query todo", err)
 	}
 
 	if result == nil || result.IsEmpty() {
 		return nil, todo.ErrNotFound
 	}
 
-	return rowToMap(result.Rows[0]), nil
+	return rowToTodo(result.Rows[0])
 }
 
 // ListByUser returns user's todos (paginated)
-func (r *TodoRepository) ListByUser(ctx context.Context, userID string, limit, offset int) ([]map[string]interface{}, error) {
-	query := r.engine.Query("Todo").
+func (r *TodoRepository) ListByUser(ctx context.Context, userID string, limit, offset int) ([]todo.Todo, error) {
+	query := r.visible().
 		Filter("user_id", "eq", userID)
 
 	if limit > 0 {
@@ -80,26 +103,33 @@ func (r *TodoRepository) ListByUser(ctx context.Context, userID string, limit, o
 		query = query.Offset(uint64(offset))
 	}
 
-	result, err := query.Execute(ctx)
+	result, err := runQuery(ctx, query)
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to list todos: %w", err)
+		return nil, todoError("list todos", err)
 	}
 
 	if result == nil {
 		return nil, fmt.Errorf("failed to list todos: empty result")
 	}
 
-	return rowsToMaps(result.Rows), nil
+	return rowsToTodos(result.Rows)
 }
 
-// ListByUserFiltered returns user's todos with completion filter
-func (r *TodoRepository) ListByUserFiltered(ctx context.Context, userID string, completed *bool, limit, offset int) ([]map[string]interface{}, error) {
-	query := r.engine.Query("Todo").
+// ListByUserFiltered returns user's todos matching filter.
+// Due date ranges are compared in the database, not in Go.
+func (r *TodoRepository) ListByUserFiltered(ctx context.Context, userID string, filter todo.ListFilter, limit, offset int) ([]todo.Todo, error) {
+	query := r.visible().
 		Filter("user_id", "eq", userID)
 
-	if completed != nil {
-		query = query.Filter("completed", "eq", *completed)
+	if filter.Completed != nil {
+		query = query.Filter("completed", "eq", *filter.Completed)
+	}
+	if filter.DueAfter != nil {
+		query = query.Filter("due_date", "gte", timestampLiteral(*filter.DueAfter))
+	}
+	if filter.DueBefore != nil {
+		query = query.Filter("due_date", "lt", timestampLiteral(*filter.DueBefore))
 	}
 	if limit > 0 {
 		query = query.Limit(uint64(limit))
@@ -108,101 +138,249 @@ func (r *TodoRepository) ListByUserFiltered(ctx context.Context, userID string,
 		query = query.Offset(uint64(offset))
 	}
 
-	result, err := query.Execute(ctx)
+	result, err := runQuery(ctx, query)
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to list todos: %w", err)
+		return nil, todoError("list todos", err)
 	}
 
 	if result == nil {
 		return nil, fmt.Errorf("failed to list todos: empty result")
 	}
 
-	return rowsToMaps(result.Rows), nil
+	return rowsToTodos(result.Rows)
 }
 
-// Update updates todo
-func (r *TodoRepository) Update(ctx context.Context, id, title, description string, completed bool) error {
-	result, err := r.engine.Update("Todo").
+// Update replaces the fields of a todo owned by userID and returns the
+// updated row
+func (r *TodoRepository) Update(ctx context.Context, id, userID string, in todo.UpdateInput) (*todo.Todo, error) {
+	var dueDate interface{}
+	if in.DueDate != nil {
+		dueDate = in.DueDate.UTC()
+	}
+
+	update := newUpdate(ctx, r.engine, "Todo").
 		Filter("id", "eq", id).
-		Set("title", title).
-		Set("description", description).
-		Set("completed", completed).
-		Debug().
-		Execute(ctx)
+		Filter("user_id", "eq", userID).
+		Filter("archived", "eq", false).
+		Set("title", in.Title).
+		Set("description", in.Description).
+		Set("completed", in.Completed).
+		Set("due_date", dueDate)
+
+	if in.Version != nil {
+		update = update.Filter("updated_at", "eq", in.Version.UTC())
+	}
+
+	result, err := update.Execute(ctx)
 
 	if err != nil {
-		return fmt.Errorf("failed to update todo: %w", err)
+		return nil, todoError("update todo", err)
 	}
 
-	if result != nil && result.Affected == 0 {
-		return todo.ErrNotFound
+	if result == nil || result.Affected == 0 || len(result.Records) == 0 {
+		return nil, r.missed(ctx, id, userID, in.Version)
 	}
 
-	return nil
+	return rowToTodo(result.Records[0])
 }
 
-// Delete deletes todo
-func (r *TodoRepository) Delete(ctx context.Context, id string) error {
-	result, err := r.engine.Delete("Todo").
+// Patch updates only the fields set in patch on a todo owned by userID
+// and returns the updated row
+func (r *TodoRepository) Patch(ctx context.Context, id, userID string, patch todo.Patch) (*todo.Todo, error) {
+	update := newUpdate(ctx, r.engine, "Todo").
 		Filter("id", "eq", id).
-		Debug().
-		Execute(ctx)
+		Filter("user_id", "eq", userID).
+		Filter("archived", "eq", false)
+
+	if patch.Version != nil {
+		update = update.Filter("updated_at", "eq", patch.Version.UTC())
+	}
+
+	if patch.Title != nil {
+		update = update.Set("title", *patch.Title)
+	}
+	if patch.Description != nil {
+		update = update.Set("description", *patch.Description)
+	} else if patch.ClearDescription {
+		update = update.Set("description", nil)
+	}
+	if patch.Completed != nil {
+		update = update.Set("completed", *patch.Completed)
+	}
+	if patch.DueDate != nil {
+		update = update.Set("due_date", patch.DueDate.UTC())
+	} else if patch.ClearDueDate {
+		update = update.Set("due_date", nil)
+	}
+
+	result, err := update.Execute(ctx)
+
+	if err != nil {
+		return nil, todoError("patch todo", err)
+	}
+
+	if result == nil || result.Affected == 0 || len(result.Records) == 0 {
+		return nil, r.missed(ctx, id, userID, patch.Version)
+	}
+
+	return rowToTodo(result.Records[0])
+}
+
+// Delete deletes a todo owned by userID
+func (r *TodoRepository) Delete(ctx context.Context, id, userID string, version *time.Time) error {
+	del := newDelete(ctx, r.engine, "Todo").
+		Filter("id", "eq", id).
+		Filter("user_id", "eq", userID).
+		Filter("archived", "eq", false)
+
+	if version != nil {
+		del = del.Filter("updated_at", "eq", version.UTC())
+	}
+
+	result, err := del.Execute(ctx)
 
 	if err != nil {
-		return fmt.Errorf("failed to delete todo: %w", err)
+		return todoError("delete todo", err)
 	}
 
 	if result != nil && result.Affected == 0 {
-		return todo.ErrNotFound
+		return r.missed(ctx, id, userID, version)
 	}
 
 	return nil
 }
 
-// GetOverdue returns overdue todos for user
-func (r *TodoRepository) GetOverdue(ctx context.Context, userID string) ([]map[string]interface{}, error) {
-	now := time.Now()
+// Toggle flips completed on a todo owned by userID in a single statement,
+// so it needs no version check and never loses a concurrent write
+func (r *TodoRepository) Toggle(ctx context.Context, id, userID string) (*todo.Todo, error) {
+	table, err := tableOf("Todo")
+	if err != nil {
+		return nil, todoError("toggle todo", err)
+	}
 
-	result, err := r.engine.Query("Todo").
-		Filter("user_id", "eq", userID).
-		Filter("completed", "eq", false).
-		Execute(ctx)
+	// The update builders can only set values, not flip a column in place
+	cond := conditions{
+		{field: "id", op: "eq", value: id},
+		{field: "user_id", op: "eq", value: userID},
+		notArchived,
+	}
+	where, args, err := cond.where(2)
+	if err != nil {
+		return nil, todoError("toggle todo", err)
+	}
+
+	rows, err := rawQuery(ctx, r.engine, "Todo", opUpdate, cond.names(),
+		`UPDATE `+table+` SET completed = NOT completed, updated_at = $1 WHERE `+where+` RETURNING *`,
+		append([]interface{}{timestamp()}, args...)...)
+	if err != nil {
+		return nil, todoError("toggle todo", err)
+	}
+
+	if len(rows) == 0 {
+		return nil, todo.ErrNotFound
+	}
+
+	return rowToTodo(rows[0])
+}
+
+// missed explains a conditional write that matched no row: the todo is
+// either gone or was changed after the caller read version
+func (r *TodoRepository) missed(ctx context.Context, id, userID string, version *time.Time) error {
+	if version == nil {
+		return todo.ErrNotFound
+	}
+
+	if _, err := r.GetByIDForUser(ctx, id, userID); err != nil {
+		return err
+	}
+
+	return todo.ErrVersionMismatch
+}
+
+// GetOverdue returns a page of incomplete todos due before now, oldest
+// due first, plus the total number of such todos
+func (r *TodoRepository) GetOverdue(ctx context.Context, userID string, now time.Time, limit, offset int) ([]todo.Todo, int, error) {
+	query := overdue(userID, now).apply(newQuery(r.engine, "Todo")).
+		OrderBy("due_date", "asc")
+
+	if limit > 0 {
+		query = query.Limit(uint64(limit))
+	}
+	if offset > 0 {
+		query = query.Offset(uint64(offset))
+	}
+
+	result, err := runQuery(ctx, query)
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to query overdue todos: %w", err)
+		return nil, 0, todoError("query overdue todos", err)
 	}
 
 	if result == nil {
-		return nil, fmt.Errorf("failed to query overdue todos: empty result")
+		return nil, 0, fmt.Errorf("failed to query overdue todos: empty result")
+	}
+
+	todos, err := rowsToTodos(result.Rows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := r.countOverdue(ctx, userID, now)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return todos, total, nil
+}
+
+// countOverdue counts the rows GetOverdue pages through, with the same
+// conditions. The query builder cannot express COUNT(*), so this runs raw
+// SQL.
+func (r *TodoRepository) countOverdue(ctx context.Context, userID string, now time.Time) (int, error) {
+	table, err := tableOf("Todo")
+	if err != nil {
+		return 0, todoError("count overdue todos", err)
+	}
+
+	cond := overdue(userID, now)
+	where, args, err := cond.where(1)
+	if err != nil {
+		return 0, todoError("count overdue todos", err)
+	}
+
+	rows, err := rawQuery(ctx, r.engine, "Todo", opQuery, cond.names(),
+		`SELECT COUNT(*) AS total FROM `+table+` WHERE `+where, args...)
+	if err != nil {
+		return 0, todoError("count overdue todos", err)
+	}
+	if len(rows) == 0 {
+		return 0, fmt.Errorf("failed to count overdue todos: empty result")
 	}
 
-	// Filter overdue todos (due_date < now)
-	var overdue []map[string]interface{}
-	for _, t := range result.Rows {
-		dueDate, ok := t["due_date"].(time.Time)
-		if ok && dueDate.Before(now) {
-			overdue = append(overdue, rowToMap(t))
-		}
+	total, ok := rows[0]["total"].(int64)
+	if !ok {
+		return 0, fmt.Errorf("failed to count overdue todos: unexpected total %T", rows[0]["total"])
 	}
 
-	return overdue, nil
+	return int(total), nil
 }
 
 // GetByIDForUser retrieves todo and validates it belongs to user
-func (r *TodoRepository) GetByIDForUser(ctx context.Context, id, userID string) (map[string]interface{}, error) {
-	result, err := r.engine.Query("Todo").
+func (r *TodoRepository) GetByIDForUser(ctx context.Context, id, userID string) (*todo.Todo, error) {
+	query := r.visible().
 		Filter("id", "eq", id).
-		Filter("user_id", "eq", userID).
-		Execute(ctx)
+		Filter("user_id", "eq", userID)
+
+	result, err := runQuery(ctx, query)
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to query todo: %w", err)
+		return nil, todoError("query todo", err)
 	}
 
 	if result == nil || result.IsEmpty() {
 		return nil, todo.ErrNotFound
 	}
 
-	return rowToMap(result.Rows[0]), nil
+	return rowToTodo(result.Rows[0])
 }
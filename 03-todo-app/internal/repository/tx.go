@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/chameleon-db/chameleondb/chameleon/pkg/engine"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// errNotConnected is returned when raw SQL is needed but the engine has no pool
+var errNotConnected = errors.New("not connected")
+
+// querier is what raw SQL needs; both the pool and pgx.Tx satisfy it
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+// querierFor returns the context's transaction, or the engine's pool
+func querierFor(ctx context.Context, eng *engine.Engine) (querier, error) {
+	if tx, ok := txFromContext(ctx); ok {
+		return tx, nil
+	}
+
+	conn := eng.Connector()
+	if conn == nil || !conn.IsConnected() {
+		return nil, errNotConnected
+	}
+
+	return conn.Pool(), nil
+}
+
+// inTx runs fn in a single database transaction, committing only when fn
+// returns nil. Inside a UnitOfWork it joins the open transaction instead.
+func inTx(ctx context.Context, eng *engine.Engine, fn func(tx pgx.Tx) error) error {
+	if tx, ok := txFromContext(ctx); ok {
+		return fn(tx)
+	}
+
+	conn := eng.Connector()
+	if conn == nil || !conn.IsConnected() {
+		return errNotConnected
+	}
+
+	tx, err := conn.Pool().BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// rawQuery runs SQL returning rows of entity, inside the context's
+// transaction when there is one, and reports it to the observer
+func rawQuery(ctx context.Context, eng *engine.Engine, entity, operation string, filters []string, sql string, args ...interface{}) ([]engine.Row, error) {
+	q, err := querierFor(ctx, eng)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []map[string]interface{}
+	err = observe(ctx, entity, operation, filters, func(ctx context.Context) error {
+		rows, err := q.Query(ctx, sql, args...)
+		if err != nil {
+			return err
+		}
+		records, err = collectRecords(rows)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]engine.Row, len(records))
+	for i, record := range records {
+		out[i] = engine.Row(record)
+	}
+	return out, nil
+}
+
+// rawExec runs an UPDATE on entity like rawQuery and returns the number
+// of rows it changed
+func rawExec(ctx context.Context, eng *engine.Engine, entity string, filters []string, sql string, args ...interface{}) (int64, error) {
+	q, err := querierFor(ctx, eng)
+	if err != nil {
+		return 0, err
+	}
+
+	var affected int64
+	err = observe(ctx, entity, opUpdate, filters, func(ctx context.Context) error {
+		tag, err := q.Exec(ctx, sql, args...)
+		affected = tag.RowsAffected()
+		return err
+	})
+
+	return affected, err
+}
@@ -0,0 +1,317 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/chameleon-db/chameleondb/chameleon/pkg/engine"
+	"github.com/jackc/pgx/v5"
+)
+
+// UnitOfWork runs repository calls in a single database transaction.
+//
+// The engine has no transaction support and its builders always execute
+// on the connection pool, so while a transaction is open the repositories
+// render queries with ToSQL and run mutations through tx-bound builders
+// that validate exactly like the engine's own and write explicit
+// parameterized SQL.
+// Calls made with any other context keep using the pool.
+type UnitOfWork struct {
+	engine *engine.Engine
+}
+
+// NewUnitOfWork creates a unit of work over the engine's connection pool
+func NewUnitOfWork(eng *engine.Engine) *UnitOfWork {
+	return &UnitOfWork{engine: eng}
+}
+
+// WithTx runs fn in a transaction. Repository calls made with the context
+// passed to fn join it; it commits when fn returns nil and rolls back
+// otherwise. A WithTx nested inside another joins the outer transaction.
+func (u *UnitOfWork) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return inTx(ctx, u.engine, func(tx pgx.Tx) error {
+		return fn(contextWithTx(ctx, tx))
+	})
+}
+
+// txKey is the context key for the open transaction
+type txKey struct{}
+
+func contextWithTx(ctx context.Context, tx pgx.Tx) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// txFromContext returns the transaction opened by WithTx, if any
+func txFromContext(ctx context.Context) (pgx.Tx, bool) {
+	tx, ok := ctx.Value(txKey{}).(pgx.Tx)
+	return tx, ok
+}
+
+// runQuery executes a query built with newQuery, inside the context's
+// transaction when there is one, and reports it to the observer
+func runQuery(ctx context.Context, q *query) (result *engine.QueryResult, err error) {
+	err = observe(ctx, q.entity, opQuery, q.filters, func(ctx context.Context) error {
+		result, err = executeQuery(ctx, q.builder)
+		return err
+	})
+	return result, err
+}
+
+func executeQuery(ctx context.Context, qb *engine.QueryBuilder) (*engine.QueryResult, error) {
+	tx, ok := txFromContext(ctx)
+	if !ok {
+		return qb.Execute(ctx)
+	}
+
+	generated, err := qb.ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("SQL generation failed: %w", err)
+	}
+
+	rows, err := tx.Query(ctx, generated.MainQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := collectRecords(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &engine.QueryResult{Rows: make([]engine.Row, len(records))}
+	for i, record := range records {
+		result.Rows[i] = engine.Row(record)
+	}
+	return result, nil
+}
+
+// collectRecords reads every row into a column name → value map
+func collectRecords(rows pgx.Rows) ([]map[string]interface{}, error) {
+	defer rows.Close()
+
+	var records []map[string]interface{}
+	columns := rows.FieldDescriptions()
+
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		record := make(map[string]interface{}, len(columns))
+		for i, col := range columns {
+			record[col.Name] = values[i]
+		}
+		records = append(records, record)
+	}
+
+	return records, rows.Err()
+}
+
+// txMutation holds what the tx-bound builders share. They write their
+// own parameterized SQL: the table comes from tables and the WHERE clause
+// from the same conditions type the raw queries use.
+type txMutation struct {
+	tx      pgx.Tx
+	engine  *engine.Engine
+	entity  string
+	values  map[string]interface{}
+	filters conditions
+}
+
+func newTxMutation(tx pgx.Tx, eng *engine.Engine, entity string) txMutation {
+	return txMutation{
+		tx:     tx,
+		engine: eng,
+		entity: entity,
+		values: make(map[string]interface{}),
+	}
+}
+
+func (m *txMutation) validator() *engine.Validator {
+	return engine.NewValidator(m.engine.Schema(), engine.DefaultValidatorConfig())
+}
+
+func (m *txMutation) filter(field, op string, value interface{}) {
+	m.filters = append(m.filters, condition{field: field, op: op, value: value})
+}
+
+// filterFields returns the filters keyed by field, for validation
+func (m *txMutation) filterFields() map[string]interface{} {
+	fields := make(map[string]interface{}, len(m.filters))
+	for _, c := range m.filters {
+		fields[c.field] = c.value
+	}
+	return fields
+}
+
+// sortedFields returns the keys of values in a stable order
+func sortedFields(values map[string]interface{}) []string {
+	fields := make([]string, 0, len(values))
+	for field := range values {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// txInsert implements engine.InsertMutation inside a transaction
+type txInsert struct{ txMutation }
+
+func (b *txInsert) Set(field string, value interface{}) engine.InsertMutation {
+	b.values[field] = value
+	return b
+}
+
+func (b *txInsert) Debug() engine.InsertMutation { return b }
+
+func (b *txInsert) Execute(ctx context.Context) (*engine.InsertResult, error) {
+	if err := b.validator().ValidateInsertInput(b.entity, b.values); err != nil {
+		return nil, err
+	}
+
+	table, err := tableOf(b.entity)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := sortedFields(b.values)
+	placeholders := make([]string, len(fields))
+	args := make([]interface{}, len(fields))
+	for i, field := range fields {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = b.values[field]
+	}
+
+	sql := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) RETURNING *",
+		table, strings.Join(fields, ", "), strings.Join(placeholders, ", "))
+
+	rows, err := b.tx.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := collectRecords(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("INSERT executed but returned no rows")
+	}
+
+	return &engine.InsertResult{ID: records[0]["id"], Record: records[0], Affected: 1}, nil
+}
+
+// txUpdate implements engine.UpdateMutation inside a transaction
+type txUpdate struct{ txMutation }
+
+func (b *txUpdate) Set(field string, value interface{}) engine.UpdateMutation {
+	b.values[field] = value
+	return b
+}
+
+func (b *txUpdate) Filter(field, op string, value interface{}) engine.UpdateMutation {
+	b.filter(field, op, value)
+	return b
+}
+
+func (b *txUpdate) Debug() engine.UpdateMutation { return b }
+
+// sql renders the UPDATE: the SET values take the first placeholders and
+// the filters the rest
+func (b *txUpdate) sql() (string, []interface{}, error) {
+	table, err := tableOf(b.entity)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(b.filters) == 0 {
+		return "", nil, fmt.Errorf("refusing to update every %s", b.entity)
+	}
+
+	fields := sortedFields(b.values)
+	sets := make([]string, len(fields))
+	args := make([]interface{}, len(fields))
+	for i, field := range fields {
+		sets[i] = fmt.Sprintf("%s = $%d", field, i+1)
+		args[i] = b.values[field]
+	}
+
+	where, filterArgs, err := b.filters.where(len(fields) + 1)
+	if err != nil {
+		return "", nil, err
+	}
+
+	sql := fmt.Sprintf("UPDATE %s SET %s WHERE %s RETURNING *",
+		table, strings.Join(sets, ", "), where)
+	return sql, append(args, filterArgs...), nil
+}
+
+func (b *txUpdate) Execute(ctx context.Context) (*engine.UpdateResult, error) {
+	if err := b.validator().ValidateUpdateInput(b.entity, b.filterFields(), b.values); err != nil {
+		return nil, err
+	}
+
+	sql, args, err := b.sql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := b.tx.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := collectRecords(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return &engine.UpdateResult{Records: records, Affected: len(records)}, nil
+}
+
+// txDelete implements engine.DeleteMutation inside a transaction
+type txDelete struct{ txMutation }
+
+func (b *txDelete) Filter(field, op string, value interface{}) engine.DeleteMutation {
+	b.filter(field, op, value)
+	return b
+}
+
+func (b *txDelete) Debug() engine.DeleteMutation { return b }
+
+// sql renders the DELETE with its filters as parameters
+func (b *txDelete) sql() (string, []interface{}, error) {
+	table, err := tableOf(b.entity)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(b.filters) == 0 {
+		return "", nil, fmt.Errorf("refusing to delete every %s", b.entity)
+	}
+
+	where, args, err := b.filters.where(1)
+	if err != nil {
+		return "", nil, err
+	}
+	return fmt.Sprintf("DELETE FROM %s WHERE %s", table, where), args, nil
+}
+
+func (b *txDelete) Execute(ctx context.Context) (*engine.DeleteResult, error) {
+	if err := b.validator().ValidateDeleteInput(b.entity, b.filterFields(), false); err != nil {
+		return nil, err
+	}
+
+	sql, args, err := b.sql()
+	if err != nil {
+		return nil, err
+	}
+
+	tag, err := b.tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &engine.DeleteResult{Affected: int(tag.RowsAffected())}, nil
+}
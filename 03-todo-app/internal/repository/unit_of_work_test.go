@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTxUpdateSQL(t *testing.T) {
+	now := time.Date(2026, 3, 1, 9, 30, 0, 0, time.FixedZone("CET", 3600))
+
+	b := &txUpdate{txMutation{entity: "Todo", values: map[string]interface{}{"title": "x", "completed": true}}}
+	b.Filter("id", "eq", "todo-1").Filter("due_date", "lt", now)
+
+	sql, args, err := b.sql()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if want := "UPDATE todos SET completed = $1, title = $2 WHERE id = $3 AND due_date < $4 RETURNING *"; sql != want {
+		t.Errorf("sql = %q, want %q", sql, want)
+	}
+	if want := []interface{}{true, "x", "todo-1", now.UTC()}; !reflect.DeepEqual(args, want) {
+		t.Errorf("args = %v, want %v", args, want)
+	}
+
+	unfiltered := &txUpdate{txMutation{entity: "Todo", values: map[string]interface{}{"title": "x"}}}
+	if _, _, err := unfiltered.sql(); err == nil {
+		t.Error("Expected an error for an update without filters")
+	}
+}
+
+func TestTxDeleteSQL(t *testing.T) {
+	b := &txDelete{txMutation{entity: "Session"}}
+	b.Filter("user_id", "eq", "user-1")
+
+	sql, args, err := b.sql()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if want := "DELETE FROM sessions WHERE user_id = $1"; sql != want {
+		t.Errorf("sql = %q, want %q", sql, want)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"user-1"}) {
+		t.Errorf("Unexpected args %v", args)
+	}
+
+	if _, _, err := (&txDelete{txMutation{entity: "Nope"}}).sql(); err == nil {
+		t.Error("Expected an error for an entity without a table")
+	}
+}
@@ -3,10 +3,14 @@ package repository
 import (
 	"context"
 	"fmt"
+	"time"
 
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/authz"
 	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/user"
 	"github.com/chameleon-db/chameleondb/chameleon/pkg/engine"
 	_ "github.com/chameleon-db/chameleondb/chameleon/pkg/engine/mutation"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
 // UserRepository implements user.Repository
@@ -19,72 +23,73 @@ func NewUserRepository(eng *engine.Engine) user.Repository {
 	return &UserRepository{engine: eng}
 }
 
-// Create inserts new user via ChameleonDB
-func (r *UserRepository) Create(ctx context.Context, email, name, passwordHash string) (map[string]interface{}, error) {
-	result, err := r.engine.Insert("User").
+// Create inserts new user via ChameleonDB, with the user role
+func (r *UserRepository) Create(ctx context.Context, email, name, passwordHash string) (*user.User, error) {
+	result, err := newInsert(ctx, r.engine, "User").
+		Set("id", uuid.New().String()).
 		Set("email", email).
 		Set("name", name).
 		Set("password_hash", passwordHash).
+		Set("role", string(authz.RoleUser)).
+		Set("is_active", true).
 		Execute(ctx)
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to create user: %w", err)
+		return nil, userError("create user", err)
 	}
 
 	if result == nil {
 		return nil, fmt.Errorf("failed to create user: empty result")
 	}
 
-	if result.Record != nil {
-		return result.Record, nil
+	if result.Record == nil {
+		return nil, fmt.Errorf("failed to create user: missing record")
 	}
 
-	if result.ID != nil {
-		return map[string]interface{}{"id": result.ID}, nil
-	}
-
-	return nil, fmt.Errorf("failed to create user: missing record")
+	return rowToUser(result.Record)
 }
 
 // GetByEmail retrieves user by email
-func (r *UserRepository) GetByEmail(ctx context.Context, email string) (map[string]interface{}, error) {
-	result, err := r.engine.Query("User").
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*user.User, error) {
+	query := newQuery(r.engine, "User").
 		Filter("email", "eq", email).
-		Filter("is_active", "eq", true).
-		Execute(ctx)
+		Filter("is_active", "eq", true)
+
+	result, err := runQuery(ctx, query)
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to query user: %w", err)
+		return nil, userError("query user", err)
 	}
 
 	if result == nil || result.IsEmpty() {
 		return nil, user.ErrNotFound
 	}
 
-	return rowToMap(result.Rows[0]), nil
+	return rowToUser(result.Rows[0])
 }
 
 // GetByID retrieves user by ID
-func (r *UserRepository) GetByID(ctx context.Context, id string) (map[string]interface{}, error) {
-	result, err := r.engine.Query("User").
+func (r *UserRepository) GetByID(ctx context.Context, id string) (*user.User, error) {
+	query := newQuery(r.engine, "User").
 		Filter("id", "eq", id).
-		Filter("is_active", "eq", true).
-		Execute(ctx)
+		Filter("is_active", "eq", true)
+
+	result, err := runQuery(ctx, query)
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to query user: %w", err)
+		return nil, userError("query user", err)
 	}
 
 	if result == nil || result.IsEmpty() {
 		return nil, user.ErrNotFound
 	}
 
-	return rowToMap(result.Rows[0]), nil
+	return rowToUser(result.Rows[0])
 }
 
 // List returns all active users (paginated)
-func (r *UserRepository) List(ctx context.Context, limit, offset int) ([]map[string]interface{}, error) {
-	query := r.engine.Query("User").
+func (r *UserRepository) List(ctx context.Context, limit, offset int) ([]user.User, error) {
+	query := newQuery(r.engine, "User").
 		Filter("is_active", "eq", true)
 
 	if limit > 0 {
@@ -94,51 +99,224 @@ func (r *UserRepository) List(ctx context.Context, limit, offset int) ([]map[str
 		query = query.Offset(uint64(offset))
 	}
 
-	result, err := query.Execute(ctx)
+	result, err := runQuery(ctx, query)
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to list users: %w", err)
+		return nil, userError("list users", err)
 	}
 
 	if result == nil {
 		return nil, fmt.Errorf("failed to list users: empty result")
 	}
 
-	return rowsToMaps(result.Rows), nil
+	return rowsToUsers(result.Rows)
 }
 
-// Update updates user (name only)
-func (r *UserRepository) Update(ctx context.Context, id, name string) error {
-	result, err := r.engine.Update("User").
+// Update updates user (name only) and returns the updated row
+func (r *UserRepository) Update(ctx context.Context, id, name string, version *time.Time) (*user.User, error) {
+	update := newUpdate(ctx, r.engine, "User").
 		Filter("id", "eq", id).
-		Set("name", name).
-		Execute(ctx)
+		Filter("is_active", "eq", true).
+		Set("name", name)
+
+	if version != nil {
+		update = update.Filter("updated_at", "eq", version.UTC())
+	}
+
+	result, err := update.Execute(ctx)
+
+	if err != nil {
+		return nil, userError("update user", err)
+	}
+
+	if result == nil || result.Affected == 0 || len(result.Records) == 0 {
+		return nil, r.missed(ctx, id, version)
+	}
+
+	return rowToUser(result.Records[0])
+}
+
+// Patch updates only the profile fields set in patch and returns the updated row
+func (r *UserRepository) Patch(ctx context.Context, id string, patch user.Patch) (*user.User, error) {
+	update := newUpdate(ctx, r.engine, "User").
+		Filter("id", "eq", id).
+		Filter("is_active", "eq", true)
+
+	if patch.Version != nil {
+		update = update.Filter("updated_at", "eq", patch.Version.UTC())
+	}
+
+	if patch.Name != nil {
+		update = update.Set("name", *patch.Name)
+	}
+
+	result, err := update.Execute(ctx)
+
+	if err != nil {
+		return nil, userError("patch user", err)
+	}
+
+	if result == nil || result.Affected == 0 || len(result.Records) == 0 {
+		return nil, r.missed(ctx, id, patch.Version)
+	}
+
+	return rowToUser(result.Records[0])
+}
+
+// Delete soft-deletes user (sets is_active = false) and archives their
+// todos in the same transaction, so both disappear together
+func (r *UserRepository) Delete(ctx context.Context, id string, version *time.Time) error {
+	now := timestamp()
+
+	var affected int64
+	err := observedTx(ctx, r.engine, "User", opUpdate, []string{"id eq"}, func(tx pgx.Tx) error {
+		query := `UPDATE users SET is_active = false, updated_at = $2
+		          WHERE id = $1 AND is_active = true`
+		args := []interface{}{id, now}
+		if version != nil {
+			query += ` AND updated_at = $3`
+			args = append(args, version.UTC())
+		}
+
+		tag, err := tx.Exec(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		if affected = tag.RowsAffected(); affected == 0 {
+			return nil
+		}
+
+		_, err = tx.Exec(ctx,
+			`UPDATE todos SET archived = true, updated_at = $2
+			 WHERE user_id = $1 AND archived = false`,
+			id, now,
+		)
+		return err
+	})
 
 	if err != nil {
-		return fmt.Errorf("failed to update user: %w", err)
+		return userError("delete user", err)
 	}
 
-	if result != nil && result.Affected == 0 {
+	if affected == 0 {
+		return r.missed(ctx, id, version)
+	}
+
+	return nil
+}
+
+// Restore reactivates a soft-deleted user and unarchives their todos in
+// the same transaction
+func (r *UserRepository) Restore(ctx context.Context, id string) error {
+	now := timestamp()
+
+	var affected int64
+	err := observedTx(ctx, r.engine, "User", opUpdate, []string{"id eq"}, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx,
+			`UPDATE users SET is_active = true, updated_at = $2
+			 WHERE id = $1 AND is_active = false`,
+			id, now,
+		)
+		if err != nil {
+			return err
+		}
+		if affected = tag.RowsAffected(); affected == 0 {
+			return nil
+		}
+
+		_, err = tx.Exec(ctx,
+			`UPDATE todos SET archived = false, updated_at = $2
+			 WHERE user_id = $1 AND archived = true`,
+			id, now,
+		)
+		return err
+	})
+
+	if err != nil {
+		return userError("restore user", err)
+	}
+
+	if affected == 0 {
 		return user.ErrNotFound
 	}
 
 	return nil
 }
 
-// Delete soft-deletes user (sets is_active = false)
-func (r *UserRepository) Delete(ctx context.Context, id string) error {
-	result, err := r.engine.Update("User").
+// SetRole changes an active user's role and returns the updated row
+func (r *UserRepository) SetRole(ctx context.Context, id string, role authz.Role) (*user.User, error) {
+	result, err := newUpdate(ctx, r.engine, "User").
 		Filter("id", "eq", id).
-		Set("is_active", false).
+		Filter("is_active", "eq", true).
+		Set("role", string(role)).
 		Execute(ctx)
 
 	if err != nil {
-		return fmt.Errorf("failed to delete user: %w", err)
+		return nil, userError("set user role", err)
 	}
 
-	if result != nil && result.Affected == 0 {
+	if result == nil || result.Affected == 0 || len(result.Records) == 0 {
+		return nil, user.ErrNotFound
+	}
+
+	return rowToUser(result.Records[0])
+}
+
+// SetPassword replaces an active user's password hash
+func (r *UserRepository) SetPassword(ctx context.Context, id, passwordHash string) error {
+	result, err := newUpdate(ctx, r.engine, "User").
+		Filter("id", "eq", id).
+		Filter("is_active", "eq", true).
+		Set("password_hash", passwordHash).
+		Execute(ctx)
+
+	if err != nil {
+		return userError("set user password", err)
+	}
+
+	if result == nil || result.Affected == 0 {
 		return user.ErrNotFound
 	}
 
 	return nil
 }
+
+// IsActive reports whether user exists and is active. The row is read
+// FOR SHARE, so inside a transaction the user cannot be deactivated until
+// it ends.
+func (r *UserRepository) IsActive(ctx context.Context, id string) (bool, error) {
+	q, err := querierFor(ctx, r.engine)
+	if err != nil {
+		return false, userError("query user", err)
+	}
+
+	var active bool
+	err = observe(ctx, "User", opQuery, []string{"id eq"}, func(ctx context.Context) error {
+		return q.QueryRow(ctx, `SELECT is_active FROM users WHERE id = $1 FOR SHARE`, id).Scan(&active)
+	})
+	if err == pgx.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		if err = userError("query user", err); err == user.ErrInvalidInput {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return active, nil
+}
+
+// missed explains a conditional write that matched no row: the user is
+// either gone or was changed after the caller read version
+func (r *UserRepository) missed(ctx context.Context, id string, version *time.Time) error {
+	if version == nil {
+		return user.ErrNotFound
+	}
+
+	if _, err := r.GetByID(ctx, id); err != nil {
+		return err
+	}
+
+	return user.ErrVersionMismatch
+}
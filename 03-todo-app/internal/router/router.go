@@ -1,61 +1,91 @@
 package router
 
 import (
+	"log/slog"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/auth"
 	"github.com/chameleon-db/chameleon-examples/todo-app/internal/handler"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/metrics"
 	appMiddleware "github.com/chameleon-db/chameleon-examples/todo-app/internal/middleware"
 )
 
 // New creates and configures the HTTP router
-func New(userHandler *handler.UserHandler, todoHandler *handler.TodoHandler) *chi.Mux {
+func New(userHandler *handler.UserHandler, todoHandler *handler.TodoHandler, sessionHandler *handler.SessionHandler, passwordResetHandler *handler.PasswordResetHandler, healthHandler *handler.HealthHandler, tokens *auth.TokenManager, logger *slog.Logger, registry *metrics.Registry, cors appMiddleware.CORSPolicy) *chi.Mux {
 	r := chi.NewRouter()
 
 	// Global middleware
-	r.Use(appMiddleware.CORS)
+	r.Use(appMiddleware.CORS(cors))
 	r.Use(middleware.RequestID)
 	r.Use(middleware.RealIP)
-	r.Use(middleware.Logger)
+	r.Use(appMiddleware.Tracing)
+	r.Use(appMiddleware.RequestLogger(logger))
+	r.Use(appMiddleware.Metrics(metrics.NewHTTP(registry)))
 	r.Use(middleware.Recoverer)
-	r.Use(appMiddleware.RequestLogger)
 
-	// Health check
-	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(`{"status":"ok"}`))
-	})
+	// Health checks
+	r.Get("/healthz", healthHandler.Live) // GET /healthz
+	r.Get("/readyz", healthHandler.Ready) // GET /readyz
+	r.Get("/health", healthHandler.Live)  // GET /health (kept for existing probes)
+
+	// Prometheus scrape endpoint
+	r.Method(http.MethodGet, "/metrics", registry.Handler()) // GET /metrics
 
-	// User routes
+	// User routes; who may call which is decided by user.Authorize
 	r.Route("/users", func(r chi.Router) {
-		r.Post("/", userHandler.Create)       // POST /users
-		r.Get("/", userHandler.List)          // GET /users
-		r.Get("/{id}", userHandler.GetByID)   // GET /users/{id}
-		r.Put("/{id}", userHandler.Update)    // PUT /users/{id}
-		r.Delete("/{id}", userHandler.Delete) // DELETE /users/{id}
-	})
+		r.Post("/", userHandler.Create) // POST /users (sign up)
+
+		r.Group(func(r chi.Router) {
+			r.Use(appMiddleware.RequireAuth(tokens))
+
+			r.Get("/", userHandler.List)          // GET /users (admin)
+			r.Get("/{id}", userHandler.GetByID)   // GET /users/{id}
+			r.Put("/{id}", userHandler.Update)    // PUT /users/{id}
+			r.Patch("/{id}", userHandler.Patch)   // PATCH /users/{id}
+			r.Delete("/{id}", userHandler.Delete) // DELETE /users/{id} (admin)
 
-	// Auth route
-	r.Post("/login", userHandler.Login) // POST /login
-
-	// Todo routes
-	r.Route("/users/{userID}/todos", func(r chi.Router) {
-		r.Post("/", todoHandler.Create)                       // POST /users/{userID}/todos
-		r.Get("/", todoHandler.ListByUser)                    // GET /users/{userID}/todos
-		r.Get("/overdue", todoHandler.GetOverdue)             // GET /users/{userID}/todos/overdue
-		r.Get("/{id}", todoHandler.GetByID)                   // GET /users/{userID}/todos/{id}
-		r.Put("/{id}", todoHandler.Update)                    // PUT /users/{userID}/todos/{id}
-		r.Delete("/{id}", todoHandler.Delete)                 // DELETE /users/{userID}/todos/{id}
-		r.Patch("/{id}/toggle", todoHandler.ToggleCompletion) // PATCH /users/{userID}/todos/{id}/toggle
+			r.Post("/{id}/restore", userHandler.Restore)        // POST /users/{id}/restore (admin)
+			r.Put("/{id}/role", userHandler.SetRole)            // PUT /users/{id}/role (admin)
+			r.Put("/{id}/password", userHandler.ChangePassword) // PUT /users/{id}/password
+
+			r.Get("/{id}/sessions", sessionHandler.List)                  // GET /users/{id}/sessions
+			r.Delete("/{id}/sessions", sessionHandler.RevokeAll)          // DELETE /users/{id}/sessions
+			r.Delete("/{id}/sessions/{sessionID}", sessionHandler.Revoke) // DELETE /users/{id}/sessions/{sessionID}
+		})
 	})
 
-	// Global todo routes (without userID in path)
-	r.Get("/todos/{id}", todoHandler.GetByID)   // GET /todos/{id}
-	r.Put("/todos/{id}", todoHandler.Update)    // PUT /todos/{id}
-	r.Delete("/todos/{id}", todoHandler.Delete) // DELETE /todos/{id}
+	// Auth routes; the refresh or reset token in the body is the credential
+	r.Post("/login", userHandler.Login)                          // POST /login
+	r.Post("/auth/refresh", sessionHandler.Refresh)              // POST /auth/refresh
+	r.Post("/auth/logout", sessionHandler.Logout)                // POST /auth/logout
+	r.Post("/auth/password/forgot", passwordResetHandler.Forgot) // POST /auth/password/forgot
+	r.Post("/auth/password/reset", passwordResetHandler.Reset)   // POST /auth/password/reset
+
+	// Authenticated routes
+	r.Group(func(r chi.Router) {
+		r.Use(appMiddleware.RequireAuth(tokens))
+
+		// Todo routes
+		r.Route("/users/{userID}/todos", func(r chi.Router) {
+			r.Post("/", todoHandler.Create)                       // POST /users/{userID}/todos
+			r.Get("/", todoHandler.ListByUser)                    // GET /users/{userID}/todos
+			r.Get("/overdue", todoHandler.GetOverdue)             // GET /users/{userID}/todos/overdue
+			r.Get("/{id}", todoHandler.GetByID)                   // GET /users/{userID}/todos/{id}
+			r.Put("/{id}", todoHandler.Update)                    // PUT /users/{userID}/todos/{id}
+			r.Patch("/{id}", todoHandler.Patch)                   // PATCH /users/{userID}/todos/{id}
+			r.Delete("/{id}", todoHandler.Delete)                 // DELETE /users/{userID}/todos/{id}
+			r.Patch("/{id}/toggle", todoHandler.ToggleCompletion) // PATCH /users/{userID}/todos/{id}/toggle
+		})
+
+		// Global todo routes (without userID in path)
+		r.Get("/todos/{id}", todoHandler.GetByID)   // GET /todos/{id}
+		r.Put("/todos/{id}", todoHandler.Update)    // PUT /todos/{id}
+		r.Patch("/todos/{id}", todoHandler.Patch)   // PATCH /todos/{id}
+		r.Delete("/todos/{id}", todoHandler.Delete) // DELETE /todos/{id}
+	})
 
 	return r
 }
@@ -0,0 +1,243 @@
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/authz"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/passwordreset"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/session"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/todo"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/user"
+)
+
+// Span attributes set by the service decorators
+const (
+	attrUserID    = attribute.Key("app.user.id")
+	attrUserRole  = attribute.Key("app.user.role")
+	attrTodoID    = attribute.Key("app.todo.id")
+	attrSessionID = attribute.Key("app.session.id")
+)
+
+// start opens a span named after a service method
+func start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// tracedTodoService wraps every todo.Service call in a span
+type tracedTodoService struct {
+	next todo.Service
+}
+
+// TodoService returns svc with a span around every call
+func TodoService(svc todo.Service) todo.Service {
+	return &tracedTodoService{next: svc}
+}
+
+func (s *tracedTodoService) Create(ctx context.Context, userID string, in todo.CreateInput) (t *todo.Todo, err error) {
+	ctx, span := start(ctx, "todo.Service.Create", attrUserID.String(userID))
+	defer func() { End(span, err) }()
+	return s.next.Create(ctx, userID, in)
+}
+
+func (s *tracedTodoService) GetByID(ctx context.Context, userID, id string) (t *todo.Todo, err error) {
+	ctx, span := start(ctx, "todo.Service.GetByID", attrUserID.String(userID), attrTodoID.String(id))
+	defer func() { End(span, err) }()
+	return s.next.GetByID(ctx, userID, id)
+}
+
+func (s *tracedTodoService) ListByUser(ctx context.Context, userID string, limit, offset int) (todos []todo.Todo, err error) {
+	ctx, span := start(ctx, "todo.Service.ListByUser", attrUserID.String(userID))
+	defer func() { End(span, err) }()
+	return s.next.ListByUser(ctx, userID, limit, offset)
+}
+
+func (s *tracedTodoService) ListByUserFiltered(ctx context.Context, userID string, filter todo.ListFilter, limit, offset int) (todos []todo.Todo, err error) {
+	ctx, span := start(ctx, "todo.Service.ListByUserFiltered", attrUserID.String(userID))
+	defer func() { End(span, err) }()
+	return s.next.ListByUserFiltered(ctx, userID, filter, limit, offset)
+}
+
+func (s *tracedTodoService) Update(ctx context.Context, userID, id string, in todo.UpdateInput) (t *todo.Todo, err error) {
+	ctx, span := start(ctx, "todo.Service.Update", attrUserID.String(userID), attrTodoID.String(id))
+	defer func() { End(span, err) }()
+	return s.next.Update(ctx, userID, id, in)
+}
+
+func (s *tracedTodoService) Patch(ctx context.Context, userID, id string, patch todo.Patch) (t *todo.Todo, err error) {
+	ctx, span := start(ctx, "todo.Service.Patch", attrUserID.String(userID), attrTodoID.String(id))
+	defer func() { End(span, err) }()
+	return s.next.Patch(ctx, userID, id, patch)
+}
+
+func (s *tracedTodoService) Delete(ctx context.Context, userID, id string, version *time.Time) (err error) {
+	ctx, span := start(ctx, "todo.Service.Delete", attrUserID.String(userID), attrTodoID.String(id))
+	defer func() { End(span, err) }()
+	return s.next.Delete(ctx, userID, id, version)
+}
+
+func (s *tracedTodoService) GetOverdue(ctx context.Context, userID string, limit, offset int) (todos []todo.Todo, total int, err error) {
+	ctx, span := start(ctx, "todo.Service.GetOverdue", attrUserID.String(userID))
+	defer func() { End(span, err) }()
+	return s.next.GetOverdue(ctx, userID, limit, offset)
+}
+
+func (s *tracedTodoService) ToggleCompletion(ctx context.Context, userID, id string) (t *todo.Todo, err error) {
+	ctx, span := start(ctx, "todo.Service.ToggleCompletion", attrUserID.String(userID), attrTodoID.String(id))
+	defer func() { End(span, err) }()
+	return s.next.ToggleCompletion(ctx, userID, id)
+}
+
+// tracedUserService wraps every user.Service call in a span. Emails and
+// passwords are never recorded.
+type tracedUserService struct {
+	next user.Service
+}
+
+// UserService returns svc with a span around every call
+func UserService(svc user.Service) user.Service {
+	return &tracedUserService{next: svc}
+}
+
+func (s *tracedUserService) Create(ctx context.Context, email, name, password string) (u *user.User, err error) {
+	ctx, span := start(ctx, "user.Service.Create")
+	defer func() { End(span, err) }()
+	return s.next.Create(ctx, email, name, password)
+}
+
+func (s *tracedUserService) GetByEmail(ctx context.Context, email string) (u *user.User, err error) {
+	ctx, span := start(ctx, "user.Service.GetByEmail")
+	defer func() { End(span, err) }()
+	return s.next.GetByEmail(ctx, email)
+}
+
+func (s *tracedUserService) GetByID(ctx context.Context, id string) (u *user.User, err error) {
+	ctx, span := start(ctx, "user.Service.GetByID", attrUserID.String(id))
+	defer func() { End(span, err) }()
+	return s.next.GetByID(ctx, id)
+}
+
+func (s *tracedUserService) List(ctx context.Context, limit, offset int) (users []user.User, err error) {
+	ctx, span := start(ctx, "user.Service.List")
+	defer func() { End(span, err) }()
+	return s.next.List(ctx, limit, offset)
+}
+
+func (s *tracedUserService) Update(ctx context.Context, id, name string, version *time.Time) (u *user.User, err error) {
+	ctx, span := start(ctx, "user.Service.Update", attrUserID.String(id))
+	defer func() { End(span, err) }()
+	return s.next.Update(ctx, id, name, version)
+}
+
+func (s *tracedUserService) Patch(ctx context.Context, id string, patch user.Patch) (u *user.User, err error) {
+	ctx, span := start(ctx, "user.Service.Patch", attrUserID.String(id))
+	defer func() { End(span, err) }()
+	return s.next.Patch(ctx, id, patch)
+}
+
+func (s *tracedUserService) Delete(ctx context.Context, id string, version *time.Time) (err error) {
+	ctx, span := start(ctx, "user.Service.Delete", attrUserID.String(id))
+	defer func() { End(span, err) }()
+	return s.next.Delete(ctx, id, version)
+}
+
+func (s *tracedUserService) Restore(ctx context.Context, id string) (err error) {
+	ctx, span := start(ctx, "user.Service.Restore", attrUserID.String(id))
+	defer func() { End(span, err) }()
+	return s.next.Restore(ctx, id)
+}
+
+func (s *tracedUserService) SetRole(ctx context.Context, id string, role authz.Role) (u *user.User, err error) {
+	ctx, span := start(ctx, "user.Service.SetRole", attrUserID.String(id), attrUserRole.String(string(role)))
+	defer func() { End(span, err) }()
+	return s.next.SetRole(ctx, id, role)
+}
+
+func (s *tracedUserService) ChangePassword(ctx context.Context, id, current, next string) (err error) {
+	ctx, span := start(ctx, "user.Service.ChangePassword", attrUserID.String(id))
+	defer func() { End(span, err) }()
+	return s.next.ChangePassword(ctx, id, current, next)
+}
+
+func (s *tracedUserService) VerifyPassword(ctx context.Context, email, password string) (u *user.User, err error) {
+	ctx, span := start(ctx, "user.Service.VerifyPassword")
+	defer func() { End(span, err) }()
+	return s.next.VerifyPassword(ctx, email, password)
+}
+
+// tracedSessionService wraps every session.Service call in a span
+type tracedSessionService struct {
+	next session.Service
+}
+
+// SessionService returns svc with a span around every call. Refresh tokens
+// are never recorded.
+func SessionService(svc session.Service) session.Service {
+	return &tracedSessionService{next: svc}
+}
+
+func (s *tracedSessionService) Start(ctx context.Context, u *user.User, client session.Client) (g *session.Grant, err error) {
+	ctx, span := start(ctx, "session.Service.Start")
+	defer func() { End(span, err) }()
+	return s.next.Start(ctx, u, client)
+}
+
+func (s *tracedSessionService) Refresh(ctx context.Context, token string, client session.Client) (g *session.Grant, err error) {
+	ctx, span := start(ctx, "session.Service.Refresh")
+	defer func() { End(span, err) }()
+	return s.next.Refresh(ctx, token, client)
+}
+
+func (s *tracedSessionService) Logout(ctx context.Context, token string) (err error) {
+	ctx, span := start(ctx, "session.Service.Logout")
+	defer func() { End(span, err) }()
+	return s.next.Logout(ctx, token)
+}
+
+func (s *tracedSessionService) ListByUser(ctx context.Context, userID string) (sessions []session.Session, err error) {
+	ctx, span := start(ctx, "session.Service.ListByUser", attrUserID.String(userID))
+	defer func() { End(span, err) }()
+	return s.next.ListByUser(ctx, userID)
+}
+
+func (s *tracedSessionService) Revoke(ctx context.Context, userID, id string) (err error) {
+	ctx, span := start(ctx, "session.Service.Revoke", attrUserID.String(userID), attrSessionID.String(id))
+	defer func() { End(span, err) }()
+	return s.next.Revoke(ctx, userID, id)
+}
+
+func (s *tracedSessionService) RevokeAll(ctx context.Context, userID string) (err error) {
+	ctx, span := start(ctx, "session.Service.RevokeAll", attrUserID.String(userID))
+	defer func() { End(span, err) }()
+	return s.next.RevokeAll(ctx, userID)
+}
+
+// tracedPasswordResetService wraps every passwordreset.Service call in a span
+type tracedPasswordResetService struct {
+	next passwordreset.Service
+}
+
+// PasswordResetService returns svc with a span around every call. Emails,
+// tokens and passwords are never recorded.
+func PasswordResetService(svc passwordreset.Service) passwordreset.Service {
+	return &tracedPasswordResetService{next: svc}
+}
+
+func (s *tracedPasswordResetService) Request(ctx context.Context, email string) (err error) {
+	ctx, span := start(ctx, "passwordreset.Service.Request")
+	defer func() { End(span, err) }()
+	return s.next.Request(ctx, email)
+}
+
+func (s *tracedPasswordResetService) Reset(ctx context.Context, token, password string) (err error) {
+	ctx, span := start(ctx, "passwordreset.Service.Reset")
+	defer func() { End(span, err) }()
+	return s.next.Reset(ctx, token, password)
+}
+
+func (s *tracedPasswordResetService) Wait() {
+	s.next.Wait()
+}
@@ -0,0 +1,106 @@
+// Package tracing sets up OpenTelemetry tracing: W3C trace context
+// propagation and an exporter chosen by config
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifies the spans this module creates
+const instrumentationName = "github.com/chameleon-db/chameleon-examples/todo-app"
+
+// Exporters
+const (
+	ExporterNone   = "none"   // spans are not recorded
+	ExporterStdout = "stdout" // one JSON object per span on stdout
+	ExporterFile   = "file"   // one JSON object per span, appended to Config.File
+)
+
+// Config selects where spans go
+type Config struct {
+	Exporter    string
+	File        string  // for ExporterFile
+	ServiceName string  // reported as service.name
+	SampleRatio float64 // share of new traces recorded; incoming sampled flags are honoured
+}
+
+// Tracer returns the tracer for this module's spans. It follows the global
+// provider, so spans are no-ops until Setup installs an exporter.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Setup installs the W3C traceparent propagator and, unless the exporter
+// is ExporterNone, a global tracer provider exporting to it. The returned
+// func flushes buffered spans and must be called before exit.
+func Setup(cfg Config) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	var w io.Writer
+	closeWriter := func() error { return nil }
+
+	switch cfg.Exporter {
+	case ExporterNone, "":
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		w = os.Stdout
+	case ExporterFile:
+		if cfg.File == "" {
+			return nil, fmt.Errorf("trace exporter %q needs a file path", cfg.Exporter)
+		}
+		f, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("open trace file: %w", err)
+		}
+		w, closeWriter = f, f.Close
+	default:
+		return nil, fmt.Errorf("invalid trace exporter %q: want none, stdout or file", cfg.Exporter)
+	}
+
+	exporter, err := stdouttrace.New(stdouttrace.WithWriter(w))
+	if err != nil {
+		closeWriter()
+		return nil, err
+	}
+
+	provider := NewProvider(exporter, cfg.ServiceName, cfg.SampleRatio)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if cerr := closeWriter(); err == nil {
+			err = cerr
+		}
+		return err
+	}, nil
+}
+
+// NewProvider creates a tracer provider batching spans to exporter
+func NewProvider(exporter sdktrace.SpanExporter, serviceName string, sampleRatio float64) *sdktrace.TracerProvider {
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+	)
+}
+
+// End records err, if any, on span and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
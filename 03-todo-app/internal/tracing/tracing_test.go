@@ -0,0 +1,68 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+)
+
+func TestSetupFileExporter(t *testing.T) {
+	prev := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	path := filepath.Join(t.TempDir(), "traces.jsonl")
+	shutdown, err := Setup(Config{Exporter: ExporterFile, File: path, ServiceName: "test", SampleRatio: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, span := Tracer().Start(context.Background(), "work")
+	End(span, errors.New("failed"))
+
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got struct {
+		Name   string
+		Status struct{ Code string }
+	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(string(data))), &got); err != nil {
+		t.Fatalf("Expected one JSON span, got %q: %v", data, err)
+	}
+	if got.Name != "work" || got.Status.Code != "Error" {
+		t.Errorf("Expected the failed span, got %+v", got)
+	}
+}
+
+func TestSetupRejectsBadConfig(t *testing.T) {
+	for _, cfg := range []Config{
+		{Exporter: "zipkin"},
+		{Exporter: ExporterFile},
+	} {
+		if _, err := Setup(cfg); err == nil {
+			t.Errorf("Expected an error for %+v", cfg)
+		}
+	}
+}
+
+func TestSetupNone(t *testing.T) {
+	shutdown, err := Setup(Config{Exporter: ExporterNone})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
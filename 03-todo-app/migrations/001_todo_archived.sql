@@ -0,0 +1,18 @@
+-- Todo.archived: todos hidden along with their deactivated owner.
+-- Rows created before the column existed are archived exactly when their
+-- owner is already deactivated, as a soft-delete would have left them.
+BEGIN;
+
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS archived BOOLEAN;
+
+UPDATE todos t
+   SET archived = NOT u.is_active
+  FROM users u
+ WHERE u.id = t.user_id AND t.archived IS NULL;
+
+UPDATE todos SET archived = false WHERE archived IS NULL;
+
+ALTER TABLE todos ALTER COLUMN archived SET DEFAULT false;
+ALTER TABLE todos ALTER COLUMN archived SET NOT NULL;
+
+COMMIT;
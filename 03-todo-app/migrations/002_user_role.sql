@@ -0,0 +1,12 @@
+-- User.role: "user" or "admin". Accounts created before roles existed
+-- become plain users; promote the first admin with `make admin EMAIL=...`.
+BEGIN;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role TEXT;
+
+UPDATE users SET role = 'user' WHERE role IS NULL;
+
+ALTER TABLE users ALTER COLUMN role SET DEFAULT 'user';
+ALTER TABLE users ALTER COLUMN role SET NOT NULL;
+
+COMMIT;
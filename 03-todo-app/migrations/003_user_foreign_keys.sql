@@ -0,0 +1,36 @@
+-- Todo.user, Session.user, PasswordReset.user: rows must belong to an
+-- existing user. Users are only ever deactivated, so rows whose owner is
+-- missing were unreachable already and are dropped before the constraints
+-- are added. Constraint names match the ones PostgreSQL gives the schema
+-- migration's inline REFERENCES.
+BEGIN;
+
+DELETE FROM todos t
+ WHERE NOT EXISTS (SELECT 1 FROM users u WHERE u.id = t.user_id);
+
+DELETE FROM sessions s
+ WHERE NOT EXISTS (SELECT 1 FROM users u WHERE u.id = s.user_id);
+
+DELETE FROM password_resets r
+ WHERE NOT EXISTS (SELECT 1 FROM users u WHERE u.id = r.user_id);
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'todos_user_id_fkey') THEN
+        ALTER TABLE todos ADD CONSTRAINT todos_user_id_fkey
+            FOREIGN KEY (user_id) REFERENCES users(id);
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'sessions_user_id_fkey') THEN
+        ALTER TABLE sessions ADD CONSTRAINT sessions_user_id_fkey
+            FOREIGN KEY (user_id) REFERENCES users(id);
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'password_resets_user_id_fkey') THEN
+        ALTER TABLE password_resets ADD CONSTRAINT password_resets_user_id_fkey
+            FOREIGN KEY (user_id) REFERENCES users(id);
+    END IF;
+END
+$$;
+
+COMMIT;
@@ -37,49 +37,15 @@ func main() {
 	fmt.Println()
 
 	// ============================================
-	// 2. Seed Demo User
+	// 2. Seed Demo Users
 	// ============================================
 
 	const demoEmail = "test@example.com"
+	const adminEmail = "admin@example.com"
 	const demoPassword = "password123"
 
-	var demoUserID string
-
-	err = sqlPool.QueryRow(
-		ctx,
-		`SELECT id FROM users WHERE email = $1 LIMIT 1`,
-		demoEmail,
-	).Scan(&demoUserID)
-
-	if err != nil && err.Error() != "no rows in result set" {
-		log.Fatalf("❌ Failed to query demo user: %v", err)
-	}
-
-	if demoUserID != "" {
-		fmt.Printf("⏭️  Demo user already exists: %s (id: %s)\n", demoEmail, demoUserID)
-	} else {
-		passwordHash, err := bcrypt.GenerateFromPassword([]byte(demoPassword), bcrypt.DefaultCost)
-		if err != nil {
-			log.Fatalf("❌ Failed to hash demo password: %v", err)
-		}
-
-		demoUserID = uuid.New().String()
-		_, err = sqlPool.Exec(
-			ctx,
-			`INSERT INTO users (id, email, name, password_hash, is_active, created_at, updated_at)
-			 VALUES ($1, $2, $3, $4, $5, NOW(), NOW())`,
-			demoUserID,
-			demoEmail,
-			"Demo User",
-			string(passwordHash),
-			true,
-		)
-		if err != nil {
-			log.Fatalf("❌ Failed to create demo user: %v", err)
-		}
-
-		fmt.Printf("✅ Created demo user: %s (id: %s)\n", demoEmail, demoUserID)
-	}
+	demoUserID := seedUser(ctx, sqlPool, demoEmail, "Demo User", demoPassword, "user")
+	seedUser(ctx, sqlPool, adminEmail, "Demo Admin", demoPassword, "admin")
 
 	fmt.Println()
 
@@ -153,8 +119,8 @@ func main() {
 		todoID := uuid.New().String()
 		_, err = sqlPool.Exec(
 			ctx,
-			`INSERT INTO todos (id, title, description, completed, user_id, created_at, updated_at)
-			 VALUES ($1, $2, $3, $4, $5, NOW(), NOW())`,
+			`INSERT INTO todos (id, title, description, completed, archived, user_id, created_at, updated_at)
+			 VALUES ($1, $2, $3, $4, false, $5, NOW(), NOW())`,
 			todoID,
 			todo.title,
 			todo.description,
@@ -203,6 +169,7 @@ func main() {
 	fmt.Println("✅ Seeding complete!")
 	fmt.Println()
 	fmt.Printf("🔐 Demo login: %s / %s\n", demoEmail, demoPassword)
+	fmt.Printf("🔐 Admin login: %s / %s\n", adminEmail, demoPassword)
 	fmt.Println()
 	fmt.Println("💡 Next steps:")
 	fmt.Println("   • Start the API:  go run ./cmd/api")
@@ -210,3 +177,48 @@ func main() {
 	fmt.Println("   • View vault:     chameleon journal schema")
 	fmt.Println()
 }
+
+// seedUser creates an active user with the given role unless one with the
+// email exists, and returns its ID
+func seedUser(ctx context.Context, sqlPool *pgxpool.Pool, email, name, password, role string) string {
+	var userID string
+
+	err := sqlPool.QueryRow(
+		ctx,
+		`SELECT id FROM users WHERE email = $1 LIMIT 1`,
+		email,
+	).Scan(&userID)
+
+	if err != nil && err.Error() != "no rows in result set" {
+		log.Fatalf("❌ Failed to query user %s: %v", email, err)
+	}
+
+	if userID != "" {
+		fmt.Printf("⏭️  User already exists: %s (id: %s)\n", email, userID)
+		return userID
+	}
+
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		log.Fatalf("❌ Failed to hash demo password: %v", err)
+	}
+
+	userID = uuid.New().String()
+	_, err = sqlPool.Exec(
+		ctx,
+		`INSERT INTO users (id, email, name, password_hash, role, is_active, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, NOW(), NOW())`,
+		userID,
+		email,
+		name,
+		string(passwordHash),
+		role,
+		true,
+	)
+	if err != nil {
+		log.Fatalf("❌ Failed to create user %s: %v", email, err)
+	}
+
+	fmt.Printf("✅ Created %s: %s (id: %s)\n", role, email, userID)
+	return userID
+}
@@ -0,0 +1,45 @@
+package e2e
+
+import (
+	"net/http"
+	"testing"
+)
+
+// TestCORSDefaultsAllowLocalFrontend checks that 03-todo-front, served from
+// any localhost port, can call the API with the default config
+func TestCORSDefaultsAllowLocalFrontend(t *testing.T) {
+	h := NewHarness(t, MemoryBackend())
+	fx := newFixture(h)
+	const origin = "http://localhost:5500"
+
+	preflight := h.Do(Request{Method: "OPTIONS", Path: "/todos/" + fx.todo.ID, Header: map[string]string{
+		"Origin":                         origin,
+		"Access-Control-Request-Method":  "PATCH",
+		"Access-Control-Request-Headers": "authorization, content-type, if-match",
+	}})
+	if preflight.Status != http.StatusNoContent {
+		t.Fatalf("Expected preflight status 204, got %d", preflight.Status)
+	}
+	if got := preflight.Header.Get("Access-Control-Allow-Origin"); got != origin {
+		t.Errorf("Expected the origin echoed back, got %q", got)
+	}
+
+	resp := h.Do(Request{Method: "GET", Path: "/todos/" + fx.todo.ID, Token: fx.owner.Token, Header: map[string]string{"Origin": origin}})
+	if resp.Status != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.Status)
+	}
+	if got := resp.Header.Get("Access-Control-Allow-Origin"); got != origin {
+		t.Errorf("Expected the origin echoed back, got %q", got)
+	}
+	if got := resp.Header.Get("Access-Control-Expose-Headers"); got != "ETag" {
+		t.Errorf("Expected ETag exposed, got %q", got)
+	}
+	if got := resp.Header.Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Errorf("Expected no credentials by default, got %q", got)
+	}
+
+	other := h.Do(Request{Method: "GET", Path: "/health", Header: map[string]string{"Origin": "https://elsewhere.test"}})
+	if got := other.Header.Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Expected no CORS headers for another origin, got %q", got)
+	}
+}
@@ -0,0 +1,348 @@
+// Package e2e tests the HTTP API end to end: router.New, middleware,
+// handlers and services wired together and served by httptest.
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/auth"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/authz"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/config"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/passwordreset"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/session"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/todo"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/user"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/handler"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/health"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/mail"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/metrics"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/middleware"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/repository/memrepo"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/router"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/tracing"
+	"github.com/go-chi/chi/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Backend is the storage a Harness serves from
+type Backend struct {
+	Users          user.Repository
+	Todos          todo.Repository
+	Sessions       session.Repository
+	PasswordResets passwordreset.Repository
+	Tx             todo.Transactor
+}
+
+// MemoryBackend returns a Backend over a fresh in-memory store
+func MemoryBackend() Backend {
+	store := memrepo.NewStore()
+	return Backend{
+		Users:          memrepo.NewUserRepository(store),
+		Todos:          memrepo.NewTodoRepository(store),
+		Sessions:       memrepo.NewSessionRepository(store),
+		PasswordResets: memrepo.NewPasswordResetRepository(store),
+		Tx:             store,
+	}
+}
+
+// Harness serves the API over a Backend, wired as cmd/api wires it
+type Harness struct {
+	T       *testing.T
+	Server  *httptest.Server
+	Router  *chi.Mux
+	Tokens  *auth.TokenManager
+	Backend Backend
+
+	// Health runs the readiness checks behind /readyz; it starts empty
+	Health *health.Checker
+
+	// Outbox holds the emails the API sent
+	Outbox *Outbox
+}
+
+// Outbox is a mail.Mailer that keeps what it is sent
+type Outbox struct {
+	mu   sync.Mutex
+	sent []mail.Message
+	wait func() // waits for emails still being sent
+}
+
+// Send records msg
+func (o *Outbox) Send(_ context.Context, msg mail.Message) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.sent = append(o.sent, msg)
+	return nil
+}
+
+// Sent waits for the emails the API is still sending, then returns the
+// messages sent so far, oldest first
+func (o *Outbox) Sent() []mail.Message {
+	if o.wait != nil {
+		o.wait()
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]mail.Message(nil), o.sent...)
+}
+
+// NewHarness starts a test server over backend; it stops when the test ends
+func NewHarness(t *testing.T, backend Backend) *Harness {
+	t.Helper()
+
+	tokens := auth.NewTokenManager([]byte("e2e-test-secret"), time.Hour)
+	sessionService := tracing.SessionService(session.Authorize(session.NewService(backend.Sessions, backend.Users, 24*time.Hour)))
+	userHandler := handler.NewUserHandler(tracing.UserService(user.Authorize(user.NewService(backend.Users, backend.Sessions, backend.PasswordResets, backend.Tx))), sessionService, tokens)
+	todoHandler := handler.NewTodoHandler(tracing.TodoService(todo.Authorize(todo.NewService(backend.Todos, backend.Users, backend.Tx))))
+	sessionHandler := handler.NewSessionHandler(sessionService, tokens)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	defaults := config.Default()
+	outbox := &Outbox{}
+	passwordResetService := tracing.PasswordResetService(passwordreset.NewService(
+		backend.PasswordResets, backend.Users, backend.Sessions, backend.Tx, outbox, defaults.PasswordResetTTL, defaults.PasswordResetURL, logger,
+	))
+	outbox.wait = passwordResetService.Wait
+	passwordResetHandler := handler.NewPasswordResetHandler(passwordResetService)
+	checker := health.NewChecker(time.Second)
+	healthHandler := handler.NewHealthHandler(checker)
+
+	cors := middleware.CORSPolicy{
+		AllowedOrigins: defaults.CORSAllowedOrigins,
+		AllowedMethods: defaults.CORSAllowedMethods,
+		AllowedHeaders: defaults.CORSAllowedHeaders,
+		ExposedHeaders: defaults.CORSExposedHeaders,
+		MaxAge:         defaults.CORSMaxAge,
+	}
+
+	r := router.New(userHandler, todoHandler, sessionHandler, passwordResetHandler, healthHandler, tokens, logger, metrics.NewRegistry(), cors)
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+
+	return &Harness{T: t, Server: server, Router: r, Tokens: tokens, Backend: backend, Health: checker, Outbox: outbox}
+}
+
+// Request describes a call to the API
+type Request struct {
+	Method string
+	Path   string
+	Token  string            // sent as a Bearer token when set
+	Body   interface{}       // a string is sent verbatim, anything else as JSON
+	Header map[string]string // extra headers, e.g. If-Match
+}
+
+// Response is a recorded API response
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// envelope mirrors handler.Response with the payload left undecoded
+type envelope struct {
+	Data  json.RawMessage `json:"data"`
+	Meta  json.RawMessage `json:"meta"`
+	Error string          `json:"error"`
+}
+
+// Do sends req and records the response, failing the test on transport errors
+func (h *Harness) Do(req Request) *Response {
+	h.T.Helper()
+
+	var body io.Reader
+	switch b := req.Body.(type) {
+	case nil:
+	case string:
+		body = strings.NewReader(b)
+	default:
+		encoded, err := json.Marshal(b)
+		if err != nil {
+			h.T.Fatalf("Encode body: %v", err)
+		}
+		body = bytes.NewReader(encoded)
+	}
+
+	httpReq, err := http.NewRequest(req.Method, h.Server.URL+req.Path, body)
+	if err != nil {
+		h.T.Fatalf("Build request: %v", err)
+	}
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if req.Token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+req.Token)
+	}
+	for name, value := range req.Header {
+		httpReq.Header.Set(name, value)
+	}
+
+	resp, err := h.Server.Client().Do(httpReq)
+	if err != nil {
+		h.T.Fatalf("%s %s: %v", req.Method, req.Path, err)
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		h.T.Fatalf("Read response: %v", err)
+	}
+
+	return &Response{Status: resp.StatusCode, Header: resp.Header, Body: raw}
+}
+
+// envelope decodes the standard response envelope
+func (r *Response) envelope(t *testing.T) envelope {
+	t.Helper()
+
+	var env envelope
+	if err := json.Unmarshal(r.Body, &env); err != nil {
+		t.Fatalf("Decode response %q: %v", r.Body, err)
+	}
+	return env
+}
+
+// Error returns the error message of the response envelope
+func (r *Response) Error(t *testing.T) string {
+	t.Helper()
+	return r.envelope(t).Error
+}
+
+// Data decodes the data of the response envelope into v
+func (r *Response) Data(t *testing.T, v interface{}) {
+	t.Helper()
+
+	if err := json.Unmarshal(r.envelope(t).Data, v); err != nil {
+		t.Fatalf("Decode data %q: %v", r.Body, err)
+	}
+}
+
+// Meta decodes the meta of the response envelope into v
+func (r *Response) Meta(t *testing.T, v interface{}) {
+	t.Helper()
+
+	if err := json.Unmarshal(r.envelope(t).Meta, v); err != nil {
+		t.Fatalf("Decode meta %q: %v", r.Body, err)
+	}
+}
+
+// Account is a user created through the API, with a token to act as them
+type Account struct {
+	ID    string
+	Email string
+	Token string
+}
+
+// Password is the password of every account created by Seed
+const Password = "password123"
+
+// passwordHash is Password hashed at the lowest cost, computed once so
+// seeding stays fast
+var passwordHash = sync.OnceValue(func() string {
+	hash, err := bcrypt.GenerateFromPassword([]byte(Password), bcrypt.MinCost)
+	if err != nil {
+		panic(err)
+	}
+	return string(hash)
+})
+
+// Seed creates a user directly in the backend, bypassing the API, and
+// issues them an access token
+func (h *Harness) Seed(email string) Account {
+	h.T.Helper()
+
+	return h.seed(email, authz.RoleUser)
+}
+
+// SeedAdmin is Seed for a user with the admin role
+func (h *Harness) SeedAdmin(email string) Account {
+	h.T.Helper()
+	return h.seed(email, authz.RoleAdmin)
+}
+
+func (h *Harness) seed(email string, role authz.Role) Account {
+	h.T.Helper()
+
+	ctx := context.Background()
+	u, err := h.Backend.Users.Create(ctx, email, "Test User", passwordHash())
+	if err != nil {
+		h.T.Fatalf("Seed %s: %v", email, err)
+	}
+
+	if role != u.Role {
+		if u, err = h.Backend.Users.SetRole(ctx, u.ID.String(), role); err != nil {
+			h.T.Fatalf("Set role of %s: %v", email, err)
+		}
+	}
+
+	token, _, err := h.Tokens.Issue(u.ID.String(), u.Role)
+	if err != nil {
+		h.T.Fatalf("Issue token: %v", err)
+	}
+
+	return Account{ID: u.ID.String(), Email: email, Token: token}
+}
+
+// CreateTodo creates a todo for account through the API
+func (h *Harness) CreateTodo(account Account, req handler.CreateTodoRequest) handler.TodoResponse {
+	h.T.Helper()
+
+	resp := h.Do(Request{Method: "POST", Path: "/users/" + account.ID + "/todos", Token: account.Token, Body: req})
+	if resp.Status != http.StatusCreated {
+		h.T.Fatalf("Create todo: expected 201, got %d %s", resp.Status, resp.Body)
+	}
+
+	var created handler.TodoResponse
+	resp.Data(h.T, &created)
+	return created
+}
+
+// Login logs account in through the API, starting a session
+func (h *Harness) Login(account Account) handler.LoginResponse {
+	h.T.Helper()
+
+	resp := h.Do(Request{Method: "POST", Path: "/login", Body: handler.LoginRequest{Email: account.Email, Password: Password}})
+	if resp.Status != http.StatusOK {
+		h.T.Fatalf("Login: expected 200, got %d %s", resp.Status, resp.Body)
+	}
+
+	var login handler.LoginResponse
+	resp.Data(h.T, &login)
+	return login
+}
+
+// resetLink finds the password reset link in an email body
+var resetLink = regexp.MustCompile(`\S+[?&]token=\S+`)
+
+// ResetToken asks for a password reset for account through the API and
+// returns the token from the emailed link
+func (h *Harness) ResetToken(account Account) string {
+	h.T.Helper()
+
+	resp := h.Do(Request{Method: "POST", Path: "/auth/password/forgot", Body: handler.ForgotPasswordRequest{Email: account.Email}})
+	if resp.Status != http.StatusAccepted {
+		h.T.Fatalf("Forgot password: expected 202, got %d %s", resp.Status, resp.Body)
+	}
+
+	sent := h.Outbox.Sent()
+	if len(sent) == 0 || sent[len(sent)-1].To != account.Email {
+		h.T.Fatalf("Expected a reset email to %s, got %+v", account.Email, sent)
+	}
+
+	link, err := url.Parse(resetLink.FindString(sent[len(sent)-1].Body))
+	if err != nil || link.Query().Get("token") == "" {
+		h.T.Fatalf("Expected a reset link in %q", sent[len(sent)-1].Body)
+	}
+	return link.Query().Get("token")
+}
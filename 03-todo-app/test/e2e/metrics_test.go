@@ -0,0 +1,92 @@
+package e2e
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// scrape fetches /metrics and returns its sample lines, without comments
+func scrape(h *Harness) map[string]string {
+	h.T.Helper()
+
+	resp := h.Do(Request{Method: "GET", Path: "/metrics"})
+	if resp.Status != http.StatusOK {
+		h.T.Fatalf("Expected status 200 from /metrics, got %d", resp.Status)
+	}
+
+	samples := make(map[string]string)
+	for _, line := range strings.Split(string(resp.Body), "\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.LastIndexByte(line, ' ')
+		samples[line[:i]] = line[i+1:]
+	}
+	return samples
+}
+
+// value returns a sample as a number; a missing series counts as zero
+func value(t *testing.T, samples map[string]string, series string) float64 {
+	t.Helper()
+
+	v, ok := samples[series]
+	if !ok {
+		return 0
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		t.Fatalf("Parse %s %q: %v", series, v, err)
+	}
+	return f
+}
+
+func TestMetricsByRoutePattern(t *testing.T) {
+	h := NewHarness(t, MemoryBackend())
+	fx := newFixture(h)
+	before := scrape(h)
+
+	for _, id := range []string{fx.todo.ID, fx.todo.ID, fx.otherTodo.ID} {
+		h.Do(Request{Method: "GET", Path: "/todos/" + id, Token: fx.owner.Token})
+	}
+	h.Do(Request{Method: "GET", Path: "/no/such/route"})
+
+	samples := scrape(h)
+
+	// Increase since the first scrape; the fixture made requests of its own
+	want := map[string]float64{
+		`http_requests_total{method="GET",route="/todos/{id}",status="200"}`:                            2,
+		`http_requests_total{method="GET",route="/todos/{id}",status="403"}`:                            1,
+		`http_requests_total{method="GET",route="unmatched",status="404"}`:                              1,
+		`http_request_duration_seconds_count{method="GET",route="/todos/{id}",status="200"}`:            2,
+		`http_request_duration_seconds_bucket{method="GET",route="/todos/{id}",status="200",le="+Inf"}`: 2,
+	}
+	for series, delta := range want {
+		if got := value(t, samples, series) - value(t, before, series); got != delta {
+			t.Errorf("Expected %s to grow by %v, got %v", series, delta, got)
+		}
+	}
+
+	// The scrape itself is in flight while the body is written
+	if got := value(t, samples, "http_requests_in_flight"); got != 1 {
+		t.Errorf("Expected 1 request in flight, got %v", got)
+	}
+
+	for series := range samples {
+		if strings.Contains(series, fx.todo.ID) {
+			t.Errorf("Expected route patterns, not raw paths, got %s", series)
+		}
+	}
+}
+
+func TestMetricsCountScrapes(t *testing.T) {
+	h := NewHarness(t, MemoryBackend())
+
+	scrape(h)
+	samples := scrape(h)
+
+	if got := samples[`http_requests_total{method="GET",route="/metrics",status="200"}`]; got != "1" {
+		t.Errorf("Expected the first scrape to be counted by the second, got %q", got)
+	}
+}
@@ -0,0 +1,72 @@
+package e2e
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/handler"
+)
+
+// login posts credentials and returns the response status
+func login(h *Harness, email, password string) int {
+	h.T.Helper()
+	return h.Do(Request{Method: "POST", Path: "/login", Body: handler.LoginRequest{Email: email, Password: password}}).Status
+}
+
+// refresh posts a refresh token and returns the response status
+func refresh(h *Harness, token string) int {
+	h.T.Helper()
+	return h.Do(Request{Method: "POST", Path: "/auth/refresh", Body: handler.RefreshTokenRequest{RefreshToken: token}}).Status
+}
+
+func TestPasswordResetSignsOutEverywhere(t *testing.T) {
+	h := NewHarness(t, MemoryBackend())
+	account := h.Seed("test@example.com")
+	laptop := h.Login(account)
+	phone := h.Login(account)
+
+	token := h.ResetToken(account)
+	resp := h.Do(Request{Method: "POST", Path: "/auth/password/reset", Body: handler.ResetPasswordRequest{Token: token, Password: "new-password"}})
+	if resp.Status != http.StatusOK {
+		t.Fatalf("Reset: expected 200, got %d %s", resp.Status, resp.Body)
+	}
+
+	for name, session := range map[string]handler.LoginResponse{"laptop": laptop, "phone": phone} {
+		if status := refresh(h, session.RefreshToken); status != http.StatusUnauthorized {
+			t.Errorf("Refresh on %s: expected 401, got %d", name, status)
+		}
+	}
+
+	if status := login(h, account.Email, Password); status != http.StatusUnauthorized {
+		t.Errorf("Old password: expected 401, got %d", status)
+	}
+	if status := login(h, account.Email, "new-password"); status != http.StatusOK {
+		t.Errorf("New password: expected 200, got %d", status)
+	}
+}
+
+func TestChangePasswordKeepsSessions(t *testing.T) {
+	h := NewHarness(t, MemoryBackend())
+	account := h.Seed("test@example.com")
+	session := h.Login(account)
+
+	resp := h.Do(Request{
+		Method: "PUT",
+		Path:   "/users/" + account.ID + "/password",
+		Token:  account.Token,
+		Body:   handler.ChangePasswordRequest{CurrentPassword: Password, NewPassword: "new-password"},
+	})
+	if resp.Status != http.StatusOK {
+		t.Fatalf("Change: expected 200, got %d %s", resp.Status, resp.Body)
+	}
+
+	if status := login(h, account.Email, Password); status != http.StatusUnauthorized {
+		t.Errorf("Old password: expected 401, got %d", status)
+	}
+	if status := login(h, account.Email, "new-password"); status != http.StatusOK {
+		t.Errorf("New password: expected 200, got %d", status)
+	}
+	if status := refresh(h, session.RefreshToken); status != http.StatusOK {
+		t.Errorf("Refresh: expected the session kept, got %d", status)
+	}
+}
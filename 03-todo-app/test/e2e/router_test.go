@@ -0,0 +1,695 @@
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/handler"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/health"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// fixture is the data every route case starts from: two users with one
+// todo each, and an admin. The owner's todo is overdue, and the owner has
+// logged in and asked for a password reset once.
+type fixture struct {
+	owner, other Account
+	admin        Account
+	todo         handler.TodoResponse
+	todoETag     string
+	otherTodo    handler.TodoResponse
+	ownerETag    string
+	refresh      string // the owner's refresh token
+	session      string // the owner's session ID
+	reset        string // the owner's password reset token
+	outbox       *Outbox
+}
+
+func newFixture(h *Harness) *fixture {
+	h.T.Helper()
+
+	fx := &fixture{
+		owner: h.Seed("owner@example.com"),
+		other: h.Seed("other@example.com"),
+		admin: h.SeedAdmin("admin@example.com"),
+	}
+
+	yesterday := time.Now().Add(-24 * time.Hour)
+	fx.todo = h.CreateTodo(fx.owner, handler.CreateTodoRequest{Title: "Mine", Description: "Owned", DueDate: &yesterday})
+	fx.otherTodo = h.CreateTodo(fx.other, handler.CreateTodoRequest{Title: "Theirs"})
+
+	fx.todoETag = h.Do(Request{Method: "GET", Path: "/todos/" + fx.todo.ID, Token: fx.owner.Token}).Header.Get("ETag")
+	fx.ownerETag = h.Do(Request{Method: "GET", Path: "/users/" + fx.owner.ID, Token: fx.owner.Token}).Header.Get("ETag")
+
+	fx.refresh = h.Login(fx.owner).RefreshToken
+	var sessions []handler.SessionResponse
+	h.Do(Request{Method: "GET", Path: "/users/" + fx.owner.ID + "/sessions", Token: fx.owner.Token}).Data(h.T, &sessions)
+	fx.session = sessions[0].ID
+
+	fx.reset = h.ResetToken(fx.owner)
+	fx.outbox = h.Outbox
+
+	return fx
+}
+
+// expand fills the placeholders of a case path or header
+func (fx *fixture) expand(s string) string {
+	return strings.NewReplacer(
+		"{owner}", fx.owner.ID,
+		"{other}", fx.other.ID,
+		"{admin}", fx.admin.ID,
+		"{todo}", fx.todo.ID,
+		"{otherTodo}", fx.otherTodo.ID,
+		"{missing}", uuid.NewString(),
+		"{todoETag}", fx.todoETag,
+		"{ownerETag}", fx.ownerETag,
+		"{refresh}", fx.refresh,
+		"{session}", fx.session,
+		"{reset}", fx.reset,
+	).Replace(s)
+}
+
+// token returns the token of the named fixture user
+func (fx *fixture) token(as string) string {
+	switch as {
+	case "owner":
+		return fx.owner.Token
+	case "other":
+		return fx.other.Token
+	case "admin":
+		return fx.admin.Token
+	case "":
+		return ""
+	default:
+		return as // a literal token
+	}
+}
+
+// routeCase is one request against a route of router.New
+type routeCase struct {
+	route   string // "METHOD pattern" as chi.Walk reports it
+	name    string
+	method  string
+	path    string      // may use the fixture placeholders
+	as      string      // "owner", "other", "admin", "" (anonymous) or a literal token
+	body    interface{} // a string may use the fixture placeholders
+	header  map[string]string
+	setup   func(h *Harness, fx *fixture)
+	status  int
+	ctype   string // expected Content-Type, application/json when empty
+	message string // expected error message, for error responses
+	check   func(t *testing.T, fx *fixture, resp *Response)
+}
+
+// todoItemCases are the cases for a route served at both /todos/{id} and
+// /users/{userID}/todos/{id}; prefix is prepended to "/{id}"
+func todoItemCases(prefix string) []routeCase {
+	pattern := "/todos/{id}"
+	if strings.HasPrefix(prefix, "/users/") {
+		pattern = "/users/{userID}/todos/{id}"
+	}
+
+	return []routeCase{
+		{route: "GET " + pattern, name: "own todo", method: "GET", path: prefix + "/{todo}", as: "owner", status: http.StatusOK,
+			check: func(t *testing.T, fx *fixture, resp *Response) {
+				var got handler.TodoResponse
+				resp.Data(t, &got)
+				if got.ID != fx.todo.ID || got.Title != "Mine" || got.UserID != fx.owner.ID {
+					t.Errorf("Expected the owner's todo, got %+v", got)
+				}
+				if resp.Header.Get("ETag") != fx.todoETag {
+					t.Errorf("Expected ETag %s, got %q", fx.todoETag, resp.Header.Get("ETag"))
+				}
+			}},
+		{route: "GET " + pattern, name: "someone else's todo", method: "GET", path: prefix + "/{otherTodo}", as: "owner", status: http.StatusForbidden, message: "Todo does not belong to user"},
+		{route: "GET " + pattern, name: "missing todo", method: "GET", path: prefix + "/{missing}", as: "owner", status: http.StatusNotFound, message: "Todo not found"},
+		{route: "GET " + pattern, name: "malformed ID", method: "GET", path: prefix + "/not-a-uuid", as: "owner", status: http.StatusBadRequest, message: "Invalid todo ID"},
+		{route: "GET " + pattern, name: "anonymous", method: "GET", path: prefix + "/{todo}", status: http.StatusUnauthorized, message: "Missing bearer token"},
+
+		{route: "PUT " + pattern, name: "replace", method: "PUT", path: prefix + "/{todo}", as: "owner",
+			body: handler.UpdateTodoRequest{Title: "Renamed", Completed: true}, header: map[string]string{"If-Match": "{todoETag}"}, status: http.StatusOK,
+			check: func(t *testing.T, fx *fixture, resp *Response) {
+				var got map[string]string
+				resp.Data(t, &got)
+				if got["message"] != "Todo updated successfully" {
+					t.Errorf("Expected success message, got %v", got)
+				}
+				if resp.Header.Get("ETag") == "" || resp.Header.Get("ETag") == fx.todoETag {
+					t.Errorf("Expected a new ETag, got %q", resp.Header.Get("ETag"))
+				}
+			}},
+		{route: "PUT " + pattern, name: "stale If-Match", method: "PUT", path: prefix + "/{todo}", as: "owner",
+			body: handler.UpdateTodoRequest{Title: "Renamed"}, header: map[string]string{"If-Match": `"1"`}, status: http.StatusPreconditionFailed,
+			message: "Todo was modified by another request; reload and retry"},
+		{route: "PUT " + pattern, name: "empty title", method: "PUT", path: prefix + "/{todo}", as: "owner",
+			body: handler.UpdateTodoRequest{}, status: http.StatusBadRequest, message: "Invalid todo ID or title"},
+		{route: "PUT " + pattern, name: "malformed body", method: "PUT", path: prefix + "/{todo}", as: "owner",
+			body: "{", status: http.StatusBadRequest, message: "Invalid request body"},
+
+		{route: "PATCH " + pattern, name: "merge patch", method: "PATCH", path: prefix + "/{todo}", as: "owner",
+			body: `{"completed": true, "description": null}`, status: http.StatusOK,
+			check: func(t *testing.T, fx *fixture, resp *Response) {
+				var got handler.TodoResponse
+				resp.Data(t, &got)
+				if got.Title != "Mine" || !got.Completed || got.Description != nil {
+					t.Errorf("Expected completed todo without description, got %+v", got)
+				}
+				if resp.Header.Get("ETag") == "" || resp.Header.Get("ETag") == fx.todoETag {
+					t.Errorf("Expected a new ETag, got %q", resp.Header.Get("ETag"))
+				}
+			}},
+		{route: "PATCH " + pattern, name: "null title", method: "PATCH", path: prefix + "/{todo}", as: "owner",
+			body: `{"title": null}`, status: http.StatusBadRequest, message: "title cannot be null"},
+		{route: "PATCH " + pattern, name: "someone else's todo", method: "PATCH", path: prefix + "/{otherTodo}", as: "owner",
+			body: `{"completed": true}`, status: http.StatusForbidden, message: "Todo does not belong to user"},
+
+		{route: "DELETE " + pattern, name: "delete", method: "DELETE", path: prefix + "/{todo}", as: "owner",
+			header: map[string]string{"If-Match": "{todoETag}"}, status: http.StatusOK,
+			check: func(t *testing.T, fx *fixture, resp *Response) {
+				var got map[string]string
+				resp.Data(t, &got)
+				if got["message"] != "Todo deleted successfully" {
+					t.Errorf("Expected success message, got %v", got)
+				}
+			}},
+		{route: "DELETE " + pattern, name: "weak If-Match", method: "DELETE", path: prefix + "/{todo}", as: "owner",
+			header: map[string]string{"If-Match": `W/"1"`}, status: http.StatusPreconditionFailed,
+			message: "Todo was modified by another request; reload and retry"},
+		{route: "DELETE " + pattern, name: "missing todo", method: "DELETE", path: prefix + "/{missing}", as: "owner",
+			status: http.StatusNotFound, message: "Todo not found"},
+	}
+}
+
+func routeCases() []routeCase {
+	cases := []routeCase{
+		{route: "GET /health", name: "ok", method: "GET", path: "/health", status: http.StatusOK,
+			check: func(t *testing.T, fx *fixture, resp *Response) {
+				if strings.TrimSpace(string(resp.Body)) != `{"status":"ok"}` {
+					t.Errorf("Expected status ok, got %s", resp.Body)
+				}
+			}},
+		{route: "GET /healthz", name: "live while a dependency is down", method: "GET", path: "/healthz",
+			setup: func(h *Harness, fx *fixture) {
+				h.Health.Add("database", func(ctx context.Context) (interface{}, error) {
+					return nil, errors.New("connection refused")
+				})
+			},
+			status: http.StatusOK,
+			check: func(t *testing.T, fx *fixture, resp *Response) {
+				if strings.TrimSpace(string(resp.Body)) != `{"status":"ok"}` {
+					t.Errorf("Expected status ok, got %s", resp.Body)
+				}
+			}},
+		{route: "GET /readyz", name: "ready", method: "GET", path: "/readyz",
+			setup: func(h *Harness, fx *fixture) {
+				h.Health.Add("database", func(ctx context.Context) (interface{}, error) {
+					return map[string]int{"total_conns": 1}, nil
+				})
+			},
+			status: http.StatusOK,
+			check: func(t *testing.T, fx *fixture, resp *Response) {
+				report := readiness(t, resp)
+				if report.Status != health.StatusOK || report.Checks["database"].Status != health.StatusOK {
+					t.Errorf("Expected every check ok, got %+v", report)
+				}
+				if report.Checks["database"].Details == nil {
+					t.Errorf("Expected database details, got %+v", report.Checks["database"])
+				}
+			}},
+		{route: "GET /readyz", name: "dependency down", method: "GET", path: "/readyz",
+			setup: func(h *Harness, fx *fixture) {
+				h.Health.Add("database", func(ctx context.Context) (interface{}, error) {
+					return nil, errors.New("connection refused")
+				})
+				h.Health.Add("schema", func(ctx context.Context) (interface{}, error) {
+					return nil, nil
+				})
+			},
+			status: http.StatusServiceUnavailable,
+			check: func(t *testing.T, fx *fixture, resp *Response) {
+				report := readiness(t, resp)
+				if report.Status != health.StatusFail {
+					t.Errorf("Expected status fail, got %q", report.Status)
+				}
+				if got := report.Checks["database"]; got.Status != health.StatusFail || got.Error != "connection refused" {
+					t.Errorf("Expected the database check to fail, got %+v", got)
+				}
+				if got := report.Checks["schema"]; got.Status != health.StatusOK {
+					t.Errorf("Expected the schema check to pass, got %+v", got)
+				}
+			}},
+
+		{route: "GET /metrics", name: "scrape", method: "GET", path: "/metrics", status: http.StatusOK,
+			ctype: "text/plain; version=0.0.4; charset=utf-8",
+			check: func(t *testing.T, fx *fixture, resp *Response) {
+				// The fixture's requests were served before this one
+				want := `http_requests_total{method="POST",route="/users/{userID}/todos",status="201"} 2`
+				if !strings.Contains(string(resp.Body), want) {
+					t.Errorf("Expected %s in\n%s", want, resp.Body)
+				}
+			}},
+
+		{route: "POST /login", name: "valid credentials", method: "POST", path: "/login",
+			body: handler.LoginRequest{Email: "owner@example.com", Password: Password}, status: http.StatusOK,
+			check: func(t *testing.T, fx *fixture, resp *Response) {
+				var got handler.LoginResponse
+				resp.Data(t, &got)
+				if got.AccessToken == "" || got.TokenType != "Bearer" || got.User.ID != fx.owner.ID {
+					t.Errorf("Expected a bearer token for the owner, got %+v", got)
+				}
+				if got.RefreshToken == "" || got.RefreshToken == fx.refresh || !got.RefreshExpiresAt.After(got.ExpiresAt) {
+					t.Errorf("Expected a refresh token for a new session, got %+v", got)
+				}
+			}},
+		{route: "POST /login", name: "wrong password", method: "POST", path: "/login",
+			body: handler.LoginRequest{Email: "owner@example.com", Password: "wrong-password"}, status: http.StatusUnauthorized, message: "Invalid email or password"},
+		{route: "POST /login", name: "malformed body", method: "POST", path: "/login",
+			body: "{", status: http.StatusBadRequest, message: "Invalid request body"},
+
+		{route: "POST /auth/refresh", name: "rotate", method: "POST", path: "/auth/refresh",
+			body: `{"refresh_token": "{refresh}"}`, status: http.StatusOK,
+			check: func(t *testing.T, fx *fixture, resp *Response) {
+				var got handler.LoginResponse
+				resp.Data(t, &got)
+				if got.AccessToken == "" || got.User.ID != fx.owner.ID {
+					t.Errorf("Expected a bearer token for the owner, got %+v", got)
+				}
+				if got.RefreshToken == "" || got.RefreshToken == fx.refresh {
+					t.Errorf("Expected a new refresh token, got %q", got.RefreshToken)
+				}
+			}},
+		{route: "POST /auth/refresh", name: "reused token", method: "POST", path: "/auth/refresh",
+			setup: func(h *Harness, fx *fixture) {
+				h.Do(Request{Method: "POST", Path: "/auth/refresh", Body: handler.RefreshTokenRequest{RefreshToken: fx.refresh}})
+			},
+			body: `{"refresh_token": "{refresh}"}`, status: http.StatusUnauthorized, message: "Refresh token was already used; session revoked"},
+		{route: "POST /auth/refresh", name: "deactivated user", method: "POST", path: "/auth/refresh",
+			setup: func(h *Harness, fx *fixture) {
+				h.Do(Request{Method: "DELETE", Path: "/users/" + fx.owner.ID, Token: fx.admin.Token})
+			},
+			body: `{"refresh_token": "{refresh}"}`, status: http.StatusUnauthorized, message: "Invalid refresh token"},
+		{route: "POST /auth/refresh", name: "unknown token", method: "POST", path: "/auth/refresh",
+			body: `{"refresh_token": "{session}.forged"}`, status: http.StatusUnauthorized, message: "Refresh token was already used; session revoked"},
+		{route: "POST /auth/refresh", name: "malformed token", method: "POST", path: "/auth/refresh",
+			body: `{"refresh_token": "garbage"}`, status: http.StatusUnauthorized, message: "Invalid refresh token"},
+		{route: "POST /auth/refresh", name: "missing token", method: "POST", path: "/auth/refresh",
+			body: `{}`, status: http.StatusBadRequest, message: "Missing refresh token"},
+
+		{route: "POST /auth/logout", name: "logout", method: "POST", path: "/auth/logout",
+			body: `{"refresh_token": "{refresh}"}`, status: http.StatusOK},
+		{route: "POST /auth/logout", name: "unknown token", method: "POST", path: "/auth/logout",
+			body: `{"refresh_token": "garbage"}`, status: http.StatusOK},
+		{route: "POST /auth/logout", name: "missing token", method: "POST", path: "/auth/logout",
+			body: `{}`, status: http.StatusBadRequest, message: "Missing refresh token"},
+
+		{route: "POST /auth/password/forgot", name: "known email", method: "POST", path: "/auth/password/forgot",
+			body: `{"email": "owner@example.com"}`, status: http.StatusAccepted,
+			check: func(t *testing.T, fx *fixture, resp *Response) {
+				if sent := fx.outbox.Sent(); len(sent) != 2 || sent[1].To != fx.owner.Email {
+					t.Errorf("Expected a second email to the owner, got %+v", sent)
+				}
+			}},
+		{route: "POST /auth/password/forgot", name: "unknown email", method: "POST", path: "/auth/password/forgot",
+			body: `{"email": "nobody@example.com"}`, status: http.StatusAccepted,
+			check: func(t *testing.T, fx *fixture, resp *Response) {
+				if sent := fx.outbox.Sent(); len(sent) != 1 {
+					t.Errorf("Expected no new email, got %+v", sent)
+				}
+			}},
+		{route: "POST /auth/password/forgot", name: "missing email", method: "POST", path: "/auth/password/forgot",
+			body: `{}`, status: http.StatusBadRequest, message: "Missing email"},
+
+		{route: "POST /auth/password/reset", name: "reset", method: "POST", path: "/auth/password/reset",
+			body: `{"token": "{reset}", "password": "new-password"}`, status: http.StatusOK},
+		{route: "POST /auth/password/reset", name: "used token", method: "POST", path: "/auth/password/reset",
+			setup: func(h *Harness, fx *fixture) {
+				h.Do(Request{Method: "POST", Path: "/auth/password/reset", Body: handler.ResetPasswordRequest{Token: fx.reset, Password: "new-password"}})
+			},
+			body: `{"token": "{reset}", "password": "other-password"}`, status: http.StatusBadRequest, message: "Invalid reset token"},
+		{route: "POST /auth/password/reset", name: "forged token", method: "POST", path: "/auth/password/reset",
+			body: `{"token": "{session}.forged", "password": "new-password"}`, status: http.StatusBadRequest, message: "Invalid reset token"},
+		{route: "POST /auth/password/reset", name: "weak password", method: "POST", path: "/auth/password/reset",
+			body: `{"token": "{reset}", "password": "short"}`, status: http.StatusBadRequest, message: "Password must be at least 8 characters"},
+		{route: "POST /auth/password/reset", name: "missing token", method: "POST", path: "/auth/password/reset",
+			body: `{"password": "new-password"}`, status: http.StatusBadRequest, message: "Missing reset token"},
+
+		{route: "POST /users/", name: "create", method: "POST", path: "/users",
+			body: handler.CreateUserRequest{Email: "new@example.com", Name: "New", Password: "password123"}, status: http.StatusCreated,
+			check: func(t *testing.T, fx *fixture, resp *Response) {
+				var got handler.UserResponse
+				resp.Data(t, &got)
+				if got.Email != "new@example.com" || !got.IsActive || got.ID == "" {
+					t.Errorf("Expected the new active user, got %+v", got)
+				}
+				if strings.Contains(string(resp.Body), "password") {
+					t.Errorf("Expected no password data in %s", resp.Body)
+				}
+			}},
+		{route: "POST /users/", name: "duplicate email", method: "POST", path: "/users",
+			body: handler.CreateUserRequest{Email: "owner@example.com", Name: "Twin", Password: "password123"}, status: http.StatusConflict, message: "Email already exists"},
+		{route: "POST /users/", name: "weak password", method: "POST", path: "/users",
+			body: handler.CreateUserRequest{Email: "new@example.com", Name: "New", Password: "short"}, status: http.StatusBadRequest, message: "Password must be at least 8 characters"},
+
+		{route: "GET /users/", name: "list", method: "GET", path: "/users?limit=1&offset=1", as: "admin", status: http.StatusOK,
+			check: func(t *testing.T, fx *fixture, resp *Response) {
+				var got []handler.UserResponse
+				resp.Data(t, &got)
+				if len(got) != 1 {
+					t.Errorf("Expected one user on the page, got %d", len(got))
+				}
+			}},
+		{route: "GET /users/", name: "not an admin", method: "GET", path: "/users", as: "owner", status: http.StatusForbidden, message: "Access denied"},
+		{route: "GET /users/", name: "anonymous", method: "GET", path: "/users", status: http.StatusUnauthorized, message: "Missing bearer token"},
+
+		{route: "GET /users/{id}", name: "existing user", method: "GET", path: "/users/{owner}", as: "owner", status: http.StatusOK,
+			check: func(t *testing.T, fx *fixture, resp *Response) {
+				var got handler.UserResponse
+				resp.Data(t, &got)
+				if got.ID != fx.owner.ID || got.Role != "user" || resp.Header.Get("ETag") == "" {
+					t.Errorf("Expected the owner with an ETag, got %+v", got)
+				}
+				if strings.Contains(string(resp.Body), "token") {
+					t.Errorf("Expected no token in %s", resp.Body)
+				}
+			}},
+		{route: "GET /users/{id}", name: "someone else", method: "GET", path: "/users/{other}", as: "owner", status: http.StatusForbidden, message: "Access denied"},
+		{route: "GET /users/{id}", name: "admin on someone else", method: "GET", path: "/users/{owner}", as: "admin", status: http.StatusOK},
+		{route: "GET /users/{id}", name: "missing user", method: "GET", path: "/users/{missing}", as: "admin", status: http.StatusNotFound, message: "User not found"},
+		{route: "GET /users/{id}", name: "malformed ID", method: "GET", path: "/users/not-a-uuid", as: "admin", status: http.StatusBadRequest, message: "Invalid user ID"},
+		{route: "GET /users/{id}", name: "anonymous", method: "GET", path: "/users/{owner}", status: http.StatusUnauthorized, message: "Missing bearer token"},
+
+		{route: "PUT /users/{id}", name: "rename", method: "PUT", path: "/users/{owner}", as: "owner",
+			body: handler.UpdateUserRequest{Name: "Renamed"}, header: map[string]string{"If-Match": "{ownerETag}"}, status: http.StatusOK,
+			check: func(t *testing.T, fx *fixture, resp *Response) {
+				if resp.Header.Get("ETag") == "" || resp.Header.Get("ETag") == fx.ownerETag {
+					t.Errorf("Expected a new ETag, got %q", resp.Header.Get("ETag"))
+				}
+			}},
+		{route: "PUT /users/{id}", name: "stale If-Match", method: "PUT", path: "/users/{owner}", as: "owner",
+			body: handler.UpdateUserRequest{Name: "Renamed"}, header: map[string]string{"If-Match": `"1"`}, status: http.StatusPreconditionFailed,
+			message: "User was modified by another request; reload and retry"},
+		{route: "PUT /users/{id}", name: "someone else", method: "PUT", path: "/users/{owner}", as: "other",
+			body: handler.UpdateUserRequest{Name: "Renamed"}, status: http.StatusForbidden, message: "Access denied"},
+
+		{route: "PATCH /users/{id}", name: "merge patch", method: "PATCH", path: "/users/{owner}", as: "owner",
+			body: `{"name": "Patched"}`, status: http.StatusOK,
+			check: func(t *testing.T, fx *fixture, resp *Response) {
+				var got handler.UserResponse
+				resp.Data(t, &got)
+				if got.Name != "Patched" {
+					t.Errorf("Expected name Patched, got %q", got.Name)
+				}
+			}},
+		{route: "PATCH /users/{id}", name: "admin on someone else", method: "PATCH", path: "/users/{owner}", as: "admin",
+			body: `{"name": "Patched"}`, status: http.StatusOK},
+		{route: "PATCH /users/{id}", name: "someone else", method: "PATCH", path: "/users/{owner}", as: "other",
+			body: `{"name": "Patched"}`, status: http.StatusForbidden, message: "Access denied"},
+		{route: "PATCH /users/{id}", name: "unknown field", method: "PATCH", path: "/users/{owner}", as: "owner",
+			body: `{"email": "x@example.com"}`, status: http.StatusBadRequest},
+		{route: "PATCH /users/{id}", name: "role is not patchable", method: "PATCH", path: "/users/{owner}", as: "owner",
+			body: `{"role": "admin"}`, status: http.StatusBadRequest},
+		{route: "PATCH /users/{id}", name: "unsupported media type", method: "PATCH", path: "/users/{owner}", as: "owner",
+			body: `{"name": "Patched"}`, header: map[string]string{"Content-Type": "text/plain"}, status: http.StatusUnsupportedMediaType,
+			check: func(t *testing.T, fx *fixture, resp *Response) {
+				if resp.Header.Get("Accept-Patch") == "" {
+					t.Errorf("Expected an Accept-Patch header")
+				}
+			}},
+
+		{route: "DELETE /users/{id}", name: "delete", method: "DELETE", path: "/users/{owner}", as: "admin", status: http.StatusOK},
+		{route: "DELETE /users/{id}", name: "not an admin", method: "DELETE", path: "/users/{owner}", as: "owner", status: http.StatusForbidden, message: "Access denied"},
+		{route: "DELETE /users/{id}", name: "missing user", method: "DELETE", path: "/users/{missing}", as: "admin", status: http.StatusNotFound, message: "User not found"},
+
+		{route: "POST /users/{id}/restore", name: "deleted user", method: "POST", path: "/users/{owner}/restore", as: "admin",
+			setup: func(h *Harness, fx *fixture) {
+				h.Do(Request{Method: "DELETE", Path: "/users/" + fx.owner.ID, Token: fx.admin.Token})
+			},
+			status: http.StatusOK,
+			check: func(t *testing.T, fx *fixture, resp *Response) {
+				var got handler.UserResponse
+				resp.Data(t, &got)
+				if !got.IsActive {
+					t.Errorf("Expected the user active again, got %+v", got)
+				}
+			}},
+		{route: "POST /users/{id}/restore", name: "active user", method: "POST", path: "/users/{owner}/restore", as: "admin",
+			status: http.StatusNotFound, message: "User not found or not deleted"},
+		{route: "POST /users/{id}/restore", name: "not an admin", method: "POST", path: "/users/{owner}/restore", as: "owner",
+			status: http.StatusForbidden, message: "Access denied"},
+		{route: "POST /users/{id}/restore", name: "anonymous", method: "POST", path: "/users/{owner}/restore",
+			status: http.StatusUnauthorized, message: "Missing bearer token"},
+
+		{route: "GET /users/{id}/sessions", name: "own sessions", method: "GET", path: "/users/{owner}/sessions", as: "owner", status: http.StatusOK,
+			check: func(t *testing.T, fx *fixture, resp *Response) {
+				var got []handler.SessionResponse
+				resp.Data(t, &got)
+				if len(got) != 1 || got[0].ID != fx.session || got[0].IP == "" || got[0].UserAgent == "" {
+					t.Errorf("Expected the owner's session with its device, got %+v", got)
+				}
+				if strings.Contains(string(resp.Body), "hash") || strings.Contains(string(resp.Body), "token") {
+					t.Errorf("Expected no token data in %s", resp.Body)
+				}
+			}},
+		{route: "GET /users/{id}/sessions", name: "someone else", method: "GET", path: "/users/{owner}/sessions", as: "other", status: http.StatusForbidden, message: "Access denied"},
+		{route: "GET /users/{id}/sessions", name: "admin on someone else", method: "GET", path: "/users/{owner}/sessions", as: "admin", status: http.StatusOK},
+		{route: "GET /users/{id}/sessions", name: "anonymous", method: "GET", path: "/users/{owner}/sessions", status: http.StatusUnauthorized, message: "Missing bearer token"},
+
+		{route: "DELETE /users/{id}/sessions/{sessionID}", name: "revoke", method: "DELETE", path: "/users/{owner}/sessions/{session}", as: "owner", status: http.StatusOK},
+		{route: "DELETE /users/{id}/sessions/{sessionID}", name: "someone else's session", method: "DELETE", path: "/users/{other}/sessions/{session}", as: "other",
+			status: http.StatusNotFound, message: "Session not found"},
+		{route: "DELETE /users/{id}/sessions/{sessionID}", name: "someone else", method: "DELETE", path: "/users/{owner}/sessions/{session}", as: "other",
+			status: http.StatusForbidden, message: "Access denied"},
+		{route: "DELETE /users/{id}/sessions/{sessionID}", name: "malformed ID", method: "DELETE", path: "/users/{owner}/sessions/not-a-uuid", as: "owner",
+			status: http.StatusBadRequest, message: "Invalid user or session ID"},
+
+		{route: "DELETE /users/{id}/sessions", name: "revoke all", method: "DELETE", path: "/users/{owner}/sessions", as: "owner", status: http.StatusOK},
+		{route: "DELETE /users/{id}/sessions", name: "someone else", method: "DELETE", path: "/users/{owner}/sessions", as: "other", status: http.StatusForbidden, message: "Access denied"},
+
+		{route: "PUT /users/{id}/password", name: "change", method: "PUT", path: "/users/{owner}/password", as: "owner",
+			body: `{"current_password": "password123", "new_password": "new-password"}`, status: http.StatusOK},
+		{route: "PUT /users/{id}/password", name: "wrong current password", method: "PUT", path: "/users/{owner}/password", as: "owner",
+			body: `{"current_password": "wrong-password", "new_password": "new-password"}`, status: http.StatusBadRequest, message: "Current password is incorrect"},
+		{route: "PUT /users/{id}/password", name: "weak password", method: "PUT", path: "/users/{owner}/password", as: "owner",
+			body: `{"current_password": "password123", "new_password": "short"}`, status: http.StatusBadRequest, message: "Password must be at least 8 characters"},
+		{route: "PUT /users/{id}/password", name: "missing passwords", method: "PUT", path: "/users/{owner}/password", as: "owner",
+			body: `{}`, status: http.StatusBadRequest, message: "Invalid user ID, current or new password"},
+		{route: "PUT /users/{id}/password", name: "someone else", method: "PUT", path: "/users/{owner}/password", as: "other",
+			body: `{"current_password": "password123", "new_password": "new-password"}`, status: http.StatusForbidden, message: "Access denied"},
+
+		{route: "PUT /users/{id}/role", name: "promote", method: "PUT", path: "/users/{owner}/role", as: "admin",
+			body: handler.SetRoleRequest{Role: "admin"}, status: http.StatusOK,
+			check: func(t *testing.T, fx *fixture, resp *Response) {
+				var got handler.UserResponse
+				resp.Data(t, &got)
+				if got.ID != fx.owner.ID || got.Role != "admin" {
+					t.Errorf("Expected the owner as admin, got %+v", got)
+				}
+			}},
+		{route: "PUT /users/{id}/role", name: "not an admin", method: "PUT", path: "/users/{owner}/role", as: "owner",
+			body: handler.SetRoleRequest{Role: "admin"}, status: http.StatusForbidden, message: "Access denied"},
+		{route: "PUT /users/{id}/role", name: "own role", method: "PUT", path: "/users/{admin}/role", as: "admin",
+			body: handler.SetRoleRequest{Role: "user"}, status: http.StatusForbidden, message: "Access denied"},
+		{route: "PUT /users/{id}/role", name: "unknown role", method: "PUT", path: "/users/{owner}/role", as: "admin",
+			body: handler.SetRoleRequest{Role: "root"}, status: http.StatusBadRequest, message: "Role must be user or admin"},
+		{route: "PUT /users/{id}/role", name: "missing user", method: "PUT", path: "/users/{missing}/role", as: "admin",
+			body: handler.SetRoleRequest{Role: "admin"}, status: http.StatusNotFound, message: "User not found"},
+
+		{route: "POST /users/{userID}/todos/", name: "create", method: "POST", path: "/users/{owner}/todos", as: "owner",
+			body: handler.CreateTodoRequest{Title: "New"}, status: http.StatusCreated,
+			check: func(t *testing.T, fx *fixture, resp *Response) {
+				var got handler.TodoResponse
+				resp.Data(t, &got)
+				if got.Title != "New" || got.UserID != fx.owner.ID || got.Completed {
+					t.Errorf("Expected a new todo for the owner, got %+v", got)
+				}
+			}},
+		{route: "POST /users/{userID}/todos/", name: "someone else's list", method: "POST", path: "/users/{other}/todos", as: "owner",
+			body: handler.CreateTodoRequest{Title: "New"}, status: http.StatusForbidden, message: "Access denied"},
+		{route: "POST /users/{userID}/todos/", name: "empty title", method: "POST", path: "/users/{owner}/todos", as: "owner",
+			body: handler.CreateTodoRequest{}, status: http.StatusBadRequest, message: "Invalid user ID or title"},
+		{route: "POST /users/{userID}/todos/", name: "anonymous", method: "POST", path: "/users/{owner}/todos",
+			body: handler.CreateTodoRequest{Title: "New"}, status: http.StatusUnauthorized, message: "Missing bearer token"},
+		{route: "POST /users/{userID}/todos/", name: "invalid token", method: "POST", path: "/users/{owner}/todos", as: "not.a.token",
+			body: handler.CreateTodoRequest{Title: "New"}, status: http.StatusUnauthorized, message: "Invalid token"},
+
+		{route: "GET /users/{userID}/todos/", name: "list", method: "GET", path: "/users/{owner}/todos", as: "owner", status: http.StatusOK,
+			check: func(t *testing.T, fx *fixture, resp *Response) {
+				var got []handler.TodoResponse
+				resp.Data(t, &got)
+				if len(got) != 1 || got[0].ID != fx.todo.ID {
+					t.Errorf("Expected only the owner's todo, got %+v", got)
+				}
+			}},
+		{route: "GET /users/{userID}/todos/", name: "filtered", method: "GET", path: "/users/{owner}/todos?completed=true", as: "owner", status: http.StatusOK,
+			check: func(t *testing.T, fx *fixture, resp *Response) {
+				var got []handler.TodoResponse
+				resp.Data(t, &got)
+				if len(got) != 0 {
+					t.Errorf("Expected no completed todos, got %d", len(got))
+				}
+			}},
+		{route: "GET /users/{userID}/todos/", name: "invalid filter", method: "GET", path: "/users/{owner}/todos?due_after=someday", as: "owner",
+			status: http.StatusBadRequest, message: "Invalid due_after"},
+
+		{route: "GET /users/{userID}/todos/overdue", name: "overdue", method: "GET", path: "/users/{owner}/todos/overdue", as: "owner", status: http.StatusOK,
+			check: func(t *testing.T, fx *fixture, resp *Response) {
+				var got []handler.TodoResponse
+				resp.Data(t, &got)
+				var meta handler.PageMeta
+				resp.Meta(t, &meta)
+				if len(got) != 1 || meta.Total != 1 || meta.Limit != 10 {
+					t.Errorf("Expected one overdue todo, got %d with %+v", len(got), meta)
+				}
+			}},
+		{route: "GET /users/{userID}/todos/overdue", name: "someone else's list", method: "GET", path: "/users/{other}/todos/overdue", as: "owner",
+			status: http.StatusForbidden, message: "Access denied"},
+
+		{route: "PATCH /users/{userID}/todos/{id}/toggle", name: "toggle", method: "PATCH", path: "/users/{owner}/todos/{todo}/toggle", as: "owner", status: http.StatusOK,
+			check: func(t *testing.T, fx *fixture, resp *Response) {
+				var got handler.TodoResponse
+				resp.Data(t, &got)
+				if !got.Completed || got.Title != "Mine" {
+					t.Errorf("Expected the completed todo, got %+v", got)
+				}
+				if resp.Header.Get("ETag") == "" {
+					t.Errorf("Expected an ETag")
+				}
+			}},
+		{route: "PATCH /users/{userID}/todos/{id}/toggle", name: "someone else's todo", method: "PATCH", path: "/users/{owner}/todos/{otherTodo}/toggle", as: "owner",
+			status: http.StatusForbidden, message: "Todo does not belong to user"},
+		{route: "PATCH /users/{userID}/todos/{id}/toggle", name: "missing todo", method: "PATCH", path: "/users/{owner}/todos/{missing}/toggle", as: "owner",
+			status: http.StatusNotFound, message: "Todo not found"},
+	}
+
+	cases = append(cases, todoItemCases("/todos")...)
+	cases = append(cases, todoItemCases("/users/{owner}/todos")...)
+
+	return cases
+}
+
+// TestRoutes runs every route case against a fresh server and fixture
+// readiness decodes a /readyz body, which is not wrapped in handler.Response
+func readiness(t *testing.T, resp *Response) health.Report {
+	t.Helper()
+
+	var report health.Report
+	if err := json.Unmarshal(resp.Body, &report); err != nil {
+		t.Fatalf("Decode readiness report: %v (%s)", err, resp.Body)
+	}
+	return report
+}
+
+func TestRoutes(t *testing.T) {
+	for _, tc := range routeCases() {
+		t.Run(tc.route+"/"+tc.name, func(t *testing.T) {
+			h := NewHarness(t, MemoryBackend())
+			fx := newFixture(h)
+			if tc.setup != nil {
+				tc.setup(h, fx)
+			}
+
+			header := make(map[string]string, len(tc.header))
+			for name, value := range tc.header {
+				header[name] = fx.expand(value)
+			}
+
+			body := tc.body
+			if s, ok := body.(string); ok {
+				body = fx.expand(s)
+			}
+
+			resp := h.Do(Request{Method: tc.method, Path: fx.expand(tc.path), Token: fx.token(tc.as), Body: body, Header: header})
+
+			if resp.Status != tc.status {
+				t.Fatalf("Expected status %d, got %d %s", tc.status, resp.Status, resp.Body)
+			}
+			ctype := tc.ctype
+			if ctype == "" {
+				ctype = "application/json"
+			}
+			if ct := resp.Header.Get("Content-Type"); ct != ctype {
+				t.Errorf("Expected Content-Type %s, got %q", ctype, ct)
+			}
+			if tc.message != "" {
+				if got := resp.Error(t); got != tc.message {
+					t.Errorf("Expected error %q, got %q", tc.message, got)
+				}
+			}
+			if tc.check != nil {
+				tc.check(t, fx, resp)
+			}
+		})
+	}
+}
+
+// TestRoutesCovered fails when router.New serves a route without a case
+func TestRoutesCovered(t *testing.T) {
+	h := NewHarness(t, MemoryBackend())
+
+	covered := make(map[string]bool)
+	for _, tc := range routeCases() {
+		covered[tc.route] = true
+	}
+
+	err := chi.Walk(h.Router, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if !covered[method+" "+route] {
+			t.Errorf("No test case for %s %s", method, route)
+		}
+		delete(covered, method+" "+route)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walk routes: %v", err)
+	}
+
+	for route := range covered {
+		t.Errorf("Test cases for %s, which the router does not serve", route)
+	}
+}
+
+// TestTodoRoutesAgree sends the same requests to /todos/{id} and
+// /users/{userID}/todos/{id} and expects identical responses
+func TestTodoRoutesAgree(t *testing.T) {
+	h := NewHarness(t, MemoryBackend())
+	fx := newFixture(h)
+
+	requests := []struct {
+		name   string
+		method string
+		id     string
+		body   interface{}
+		header map[string]string
+	}{
+		{"own todo", "GET", fx.todo.ID, nil, nil},
+		{"someone else's todo", "GET", fx.otherTodo.ID, nil, nil},
+		{"missing todo", "GET", uuid.NewString(), nil, nil},
+		{"malformed ID", "GET", "not-a-uuid", nil, nil},
+		{"stale PUT", "PUT", fx.todo.ID, handler.UpdateTodoRequest{Title: "Renamed"}, map[string]string{"If-Match": `"1"`}},
+		{"PUT someone else's todo", "PUT", fx.otherTodo.ID, handler.UpdateTodoRequest{Title: "Renamed"}, nil},
+		{"empty PATCH", "PATCH", fx.todo.ID, `{}`, nil},
+		{"PATCH unknown field", "PATCH", fx.todo.ID, `{"owner": "me"}`, nil},
+		{"PATCH wrong media type", "PATCH", fx.todo.ID, `{}`, map[string]string{"Content-Type": "text/plain"}},
+		{"stale DELETE", "DELETE", fx.todo.ID, nil, map[string]string{"If-Match": `"1"`}},
+		{"DELETE someone else's todo", "DELETE", fx.otherTodo.ID, nil, nil},
+	}
+
+	for _, req := range requests {
+		t.Run(req.name, func(t *testing.T) {
+			global := h.Do(Request{Method: req.method, Path: "/todos/" + req.id, Token: fx.owner.Token, Body: req.body, Header: req.header})
+			nested := h.Do(Request{Method: req.method, Path: "/users/" + fx.owner.ID + "/todos/" + req.id, Token: fx.owner.Token, Body: req.body, Header: req.header})
+
+			if global.Status != nested.Status || string(global.Body) != string(nested.Body) {
+				t.Errorf("Expected identical responses, got %d %s and %d %s", global.Status, global.Body, nested.Status, nested.Body)
+			}
+			if global.Header.Get("ETag") != nested.Header.Get("ETag") {
+				t.Errorf("Expected identical ETags, got %q and %q", global.Header.Get("ETag"), nested.Header.Get("ETag"))
+			}
+		})
+	}
+}
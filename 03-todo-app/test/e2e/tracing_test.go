@@ -0,0 +1,80 @@
+package e2e
+
+import (
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// recordSpans installs a global tracer provider recording every span until
+// the test ends
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+
+	recorder := tracetest.NewSpanRecorder()
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+
+	return recorder
+}
+
+func TestTraceparentContinued(t *testing.T) {
+	recorder := recordSpans(t)
+	h := NewHarness(t, MemoryBackend())
+	fx := newFixture(h)
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	resp := h.Do(Request{
+		Method: "GET",
+		Path:   "/todos/" + fx.todo.ID,
+		Token:  fx.owner.Token,
+		Header: map[string]string{"traceparent": "00-" + traceID + "-00f067aa0ba902b7-01"},
+	})
+	if resp.Status != 200 {
+		t.Fatalf("Expected status 200, got %d", resp.Status)
+	}
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		if span.SpanContext().TraceID().String() == traceID {
+			spans[span.Name()] = span
+		}
+	}
+
+	server, ok := spans["GET /todos/{id}"]
+	if !ok {
+		t.Fatalf("Expected a server span named after the route in trace %s, got %v", traceID, spans)
+	}
+	if server.SpanKind() != trace.SpanKindServer || server.Parent().SpanID().String() != "00f067aa0ba902b7" {
+		t.Errorf("Expected a server span under the incoming parent, got kind %v parent %v", server.SpanKind(), server.Parent().SpanID())
+	}
+
+	service, ok := spans["todo.Service.GetByID"]
+	if !ok {
+		t.Fatalf("Expected a todo.Service.GetByID span in trace %s, got %v", traceID, spans)
+	}
+	if service.Parent().SpanID() != server.SpanContext().SpanID() {
+		t.Errorf("Expected the service span to be a child of the server span")
+	}
+}
+
+func TestTraceStartedWithoutTraceparent(t *testing.T) {
+	recorder := recordSpans(t)
+	h := NewHarness(t, MemoryBackend())
+
+	h.Do(Request{Method: "GET", Path: "/no/such/route"})
+
+	spans := recorder.Ended()
+	if len(spans) != 1 || spans[0].Name() != "GET unmatched" || spans[0].Parent().IsValid() {
+		t.Errorf("Expected one root span for the unmatched request, got %v", spans)
+	}
+}
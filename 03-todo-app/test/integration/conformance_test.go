@@ -0,0 +1,22 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/repository"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/repository/repotest"
+)
+
+// TestRepositoryConformance runs the shared repository suite against the
+// ChameleonDB repositories; memrepo runs the same suite in its own tests
+func TestRepositoryConformance(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repotest.Repos {
+		eng := setupTestEngine(t)
+		return repotest.Repos{
+			Todos:          repository.NewTodoRepository(eng),
+			Users:          repository.NewUserRepository(eng),
+			Sessions:       repository.NewSessionRepository(eng),
+			PasswordResets: repository.NewPasswordResetRepository(eng),
+		}
+	})
+}
@@ -0,0 +1,27 @@
+package integration
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/chameleon-db/chameleon-examples/todo-app/test/pgtest"
+)
+
+// TestMain starts a disposable PostgreSQL server when the run opts in
+// with TEST_POSTGRES=local; see setupTestEngine
+func TestMain(m *testing.M) {
+	if !pgtest.Enabled() {
+		os.Exit(m.Run())
+	}
+
+	stop, err := startLocalPostgres()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "TEST_POSTGRES=local: %v\n", err)
+		os.Exit(1)
+	}
+
+	code := m.Run()
+	stop()
+	os.Exit(code)
+}
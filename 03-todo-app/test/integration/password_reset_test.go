@@ -0,0 +1,67 @@
+package integration
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/passwordreset"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/session"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/user"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/mail"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/repository"
+)
+
+// lastMail is a mail.Mailer keeping the last message it was sent
+type lastMail struct {
+	msg mail.Message
+}
+
+func (m *lastMail) Send(_ context.Context, msg mail.Message) error {
+	m.msg = msg
+	return nil
+}
+
+// TestPasswordResetRevokesSessions tests that a reset token sets the new
+// password once and signs the user out of every session
+func TestPasswordResetRevokesSessions(t *testing.T) {
+	eng := setupTestEngine(t)
+	userRepo := repository.NewUserRepository(eng)
+	sessionRepo := repository.NewSessionRepository(eng)
+	resetRepo := repository.NewPasswordResetRepository(eng)
+	uow := repository.NewUnitOfWork(eng)
+	userSvc := user.NewService(userRepo, sessionRepo, resetRepo, uow)
+	sessionSvc := session.NewService(sessionRepo, userRepo, time.Hour)
+	outbox := &lastMail{}
+	resetSvc := passwordreset.NewService(resetRepo, userRepo, sessionRepo, uow, outbox, time.Hour, "https://app.example.com/reset", slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	ctx := context.Background()
+
+	u, _ := userSvc.Create(ctx, "test@example.com", "Test User", "password123")
+	started, _ := sessionSvc.Start(ctx, u, session.Client{UserAgent: "laptop"})
+
+	if err := resetSvc.Request(ctx, u.Email); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	resetSvc.Wait()
+	link, _ := url.Parse(regexp.MustCompile(`https://\S+`).FindString(outbox.msg.Body))
+	token := link.Query().Get("token")
+
+	if err := resetSvc.Reset(ctx, token, "new-password"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := resetSvc.Reset(ctx, token, "other-password"); err != passwordreset.ErrInvalidToken {
+		t.Errorf("Expected the token used up, got %v", err)
+	}
+
+	if _, err := userSvc.VerifyPassword(ctx, u.Email, "new-password"); err != nil {
+		t.Errorf("Expected the new password to verify, got %v", err)
+	}
+	if _, err := sessionSvc.Refresh(ctx, started.RefreshToken, session.Client{}); err != session.ErrInvalidToken {
+		t.Errorf("Expected the session revoked, got %v", err)
+	}
+}
@@ -0,0 +1,20 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/repository"
+)
+
+// TestSchemaMatchesDomainModels parses schemas/*.cham and checks them
+// against the typed row mappers in internal/repository
+func TestSchemaMatchesDomainModels(t *testing.T) {
+	eng, err := newSchemaEngine()
+	if err != nil {
+		t.Fatalf("Failed to load schemas: %v", err)
+	}
+
+	if err := repository.VerifySchema(eng.Schema()); err != nil {
+		t.Errorf("Schema drift detected: %v", err)
+	}
+}
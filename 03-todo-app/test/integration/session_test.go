@@ -0,0 +1,71 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/session"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/user"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/repository"
+)
+
+// TestSessionRefreshRotatesToken tests that every refresh rotates the token
+// and that replaying a rotated one revokes the session
+func TestSessionRefreshRotatesToken(t *testing.T) {
+	eng := setupTestEngine(t)
+	userRepo := repository.NewUserRepository(eng)
+	sessionSvc := session.NewService(repository.NewSessionRepository(eng), userRepo, time.Hour)
+	client := session.Client{UserAgent: "integration", IP: "192.0.2.1"}
+
+	ctx := context.Background()
+
+	u, _ := userRepo.Create(ctx, "test@example.com", "Test User", "hash")
+
+	started, err := sessionSvc.Start(ctx, u, client)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	refreshed, err := sessionSvc.Refresh(ctx, started.RefreshToken, client)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if refreshed.RefreshToken == started.RefreshToken || refreshed.Session.ID != started.Session.ID {
+		t.Errorf("Expected a new token for the same session")
+	}
+
+	if _, err := sessionSvc.Refresh(ctx, started.RefreshToken, client); err != session.ErrTokenReused {
+		t.Errorf("Expected ErrTokenReused, got %v", err)
+	}
+	if _, err := sessionSvc.Refresh(ctx, refreshed.RefreshToken, client); err != session.ErrInvalidToken {
+		t.Errorf("Expected the session revoked after reuse, got %v", err)
+	}
+}
+
+// TestUserDeleteRevokesSessions tests that deactivating a user revokes
+// every session they have
+func TestUserDeleteRevokesSessions(t *testing.T) {
+	eng := setupTestEngine(t)
+	userRepo := repository.NewUserRepository(eng)
+	sessionRepo := repository.NewSessionRepository(eng)
+	userSvc := user.NewService(userRepo, sessionRepo, repository.NewPasswordResetRepository(eng), repository.NewUnitOfWork(eng))
+	sessionSvc := session.NewService(sessionRepo, userRepo, time.Hour)
+
+	ctx := context.Background()
+
+	u, _ := userSvc.Create(ctx, "test@example.com", "Test User", "password123")
+	laptop, _ := sessionSvc.Start(ctx, u, session.Client{UserAgent: "laptop"})
+	sessionSvc.Start(ctx, u, session.Client{UserAgent: "phone"})
+
+	if err := userSvc.Delete(ctx, u.ID.String(), nil); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if active, err := sessionSvc.ListByUser(ctx, u.ID.String()); err != nil || len(active) != 0 {
+		t.Errorf("Expected no active sessions, got %d (%v)", len(active), err)
+	}
+	if _, err := sessionSvc.Refresh(ctx, laptop.RefreshToken, session.Client{}); err != session.ErrInvalidToken {
+		t.Errorf("Expected ErrInvalidToken, got %v", err)
+	}
+}
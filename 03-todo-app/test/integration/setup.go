@@ -1,22 +1,153 @@
 package integration
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/chameleon-db/chameleon-examples/todo-app/test/pgtest"
 	"github.com/chameleon-db/chameleondb/chameleon/pkg/engine"
+	"github.com/jackc/pgx/v5"
 )
 
-// setupTestEngine creates a test engine with schemas loaded
+// localPG is the disposable server of a TEST_POSTGRES=local run; nil otherwise
+var localPG *pgtest.Server
+
+// templateDB is the database on localPG holding the migrated schemas,
+// copied for every test
+var templateDB string
+
+// setupTestEngine creates a test engine with schemas loaded. With
+// TEST_POSTGRES=local it is connected to a fresh database of its own,
+// dropped when the test ends.
 func setupTestEngine(t *testing.T) *engine.Engine {
-	eng, err := engine.NewEngine()
+	if localPG == nil {
+		eng, err := engine.NewEngine()
+		if err != nil {
+			t.Fatalf("Failed to create test engine: %v", err)
+		}
+
+		return eng
+	}
+
+	ctx := context.Background()
+
+	database, err := localPG.CreateDatabase(ctx, templateDB)
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := localPG.DropDatabase(context.Background(), database); err != nil {
+			t.Errorf("Failed to drop test database: %v", err)
+		}
+	})
+
+	eng, err := newSchemaEngine()
 	if err != nil {
 		t.Fatalf("Failed to create test engine: %v", err)
 	}
 
+	config, err := engine.ParseConnectionString(localPG.URL(database))
+	if err != nil {
+		t.Fatalf("Failed to parse database URL: %v", err)
+	}
+	if err := eng.Connect(ctx, config); err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+	t.Cleanup(eng.Close)
+
 	return eng
 }
 
+// startLocalPostgres starts localPG and migrates schemas/*.cham into its
+// template database. The returned stop shuts the server down.
+func startLocalPostgres() (stop func(), err error) {
+	server, err := pgtest.Start()
+	if err != nil {
+		return nil, fmt.Errorf("start PostgreSQL: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			server.Stop()
+		}
+	}()
+
+	ctx := context.Background()
+
+	template, err := server.CreateDatabase(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("create template database: %w", err)
+	}
+
+	eng, err := newSchemaEngine()
+	if err != nil {
+		return nil, err
+	}
+
+	ddl, err := eng.GenerateMigration()
+	if err != nil {
+		return nil, fmt.Errorf("generate migration: %w", err)
+	}
+
+	conn, err := pgx.Connect(ctx, server.URL(template))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close(ctx)
+
+	if _, err := conn.Exec(ctx, ddl); err != nil {
+		return nil, fmt.Errorf("apply migration: %w", err)
+	}
+
+	localPG, templateDB = server, template
+	return func() {
+		if err := server.Stop(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to stop PostgreSQL: %v\n", err)
+		}
+	}, nil
+}
+
+// newSchemaEngine creates an unconnected engine with schemas/*.cham loaded
+func newSchemaEngine() (*engine.Engine, error) {
+	src, err := schemaSource()
+	if err != nil {
+		return nil, err
+	}
+
+	eng := engine.NewEngineWithoutSchema()
+	if _, err := eng.LoadSchemaFromString(src); err != nil {
+		return nil, fmt.Errorf("parse schemas: %w", err)
+	}
+
+	return eng, nil
+}
+
+// schemaSource concatenates schemas/*.cham
+func schemaSource() (string, error) {
+	files, err := filepath.Glob(filepath.Join("..", "..", "schemas", "*.cham"))
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 0 {
+		return "", fmt.Errorf("no schema files found")
+	}
+
+	var src strings.Builder
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return "", err
+		}
+		src.Write(content)
+		src.WriteString("\n")
+	}
+
+	return src.String(), nil
+}
+
 // TestHelper provides common test utilities
 type TestHelper struct {
 	T *testing.T
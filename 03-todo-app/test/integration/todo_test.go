@@ -2,7 +2,9 @@ package integration
 
 import (
 	"context"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/todo"
 	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/user"
@@ -14,8 +16,8 @@ func TestTodoCreate(t *testing.T) {
 	eng := setupTestEngine(t)
 	userRepo := repository.NewUserRepository(eng)
 	todoRepo := repository.NewTodoRepository(eng)
-	userSvc := user.NewService(userRepo)
-	todoSvc := todo.NewService(todoRepo)
+	userSvc := user.NewService(userRepo, repository.NewSessionRepository(eng), repository.NewPasswordResetRepository(eng), repository.NewUnitOfWork(eng))
+	todoSvc := todo.NewService(todoRepo, userRepo, repository.NewUnitOfWork(eng))
 
 	ctx := context.Background()
 
@@ -25,10 +27,10 @@ func TestTodoCreate(t *testing.T) {
 		t.Fatalf("Failed to create user: %v", err)
 	}
 
-	userID := u["id"].(string)
+	userID := u.ID.String()
 
 	// Create todo
-	t1, err := todoSvc.Create(ctx, userID, "Test Todo", "This is a test")
+	t1, err := todoSvc.Create(ctx, userID, todo.CreateInput{Title: "Test Todo", Description: "This is a test"})
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
@@ -37,31 +39,32 @@ func TestTodoCreate(t *testing.T) {
 		t.Fatal("Expected todo, got nil")
 	}
 
-	if title, ok := t1["title"].(string); !ok || title != "Test Todo" {
-		t.Errorf("Expected title 'Test Todo', got %v", t1["title"])
+	if t1.Title != "Test Todo" {
+		t.Errorf("Expected title 'Test Todo', got %v", t1.Title)
 	}
 
-	if completed, ok := t1["completed"].(bool); !ok || completed {
-		t.Errorf("Expected completed false, got %v", t1["completed"])
+	if t1.Completed {
+		t.Errorf("Expected completed false, got %v", t1.Completed)
 	}
 }
 
 // TestTodoCreateInvalidInput tests invalid input
 func TestTodoCreateInvalidInput(t *testing.T) {
 	eng := setupTestEngine(t)
+	userRepo := repository.NewUserRepository(eng)
 	todoRepo := repository.NewTodoRepository(eng)
-	todoSvc := todo.NewService(todoRepo)
+	todoSvc := todo.NewService(todoRepo, userRepo, repository.NewUnitOfWork(eng))
 
 	ctx := context.Background()
 
 	// Empty title
-	_, err := todoSvc.Create(ctx, "user-id", "", "Description")
+	_, err := todoSvc.Create(ctx, "user-id", todo.CreateInput{Description: "Description"})
 	if err != todo.ErrInvalidInput {
 		t.Errorf("Expected ErrInvalidInput, got %v", err)
 	}
 
 	// Empty user ID
-	_, err = todoSvc.Create(ctx, "", "Title", "Description")
+	_, err = todoSvc.Create(ctx, "", todo.CreateInput{Title: "Title", Description: "Description"})
 	if err != todo.ErrInvalidInput {
 		t.Errorf("Expected ErrInvalidInput, got %v", err)
 	}
@@ -72,8 +75,8 @@ func TestTodoListByUser(t *testing.T) {
 	eng := setupTestEngine(t)
 	userRepo := repository.NewUserRepository(eng)
 	todoRepo := repository.NewTodoRepository(eng)
-	userSvc := user.NewService(userRepo)
-	todoSvc := todo.NewService(todoRepo)
+	userSvc := user.NewService(userRepo, repository.NewSessionRepository(eng), repository.NewPasswordResetRepository(eng), repository.NewUnitOfWork(eng))
+	todoSvc := todo.NewService(todoRepo, userRepo, repository.NewUnitOfWork(eng))
 
 	ctx := context.Background()
 
@@ -83,12 +86,12 @@ func TestTodoListByUser(t *testing.T) {
 		t.Fatalf("Failed to create user: %v", err)
 	}
 
-	userID := u["id"].(string)
+	userID := u.ID.String()
 
 	// Create multiple todos
 	for i := 1; i <= 3; i++ {
 		title := "Todo " + string(rune(48+i))
-		_, err := todoSvc.Create(ctx, userID, title, "Description")
+		_, err := todoSvc.Create(ctx, userID, todo.CreateInput{Title: title, Description: "Description"})
 		if err != nil {
 			t.Fatalf("Failed to create todo: %v", err)
 		}
@@ -110,8 +113,8 @@ func TestTodoListByUserFiltered(t *testing.T) {
 	eng := setupTestEngine(t)
 	userRepo := repository.NewUserRepository(eng)
 	todoRepo := repository.NewTodoRepository(eng)
-	userSvc := user.NewService(userRepo)
-	todoSvc := todo.NewService(todoRepo)
+	userSvc := user.NewService(userRepo, repository.NewSessionRepository(eng), repository.NewPasswordResetRepository(eng), repository.NewUnitOfWork(eng))
+	todoSvc := todo.NewService(todoRepo, userRepo, repository.NewUnitOfWork(eng))
 
 	ctx := context.Background()
 
@@ -121,20 +124,20 @@ func TestTodoListByUserFiltered(t *testing.T) {
 		t.Fatalf("Failed to create user: %v", err)
 	}
 
-	userID := u["id"].(string)
+	userID := u.ID.String()
 
 	// Create todos
-	t1, _ := todoSvc.Create(ctx, userID, "Todo 1", "Description")
-	todoID1 := t1["id"].(string)
+	t1, _ := todoSvc.Create(ctx, userID, todo.CreateInput{Title: "Todo 1", Description: "Description"})
+	todoID1 := t1.ID.String()
 
-	_, _ = todoSvc.Create(ctx, userID, "Todo 2", "Description")
+	_, _ = todoSvc.Create(ctx, userID, todo.CreateInput{Title: "Todo 2", Description: "Description"})
 
 	// Mark first todo as complete
-	todoSvc.Update(ctx, todoID1, "Todo 1", "Description", true)
+	todoSvc.Update(ctx, userID, todoID1, todo.UpdateInput{Title: "Todo 1", Description: "Description", Completed: true})
 
 	// Filter by completed=true
 	completed := true
-	todos, err := todoSvc.ListByUserFiltered(ctx, userID, &completed, 10, 0)
+	todos, err := todoSvc.ListByUserFiltered(ctx, userID, todo.ListFilter{Completed: &completed}, 10, 0)
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
@@ -145,7 +148,7 @@ func TestTodoListByUserFiltered(t *testing.T) {
 
 	// Filter by completed=false
 	completed = false
-	todos, err = todoSvc.ListByUserFiltered(ctx, userID, &completed, 10, 0)
+	todos, err = todoSvc.ListByUserFiltered(ctx, userID, todo.ListFilter{Completed: &completed}, 10, 0)
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
@@ -160,36 +163,36 @@ func TestTodoUpdate(t *testing.T) {
 	eng := setupTestEngine(t)
 	userRepo := repository.NewUserRepository(eng)
 	todoRepo := repository.NewTodoRepository(eng)
-	userSvc := user.NewService(userRepo)
-	todoSvc := todo.NewService(todoRepo)
+	userSvc := user.NewService(userRepo, repository.NewSessionRepository(eng), repository.NewPasswordResetRepository(eng), repository.NewUnitOfWork(eng))
+	todoSvc := todo.NewService(todoRepo, userRepo, repository.NewUnitOfWork(eng))
 
 	ctx := context.Background()
 
 	// Create user and todo
 	u, _ := userSvc.Create(ctx, "test@example.com", "Test User", "password123")
-	userID := u["id"].(string)
+	userID := u.ID.String()
 
-	t1, _ := todoSvc.Create(ctx, userID, "Original Title", "Original Desc")
-	todoID := t1["id"].(string)
+	t1, _ := todoSvc.Create(ctx, userID, todo.CreateInput{Title: "Original Title", Description: "Original Desc"})
+	todoID := t1.ID.String()
 
 	// Update todo
-	err := todoSvc.Update(ctx, todoID, "Updated Title", "Updated Desc", true)
+	_, err := todoSvc.Update(ctx, userID, todoID, todo.UpdateInput{Title: "Updated Title", Description: "Updated Desc", Completed: true})
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
 
 	// Verify update
-	updated, err := todoSvc.GetByID(ctx, todoID)
+	updated, err := todoSvc.GetByID(ctx, userID, todoID)
 	if err != nil {
 		t.Fatalf("Failed to get todo: %v", err)
 	}
 
-	if title, ok := updated["title"].(string); !ok || title != "Updated Title" {
-		t.Errorf("Expected title 'Updated Title', got %v", updated["title"])
+	if updated.Title != "Updated Title" {
+		t.Errorf("Expected title 'Updated Title', got %v", updated.Title)
 	}
 
-	if completed, ok := updated["completed"].(bool); !ok || !completed {
-		t.Errorf("Expected completed true, got %v", updated["completed"])
+	if !updated.Completed {
+		t.Errorf("Expected completed true, got %v", updated.Completed)
 	}
 }
 
@@ -198,26 +201,26 @@ func TestTodoDelete(t *testing.T) {
 	eng := setupTestEngine(t)
 	userRepo := repository.NewUserRepository(eng)
 	todoRepo := repository.NewTodoRepository(eng)
-	userSvc := user.NewService(userRepo)
-	todoSvc := todo.NewService(todoRepo)
+	userSvc := user.NewService(userRepo, repository.NewSessionRepository(eng), repository.NewPasswordResetRepository(eng), repository.NewUnitOfWork(eng))
+	todoSvc := todo.NewService(todoRepo, userRepo, repository.NewUnitOfWork(eng))
 
 	ctx := context.Background()
 
 	// Create user and todo
 	u, _ := userSvc.Create(ctx, "test@example.com", "Test User", "password123")
-	userID := u["id"].(string)
+	userID := u.ID.String()
 
-	t1, _ := todoSvc.Create(ctx, userID, "Test Todo", "Description")
-	todoID := t1["id"].(string)
+	t1, _ := todoSvc.Create(ctx, userID, todo.CreateInput{Title: "Test Todo", Description: "Description"})
+	todoID := t1.ID.String()
 
 	// Delete todo
-	err := todoSvc.Delete(ctx, todoID)
+	err := todoSvc.Delete(ctx, userID, todoID, nil)
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
 
 	// Verify deletion
-	_, err = todoSvc.GetByID(ctx, todoID)
+	_, err = todoSvc.GetByID(ctx, userID, todoID)
 	if err != todo.ErrNotFound {
 		t.Errorf("Expected ErrNotFound, got %v", err)
 	}
@@ -228,34 +231,396 @@ func TestTodoToggleCompletion(t *testing.T) {
 	eng := setupTestEngine(t)
 	userRepo := repository.NewUserRepository(eng)
 	todoRepo := repository.NewTodoRepository(eng)
-	userSvc := user.NewService(userRepo)
-	todoSvc := todo.NewService(todoRepo)
+	userSvc := user.NewService(userRepo, repository.NewSessionRepository(eng), repository.NewPasswordResetRepository(eng), repository.NewUnitOfWork(eng))
+	todoSvc := todo.NewService(todoRepo, userRepo, repository.NewUnitOfWork(eng))
 
 	ctx := context.Background()
 
 	// Create user and todo
 	u, _ := userSvc.Create(ctx, "test@example.com", "Test User", "password123")
-	userID := u["id"].(string)
+	userID := u.ID.String()
 
-	t1, _ := todoSvc.Create(ctx, userID, "Test Todo", "Description")
-	todoID := t1["id"].(string)
+	t1, _ := todoSvc.Create(ctx, userID, todo.CreateInput{Title: "Test Todo", Description: "Description"})
+	todoID := t1.ID.String()
 
 	// Toggle completion
-	err := todoSvc.ToggleCompletion(ctx, todoID)
+	toggled, err := todoSvc.ToggleCompletion(ctx, userID, todoID)
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
+	if !toggled.Completed || toggled.Title != "Test Todo" {
+		t.Errorf("Expected completed todo returned, got %+v", toggled)
+	}
 
 	// Verify toggle
-	updated, _ := todoSvc.GetByID(ctx, todoID)
-	if completed, ok := updated["completed"].(bool); !ok || !completed {
-		t.Errorf("Expected completed true, got %v", updated["completed"])
+	updated, _ := todoSvc.GetByID(ctx, userID, todoID)
+	if !updated.Completed {
+		t.Errorf("Expected completed true, got %v", updated.Completed)
 	}
 
 	// Toggle again
-	todoSvc.ToggleCompletion(ctx, todoID)
-	updated, _ = todoSvc.GetByID(ctx, todoID)
-	if completed, ok := updated["completed"].(bool); !ok || completed {
-		t.Errorf("Expected completed false, got %v", updated["completed"])
+	todoSvc.ToggleCompletion(ctx, userID, todoID)
+	updated, _ = todoSvc.GetByID(ctx, userID, todoID)
+	if updated.Completed {
+		t.Errorf("Expected completed false, got %v", updated.Completed)
+	}
+}
+
+// TestTodoToggleConcurrent tests that concurrent toggles never cancel out
+// and keep edits made in between
+func TestTodoToggleConcurrent(t *testing.T) {
+	eng := setupTestEngine(t)
+	userRepo := repository.NewUserRepository(eng)
+	todoRepo := repository.NewTodoRepository(eng)
+	userSvc := user.NewService(userRepo, repository.NewSessionRepository(eng), repository.NewPasswordResetRepository(eng), repository.NewUnitOfWork(eng))
+	todoSvc := todo.NewService(todoRepo, userRepo, repository.NewUnitOfWork(eng))
+
+	ctx := context.Background()
+
+	u, _ := userSvc.Create(ctx, "test@example.com", "Test User", "password123")
+	userID := u.ID.String()
+
+	t1, _ := todoSvc.Create(ctx, userID, todo.CreateInput{Title: "Test Todo"})
+	todoID := t1.ID.String()
+
+	title := "Edited"
+	if _, err := todoSvc.Patch(ctx, userID, todoID, todo.Patch{Title: &title}); err != nil {
+		t.Fatalf("Patch: expected no error, got %v", err)
+	}
+
+	const toggles = 9
+	var wg sync.WaitGroup
+	for i := 0; i < toggles; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := todoSvc.ToggleCompletion(ctx, userID, todoID); err != nil {
+				t.Errorf("ToggleCompletion: expected no error, got %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	updated, _ := todoSvc.GetByID(ctx, userID, todoID)
+	if !updated.Completed {
+		t.Errorf("Expected completed after %d toggles, got %v", toggles, updated.Completed)
+	}
+	if updated.Title != title {
+		t.Errorf("Expected title %q kept, got %q", title, updated.Title)
+	}
+}
+
+// TestTodoOwnershipEnforced tests that a user cannot touch another user's todos
+func TestTodoOwnershipEnforced(t *testing.T) {
+	eng := setupTestEngine(t)
+	userRepo := repository.NewUserRepository(eng)
+	todoRepo := repository.NewTodoRepository(eng)
+	userSvc := user.NewService(userRepo, repository.NewSessionRepository(eng), repository.NewPasswordResetRepository(eng), repository.NewUnitOfWork(eng))
+	todoSvc := todo.NewService(todoRepo, userRepo, repository.NewUnitOfWork(eng))
+
+	ctx := context.Background()
+
+	// Create owner and intruder
+	owner, _ := userSvc.Create(ctx, "owner@example.com", "Owner", "password123")
+	ownerID := owner.ID.String()
+
+	intruder, _ := userSvc.Create(ctx, "intruder@example.com", "Intruder", "password123")
+	intruderID := intruder.ID.String()
+
+	t1, _ := todoSvc.Create(ctx, ownerID, todo.CreateInput{Title: "Private Todo", Description: "Description"})
+	todoID := t1.ID.String()
+
+	// Intruder can't read, update, toggle or delete it
+	if _, err := todoSvc.GetByID(ctx, intruderID, todoID); err != todo.ErrUnauthorized {
+		t.Errorf("GetByID: expected ErrUnauthorized, got %v", err)
+	}
+
+	if _, err := todoSvc.Update(ctx, intruderID, todoID, todo.UpdateInput{Title: "Hijacked", Completed: true}); err != todo.ErrUnauthorized {
+		t.Errorf("Update: expected ErrUnauthorized, got %v", err)
+	}
+
+	if _, err := todoSvc.ToggleCompletion(ctx, intruderID, todoID); err != todo.ErrUnauthorized {
+		t.Errorf("ToggleCompletion: expected ErrUnauthorized, got %v", err)
+	}
+
+	if err := todoSvc.Delete(ctx, intruderID, todoID, nil); err != todo.ErrUnauthorized {
+		t.Errorf("Delete: expected ErrUnauthorized, got %v", err)
+	}
+
+	// Owner still sees the original todo
+	got, err := todoSvc.GetByID(ctx, ownerID, todoID)
+	if err != nil {
+		t.Fatalf("Owner failed to get todo: %v", err)
+	}
+
+	if got.Title != "Private Todo" {
+		t.Errorf("Expected title 'Private Todo', got %v", got.Title)
+	}
+
+	if got.Completed {
+		t.Errorf("Expected completed false, got %v", got.Completed)
+	}
+
+	// Intruder's listing does not include it
+	todos, err := todoSvc.ListByUser(ctx, intruderID, 10, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(todos) != 0 {
+		t.Errorf("Expected intruder to have no todos, got %d", len(todos))
+	}
+}
+
+// TestTodoDueDates tests setting, clearing and filtering by due date
+func TestTodoDueDates(t *testing.T) {
+	eng := setupTestEngine(t)
+	userRepo := repository.NewUserRepository(eng)
+	todoRepo := repository.NewTodoRepository(eng)
+	userSvc := user.NewService(userRepo, repository.NewSessionRepository(eng), repository.NewPasswordResetRepository(eng), repository.NewUnitOfWork(eng))
+	todoSvc := todo.NewService(todoRepo, userRepo, repository.NewUnitOfWork(eng))
+
+	ctx := context.Background()
+
+	u, _ := userSvc.Create(ctx, "test@example.com", "Test User", "password123")
+	userID := u.ID.String()
+
+	now := time.Now()
+	yesterday := now.Add(-24 * time.Hour)
+	tomorrow := now.Add(24 * time.Hour)
+
+	past, err := todoSvc.Create(ctx, userID, todo.CreateInput{Title: "Past", DueDate: &yesterday})
+	if err != nil {
+		t.Fatalf("Failed to create todo: %v", err)
+	}
+
+	if past.DueDate == nil || !past.DueDate.Equal(yesterday.Truncate(time.Microsecond)) {
+		t.Errorf("Expected due date %v, got %v", yesterday, past.DueDate)
+	}
+
+	todoSvc.Create(ctx, userID, todo.CreateInput{Title: "Future", DueDate: &tomorrow})
+	todoSvc.Create(ctx, userID, todo.CreateInput{Title: "Someday"})
+
+	// Only the past todo is due before now
+	todos, err := todoSvc.ListByUserFiltered(ctx, userID, todo.ListFilter{DueBefore: &now}, 10, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(todos) != 1 || todos[0].Title != "Past" {
+		t.Errorf("Expected only 'Past' due before now, got %d todos", len(todos))
+	}
+
+	// Only the future todo is due from now on
+	todos, _ = todoSvc.ListByUserFiltered(ctx, userID, todo.ListFilter{DueAfter: &now}, 10, 0)
+	if len(todos) != 1 || todos[0].Title != "Future" {
+		t.Errorf("Expected only 'Future' due after now, got %d todos", len(todos))
+	}
+
+	// An inverted range is rejected
+	_, err = todoSvc.ListByUserFiltered(ctx, userID, todo.ListFilter{DueAfter: &tomorrow, DueBefore: &yesterday}, 10, 0)
+	if err != todo.ErrInvalidInput {
+		t.Errorf("Expected ErrInvalidInput, got %v", err)
+	}
+
+	// Updating without a due date clears it
+	_, err = todoSvc.Update(ctx, userID, past.ID.String(), todo.UpdateInput{Title: "Past"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	cleared, _ := todoSvc.GetByID(ctx, userID, past.ID.String())
+	if cleared.DueDate != nil {
+		t.Errorf("Expected due date cleared, got %v", cleared.DueDate)
+	}
+}
+
+// TestTodoGetOverdue tests overdue filtering, ordering, pagination and total
+func TestTodoGetOverdue(t *testing.T) {
+	eng := setupTestEngine(t)
+	userRepo := repository.NewUserRepository(eng)
+	todoRepo := repository.NewTodoRepository(eng)
+	userSvc := user.NewService(userRepo, repository.NewSessionRepository(eng), repository.NewPasswordResetRepository(eng), repository.NewUnitOfWork(eng))
+	todoSvc := todo.NewService(todoRepo, userRepo, repository.NewUnitOfWork(eng))
+
+	ctx := context.Background()
+
+	u, _ := userSvc.Create(ctx, "test@example.com", "Test User", "password123")
+	userID := u.ID.String()
+
+	now := time.Now()
+	for i, title := range []string{"Oldest", "Older", "Old"} {
+		due := now.Add(-time.Duration(3-i) * time.Hour)
+		todoSvc.Create(ctx, userID, todo.CreateInput{Title: title, DueDate: &due})
+	}
+
+	// Not overdue: completed, due in the future, or without due date
+	due := now.Add(-time.Hour)
+	done, _ := todoSvc.Create(ctx, userID, todo.CreateInput{Title: "Done", DueDate: &due})
+	todoSvc.Update(ctx, userID, done.ID.String(), todo.UpdateInput{Title: "Done", Completed: true, DueDate: &due})
+
+	future := now.Add(time.Hour)
+	todoSvc.Create(ctx, userID, todo.CreateInput{Title: "Future", DueDate: &future})
+	todoSvc.Create(ctx, userID, todo.CreateInput{Title: "Someday"})
+
+	page, total, err := todoSvc.GetOverdue(ctx, userID, 2, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if total != 3 {
+		t.Errorf("Expected 3 overdue todos in total, got %d", total)
+	}
+
+	if len(page) != 2 || page[0].Title != "Oldest" || page[1].Title != "Older" {
+		t.Errorf("Expected [Oldest Older], got %v", page)
+	}
+
+	page, total, _ = todoSvc.GetOverdue(ctx, userID, 2, 2)
+	if total != 3 || len(page) != 1 || page[0].Title != "Old" {
+		t.Errorf("Expected second page [Old] of 3, got %v of %d", page, total)
+	}
+}
+
+// TestTodoPatch tests partial updates and clearing nullable fields
+func TestTodoPatch(t *testing.T) {
+	eng := setupTestEngine(t)
+	userRepo := repository.NewUserRepository(eng)
+	todoRepo := repository.NewTodoRepository(eng)
+	userSvc := user.NewService(userRepo, repository.NewSessionRepository(eng), repository.NewPasswordResetRepository(eng), repository.NewUnitOfWork(eng))
+	todoSvc := todo.NewService(todoRepo, userRepo, repository.NewUnitOfWork(eng))
+
+	ctx := context.Background()
+
+	u, _ := userSvc.Create(ctx, "test@example.com", "Test User", "password123")
+	userID := u.ID.String()
+
+	due := time.Now().Add(24 * time.Hour)
+	created, _ := todoSvc.Create(ctx, userID, todo.CreateInput{Title: "Original", Description: "Old", DueDate: &due})
+	id := created.ID.String()
+
+	// Only the description changes
+	description := "New"
+	patched, err := todoSvc.Patch(ctx, userID, id, todo.Patch{Description: &description})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if patched.Title != "Original" || patched.Description == nil || *patched.Description != "New" || patched.DueDate == nil {
+		t.Errorf("Expected only description changed, got %+v", patched)
+	}
+
+	// Null clears description and due date
+	patched, err = todoSvc.Patch(ctx, userID, id, todo.Patch{ClearDescription: true, ClearDueDate: true})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if patched.Description != nil || patched.DueDate != nil {
+		t.Errorf("Expected description and due date cleared, got %+v", patched)
+	}
+
+	// An empty title is rejected
+	empty := ""
+	if _, err := todoSvc.Patch(ctx, userID, id, todo.Patch{Title: &empty}); err != todo.ErrInvalidInput {
+		t.Errorf("Expected ErrInvalidInput, got %v", err)
+	}
+}
+
+// TestTodoVersionMismatch tests that writes with a stale version are rejected
+func TestTodoVersionMismatch(t *testing.T) {
+	eng := setupTestEngine(t)
+	userRepo := repository.NewUserRepository(eng)
+	todoRepo := repository.NewTodoRepository(eng)
+	userSvc := user.NewService(userRepo, repository.NewSessionRepository(eng), repository.NewPasswordResetRepository(eng), repository.NewUnitOfWork(eng))
+	todoSvc := todo.NewService(todoRepo, userRepo, repository.NewUnitOfWork(eng))
+
+	ctx := context.Background()
+
+	u, _ := userSvc.Create(ctx, "test@example.com", "Test User", "password123")
+	userID := u.ID.String()
+
+	created, _ := todoSvc.Create(ctx, userID, todo.CreateInput{Title: "Shared"})
+	id := created.ID.String()
+	stale := created.UpdatedAt
+
+	// First tab saves with the version it read
+	_, err := todoSvc.Update(ctx, userID, id, todo.UpdateInput{Title: "Tab one", Version: &stale})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Second tab still holds the old version and is rejected
+	_, err = todoSvc.Update(ctx, userID, id, todo.UpdateInput{Title: "Tab two", Version: &stale})
+	if err != todo.ErrVersionMismatch {
+		t.Errorf("Expected ErrVersionMismatch, got %v", err)
+	}
+
+	title := "Tab two"
+	if _, err := todoSvc.Patch(ctx, userID, id, todo.Patch{Title: &title, Version: &stale}); err != todo.ErrVersionMismatch {
+		t.Errorf("Expected ErrVersionMismatch on patch, got %v", err)
+	}
+
+	if err := todoSvc.Delete(ctx, userID, id, &stale); err != todo.ErrVersionMismatch {
+		t.Errorf("Expected ErrVersionMismatch on delete, got %v", err)
+	}
+
+	// The repository enforces the version in the write itself
+	if _, err := todoRepo.Update(ctx, id, userID, todo.UpdateInput{Title: "Raced", Version: &stale}); err != todo.ErrVersionMismatch {
+		t.Errorf("Expected ErrVersionMismatch from repository, got %v", err)
+	}
+
+	current, _ := todoSvc.GetByID(ctx, userID, id)
+	if current.Title != "Tab one" {
+		t.Errorf("Expected title 'Tab one', got %v", current.Title)
+	}
+
+	// The current version is accepted
+	if err := todoSvc.Delete(ctx, userID, id, &current.UpdatedAt); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+// TestTodoWritesBumpUpdatedAt tests that updates and patches bump updated_at
+func TestTodoWritesBumpUpdatedAt(t *testing.T) {
+	eng := setupTestEngine(t)
+	userRepo := repository.NewUserRepository(eng)
+	todoRepo := repository.NewTodoRepository(eng)
+	userSvc := user.NewService(userRepo, repository.NewSessionRepository(eng), repository.NewPasswordResetRepository(eng), repository.NewUnitOfWork(eng))
+	todoSvc := todo.NewService(todoRepo, userRepo, repository.NewUnitOfWork(eng))
+
+	ctx := context.Background()
+
+	u, _ := userSvc.Create(ctx, "test@example.com", "Test User", "password123")
+	userID := u.ID.String()
+
+	created, _ := todoSvc.Create(ctx, userID, todo.CreateInput{Title: "Tracked"})
+	id := created.ID.String()
+
+	time.Sleep(time.Millisecond)
+
+	if _, err := todoSvc.Update(ctx, userID, id, todo.UpdateInput{Title: "Updated"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	updated, _ := todoSvc.GetByID(ctx, userID, id)
+	if !updated.UpdatedAt.After(created.UpdatedAt) {
+		t.Errorf("Expected updated_at to advance after update, got %v", updated.UpdatedAt)
+	}
+
+	if !updated.CreatedAt.Equal(created.CreatedAt) {
+		t.Errorf("Expected created_at unchanged, got %v", updated.CreatedAt)
+	}
+
+	time.Sleep(time.Millisecond)
+
+	completed := true
+	patched, err := todoSvc.Patch(ctx, userID, id, todo.Patch{Completed: &completed})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !patched.UpdatedAt.After(updated.UpdatedAt) {
+		t.Errorf("Expected updated_at to advance after patch, got %v", patched.UpdatedAt)
 	}
 }
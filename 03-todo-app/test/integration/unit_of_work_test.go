@@ -0,0 +1,87 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/todo"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/user"
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/repository"
+)
+
+// TestUnitOfWorkRollsBack tests that a failed unit of work leaves no trace
+// in either repository
+func TestUnitOfWorkRollsBack(t *testing.T) {
+	eng := setupTestEngine(t)
+	userRepo := repository.NewUserRepository(eng)
+	todoRepo := repository.NewTodoRepository(eng)
+	uow := repository.NewUnitOfWork(eng)
+
+	ctx := context.Background()
+	errAbort := errors.New("abort")
+
+	var userID string
+	err := uow.WithTx(ctx, func(ctx context.Context) error {
+		u, err := userRepo.Create(ctx, "test@example.com", "Test User", "hash")
+		if err != nil {
+			return err
+		}
+		userID = u.ID.String()
+
+		if _, err := todoRepo.Create(ctx, userID, todo.CreateInput{Title: "Starter"}); err != nil {
+			return err
+		}
+
+		// Reads inside the transaction see its own writes
+		todos, err := todoRepo.ListByUser(ctx, userID, 10, 0)
+		if err != nil || len(todos) != 1 {
+			t.Errorf("Expected 1 todo inside the transaction, got %d (%v)", len(todos), err)
+		}
+
+		return errAbort
+	})
+	if err != errAbort {
+		t.Fatalf("Expected errAbort, got %v", err)
+	}
+
+	if _, err := userRepo.GetByID(ctx, userID); err != user.ErrNotFound {
+		t.Errorf("Expected user rolled back, got %v", err)
+	}
+
+	todos, _ := todoRepo.ListByUser(ctx, userID, 10, 0)
+	if len(todos) != 0 {
+		t.Errorf("Expected todos rolled back, got %d", len(todos))
+	}
+}
+
+// TestUnitOfWorkCommits tests that a successful unit of work persists
+// writes from both repositories
+func TestUnitOfWorkCommits(t *testing.T) {
+	eng := setupTestEngine(t)
+	userRepo := repository.NewUserRepository(eng)
+	todoRepo := repository.NewTodoRepository(eng)
+	uow := repository.NewUnitOfWork(eng)
+
+	ctx := context.Background()
+
+	var userID string
+	err := uow.WithTx(ctx, func(ctx context.Context) error {
+		u, err := userRepo.Create(ctx, "test@example.com", "Test User", "hash")
+		if err != nil {
+			return err
+		}
+		userID = u.ID.String()
+
+		_, err = todoRepo.Create(ctx, userID, todo.CreateInput{Title: "Starter"})
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	todos, err := todoRepo.ListByUser(ctx, userID, 10, 0)
+	if err != nil || len(todos) != 1 {
+		t.Errorf("Expected 1 committed todo, got %d (%v)", len(todos), err)
+	}
+}
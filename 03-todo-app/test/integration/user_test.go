@@ -3,7 +3,9 @@ package integration
 import (
 	"context"
 	"testing"
+	"time"
 
+	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/todo"
 	"github.com/chameleon-db/chameleon-examples/todo-app/internal/domain/user"
 	"github.com/chameleon-db/chameleon-examples/todo-app/internal/repository"
 )
@@ -13,7 +15,7 @@ func TestUserCreate(t *testing.T) {
 	// Setup
 	eng := setupTestEngine(t)
 	repo := repository.NewUserRepository(eng)
-	svc := user.NewService(repo)
+	svc := user.NewService(repo, repository.NewSessionRepository(eng), repository.NewPasswordResetRepository(eng), repository.NewUnitOfWork(eng))
 
 	// Test
 	ctx := context.Background()
@@ -28,12 +30,12 @@ func TestUserCreate(t *testing.T) {
 		t.Fatal("Expected user, got nil")
 	}
 
-	if email, ok := u["email"].(string); !ok || email != "test@example.com" {
-		t.Errorf("Expected email test@example.com, got %v", u["email"])
+	if u.Email != "test@example.com" {
+		t.Errorf("Expected email test@example.com, got %v", u.Email)
 	}
 
-	if _, ok := u["password_hash"]; ok {
-		t.Error("Password hash should not be returned")
+	if !u.IsActive {
+		t.Error("Expected new user to be active")
 	}
 }
 
@@ -41,7 +43,7 @@ func TestUserCreate(t *testing.T) {
 func TestUserCreateInvalidInput(t *testing.T) {
 	eng := setupTestEngine(t)
 	repo := repository.NewUserRepository(eng)
-	svc := user.NewService(repo)
+	svc := user.NewService(repo, repository.NewSessionRepository(eng), repository.NewPasswordResetRepository(eng), repository.NewUnitOfWork(eng))
 
 	ctx := context.Background()
 
@@ -58,11 +60,29 @@ func TestUserCreateInvalidInput(t *testing.T) {
 	}
 }
 
+// TestUserCreateDuplicateEmail tests that a taken email maps to ErrDuplicateEmail
+func TestUserCreateDuplicateEmail(t *testing.T) {
+	eng := setupTestEngine(t)
+	repo := repository.NewUserRepository(eng)
+	svc := user.NewService(repo, repository.NewSessionRepository(eng), repository.NewPasswordResetRepository(eng), repository.NewUnitOfWork(eng))
+
+	ctx := context.Background()
+
+	if _, err := svc.Create(ctx, "test@example.com", "Test User", "password123"); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	_, err := svc.Create(ctx, "test@example.com", "Someone Else", "password456")
+	if err != user.ErrDuplicateEmail {
+		t.Errorf("Expected ErrDuplicateEmail, got %v", err)
+	}
+}
+
 // TestUserGetByEmail tests getting user by email
 func TestUserGetByEmail(t *testing.T) {
 	eng := setupTestEngine(t)
 	repo := repository.NewUserRepository(eng)
-	svc := user.NewService(repo)
+	svc := user.NewService(repo, repository.NewSessionRepository(eng), repository.NewPasswordResetRepository(eng), repository.NewUnitOfWork(eng))
 
 	ctx := context.Background()
 
@@ -82,8 +102,8 @@ func TestUserGetByEmail(t *testing.T) {
 		t.Fatal("Expected user, got nil")
 	}
 
-	if email, ok := u["email"].(string); !ok || email != "test@example.com" {
-		t.Errorf("Expected email test@example.com, got %v", u["email"])
+	if u.Email != "test@example.com" {
+		t.Errorf("Expected email test@example.com, got %v", u.Email)
 	}
 }
 
@@ -91,7 +111,7 @@ func TestUserGetByEmail(t *testing.T) {
 func TestUserGetByEmailNotFound(t *testing.T) {
 	eng := setupTestEngine(t)
 	repo := repository.NewUserRepository(eng)
-	svc := user.NewService(repo)
+	svc := user.NewService(repo, repository.NewSessionRepository(eng), repository.NewPasswordResetRepository(eng), repository.NewUnitOfWork(eng))
 
 	ctx := context.Background()
 
@@ -109,7 +129,7 @@ func TestUserGetByEmailNotFound(t *testing.T) {
 func TestUserVerifyPassword(t *testing.T) {
 	eng := setupTestEngine(t)
 	repo := repository.NewUserRepository(eng)
-	svc := user.NewService(repo)
+	svc := user.NewService(repo, repository.NewSessionRepository(eng), repository.NewPasswordResetRepository(eng), repository.NewUnitOfWork(eng))
 
 	ctx := context.Background()
 
@@ -137,10 +157,39 @@ func TestUserVerifyPassword(t *testing.T) {
 }
 
 // TestUserList tests listing users
+func TestUserChangePassword(t *testing.T) {
+	eng := setupTestEngine(t)
+	repo := repository.NewUserRepository(eng)
+	svc := user.NewService(repo, repository.NewSessionRepository(eng), repository.NewPasswordResetRepository(eng), repository.NewUnitOfWork(eng))
+
+	ctx := context.Background()
+
+	u, err := svc.Create(ctx, "test@example.com", "Test User", "password123")
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	// Wrong current password
+	if err := svc.ChangePassword(ctx, u.ID.String(), "wrongpassword", "newpassword"); err != user.ErrIncorrectPassword {
+		t.Errorf("Expected ErrIncorrectPassword, got %v", err)
+	}
+
+	if err := svc.ChangePassword(ctx, u.ID.String(), "password123", "newpassword"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if _, err := svc.VerifyPassword(ctx, "test@example.com", "newpassword"); err != nil {
+		t.Errorf("Expected the new password to verify, got %v", err)
+	}
+	if _, err := svc.VerifyPassword(ctx, "test@example.com", "password123"); err != user.ErrInvalidPassword {
+		t.Errorf("Expected the old password to fail, got %v", err)
+	}
+}
+
 func TestUserList(t *testing.T) {
 	eng := setupTestEngine(t)
 	repo := repository.NewUserRepository(eng)
-	svc := user.NewService(repo)
+	svc := user.NewService(repo, repository.NewSessionRepository(eng), repository.NewPasswordResetRepository(eng), repository.NewUnitOfWork(eng))
 
 	ctx := context.Background()
 
@@ -168,7 +217,7 @@ func TestUserList(t *testing.T) {
 func TestUserUpdate(t *testing.T) {
 	eng := setupTestEngine(t)
 	repo := repository.NewUserRepository(eng)
-	svc := user.NewService(repo)
+	svc := user.NewService(repo, repository.NewSessionRepository(eng), repository.NewPasswordResetRepository(eng), repository.NewUnitOfWork(eng))
 
 	ctx := context.Background()
 
@@ -178,10 +227,10 @@ func TestUserUpdate(t *testing.T) {
 		t.Fatalf("Failed to create user: %v", err)
 	}
 
-	userID := u["id"].(string)
+	userID := u.ID.String()
 
 	// Update user
-	err = svc.Update(ctx, userID, "Updated Name")
+	_, err = svc.Update(ctx, userID, "Updated Name", nil)
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
@@ -192,8 +241,33 @@ func TestUserUpdate(t *testing.T) {
 		t.Fatalf("Failed to get user: %v", err)
 	}
 
-	if name, ok := updated["name"].(string); !ok || name != "Updated Name" {
-		t.Errorf("Expected name 'Updated Name', got %v", updated["name"])
+	if updated.Name != "Updated Name" {
+		t.Errorf("Expected name 'Updated Name', got %v", updated.Name)
+	}
+}
+
+// TestUserUpdateDeleted tests that a soft-deleted user cannot be renamed
+func TestUserUpdateDeleted(t *testing.T) {
+	eng := setupTestEngine(t)
+	repo := repository.NewUserRepository(eng)
+	svc := user.NewService(repo, repository.NewSessionRepository(eng), repository.NewPasswordResetRepository(eng), repository.NewUnitOfWork(eng))
+
+	ctx := context.Background()
+
+	u, err := svc.Create(ctx, "test@example.com", "Test User", "password123")
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	userID := u.ID.String()
+
+	if err := svc.Delete(ctx, userID, nil); err != nil {
+		t.Fatalf("Failed to delete user: %v", err)
+	}
+
+	_, err = svc.Update(ctx, userID, "Updated Name", nil)
+	if err != user.ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
 	}
 }
 
@@ -201,7 +275,7 @@ func TestUserUpdate(t *testing.T) {
 func TestUserDelete(t *testing.T) {
 	eng := setupTestEngine(t)
 	repo := repository.NewUserRepository(eng)
-	svc := user.NewService(repo)
+	svc := user.NewService(repo, repository.NewSessionRepository(eng), repository.NewPasswordResetRepository(eng), repository.NewUnitOfWork(eng))
 
 	ctx := context.Background()
 
@@ -211,10 +285,10 @@ func TestUserDelete(t *testing.T) {
 		t.Fatalf("Failed to create user: %v", err)
 	}
 
-	userID := u["id"].(string)
+	userID := u.ID.String()
 
 	// Delete user
-	err = svc.Delete(ctx, userID)
+	err = svc.Delete(ctx, userID, nil)
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
@@ -225,3 +299,112 @@ func TestUserDelete(t *testing.T) {
 		t.Errorf("Expected ErrNotFound, got %v", err)
 	}
 }
+
+// TestUserWritesBumpUpdatedAt tests that updates and soft-deletes bump updated_at
+func TestUserWritesBumpUpdatedAt(t *testing.T) {
+	eng := setupTestEngine(t)
+	repo := repository.NewUserRepository(eng)
+	svc := user.NewService(repo, repository.NewSessionRepository(eng), repository.NewPasswordResetRepository(eng), repository.NewUnitOfWork(eng))
+
+	ctx := context.Background()
+
+	u, err := svc.Create(ctx, "test@example.com", "Test User", "password123")
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	userID := u.ID.String()
+
+	if !u.UpdatedAt.Equal(u.CreatedAt) {
+		t.Errorf("Expected updated_at %v to equal created_at %v", u.UpdatedAt, u.CreatedAt)
+	}
+
+	time.Sleep(time.Millisecond)
+
+	if _, err := svc.Update(ctx, userID, "Renamed", nil); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	updated, _ := svc.GetByID(ctx, userID)
+	if !updated.UpdatedAt.After(u.UpdatedAt) {
+		t.Errorf("Expected updated_at to advance after update, got %v", updated.UpdatedAt)
+	}
+
+	time.Sleep(time.Millisecond)
+
+	if err := svc.Delete(ctx, userID, nil); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Soft-deleted users are hidden from the repository, so read the row directly
+	result, err := eng.Query("User").Filter("id", "eq", userID).Execute(ctx)
+	if err != nil || result.IsEmpty() {
+		t.Fatalf("Failed to read deleted user: %v", err)
+	}
+
+	deletedAt, ok := result.Rows[0]["updated_at"].(time.Time)
+	if !ok || !deletedAt.After(updated.UpdatedAt) {
+		t.Errorf("Expected updated_at to advance after soft-delete, got %v", result.Rows[0]["updated_at"])
+	}
+}
+
+// TestUserDeleteCascadesToTodos tests that deactivation hides the user's
+// todos, blocks new todos and login, and that restore brings everything back
+func TestUserDeleteCascadesToTodos(t *testing.T) {
+	eng := setupTestEngine(t)
+	userRepo := repository.NewUserRepository(eng)
+	todoRepo := repository.NewTodoRepository(eng)
+	userSvc := user.NewService(userRepo, repository.NewSessionRepository(eng), repository.NewPasswordResetRepository(eng), repository.NewUnitOfWork(eng))
+	todoSvc := todo.NewService(todoRepo, userRepo, repository.NewUnitOfWork(eng))
+
+	ctx := context.Background()
+
+	u, _ := userSvc.Create(ctx, "test@example.com", "Test User", "password123")
+	userID := u.ID.String()
+
+	kept, _ := todoSvc.Create(ctx, userID, todo.CreateInput{Title: "Kept"})
+
+	if err := userSvc.Delete(ctx, userID, nil); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Todos are hidden from every read path
+	if _, err := todoSvc.GetByID(ctx, userID, kept.ID.String()); err != todo.ErrNotFound {
+		t.Errorf("Expected ErrNotFound for archived todo, got %v", err)
+	}
+
+	todos, _ := todoSvc.ListByUser(ctx, userID, 10, 0)
+	if len(todos) != 0 {
+		t.Errorf("Expected no todos for deleted user, got %d", len(todos))
+	}
+
+	// New todos and login are rejected
+	if _, err := todoSvc.Create(ctx, userID, todo.CreateInput{Title: "Rejected"}); err != todo.ErrOwnerInactive {
+		t.Errorf("Expected ErrOwnerInactive, got %v", err)
+	}
+
+	if _, err := userSvc.VerifyPassword(ctx, "test@example.com", "password123"); err == nil {
+		t.Error("Expected login to fail for deleted user")
+	}
+
+	// Deleting twice reports the user as gone
+	if err := userSvc.Delete(ctx, userID, nil); err != user.ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	// Restore brings back both the user and their todos
+	if err := userSvc.Restore(ctx, userID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if _, err := todoSvc.GetByID(ctx, userID, kept.ID.String()); err != nil {
+		t.Errorf("Expected restored todo, got %v", err)
+	}
+
+	if _, err := userSvc.VerifyPassword(ctx, "test@example.com", "password123"); err != nil {
+		t.Errorf("Expected login to succeed after restore, got %v", err)
+	}
+
+	if err := userSvc.Restore(ctx, userID); err != user.ErrNotFound {
+		t.Errorf("Expected ErrNotFound restoring an active user, got %v", err)
+	}
+}
@@ -0,0 +1,162 @@
+// Package pgtest runs a disposable PostgreSQL server for tests.
+//
+// The server is created with the initdb and pg_ctl binaries already on
+// PATH (or in $PG_BIN) inside a temporary directory, listens on a free
+// loopback port and is thrown away by Stop. Nothing is downloaded and no
+// container is needed, so tests using it run on any Linux box with
+// PostgreSQL installed. PostgreSQL refuses to run as root.
+package pgtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// EnvVar opts a test run into a local server when set to "local"
+const EnvVar = "TEST_POSTGRES"
+
+// Enabled reports whether the run opted into a local server
+func Enabled() bool {
+	return os.Getenv(EnvVar) == "local"
+}
+
+// superuser is the role initdb creates; authentication is trust-only
+const superuser = "postgres"
+
+// Server is a running disposable PostgreSQL server
+type Server struct {
+	dir  string
+	bin  string
+	port int
+	seq  atomic.Int64 // database name counter
+}
+
+// Start initializes a cluster in a new temporary directory and starts it
+func Start() (*Server, error) {
+	bin, err := binDir()
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "pgtest-")
+	if err != nil {
+		return nil, err
+	}
+
+	port, err := freePort()
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	s := &Server{dir: dir, bin: bin, port: port}
+
+	if err := s.run("initdb", "-D", s.dataDir(), "-U", superuser, "-A", "trust", "-E", "UTF8", "--no-sync"); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	options := fmt.Sprintf("-p %d -k %s -c listen_addresses=127.0.0.1 -c fsync=off -c full_page_writes=off", port, dir)
+	if err := s.run("pg_ctl", "-D", s.dataDir(), "-l", filepath.Join(dir, "postgres.log"), "-o", options, "-w", "start"); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Stop shuts the server down and deletes its data
+func (s *Server) Stop() error {
+	err := s.run("pg_ctl", "-D", s.dataDir(), "-m", "immediate", "-w", "stop")
+	if rmErr := os.RemoveAll(s.dir); err == nil {
+		err = rmErr
+	}
+	return err
+}
+
+// URL returns the connection URL of database on the server
+func (s *Server) URL(database string) string {
+	return fmt.Sprintf("postgresql://%s@127.0.0.1:%d/%s?sslmode=disable", superuser, s.port, database)
+}
+
+// CreateDatabase creates a new, uniquely named database, copied from
+// template when it is not empty, and returns its name
+func (s *Server) CreateDatabase(ctx context.Context, template string) (string, error) {
+	name := "test_" + strconv.FormatInt(s.seq.Add(1), 10)
+
+	sql := "CREATE DATABASE " + pgx.Identifier{name}.Sanitize()
+	if template != "" {
+		sql += " TEMPLATE " + pgx.Identifier{template}.Sanitize()
+	}
+
+	if err := s.exec(ctx, sql); err != nil {
+		return "", err
+	}
+
+	return name, nil
+}
+
+// DropDatabase drops database, disconnecting any remaining sessions
+func (s *Server) DropDatabase(ctx context.Context, database string) error {
+	return s.exec(ctx, "DROP DATABASE IF EXISTS "+pgx.Identifier{database}.Sanitize()+" WITH (FORCE)")
+}
+
+// exec runs sql on the maintenance database
+func (s *Server) exec(ctx context.Context, sql string) error {
+	conn, err := pgx.Connect(ctx, s.URL("postgres"))
+	if err != nil {
+		return err
+	}
+	defer conn.Close(ctx)
+
+	_, err = conn.Exec(ctx, sql)
+	return err
+}
+
+func (s *Server) dataDir() string {
+	return filepath.Join(s.dir, "data")
+}
+
+// run runs a PostgreSQL binary, reporting its output on failure
+func (s *Server) run(name string, args ...string) error {
+	out, err := exec.Command(filepath.Join(s.bin, name), args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s: %w: %s", name, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// binDir finds the directory holding initdb and pg_ctl
+func binDir() (string, error) {
+	if dir := os.Getenv("PG_BIN"); dir != "" {
+		return dir, nil
+	}
+
+	initdb, err := exec.LookPath("initdb")
+	if err != nil {
+		return "", errors.New("initdb not found on PATH; install PostgreSQL or set PG_BIN")
+	}
+
+	return filepath.Dir(initdb), nil
+}
+
+// freePort returns a loopback TCP port that is free right now
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
@@ -0,0 +1,41 @@
+package pgtest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func TestServer(t *testing.T) {
+	if !Enabled() {
+		t.Skip("set " + EnvVar + "=local to run against a local PostgreSQL")
+	}
+
+	s, err := Start()
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer s.Stop()
+
+	ctx := context.Background()
+	database, err := s.CreateDatabase(ctx, "")
+	if err != nil {
+		t.Fatalf("CreateDatabase: %v", err)
+	}
+
+	conn, err := pgx.Connect(ctx, s.URL(database))
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+
+	var one int
+	if err := conn.QueryRow(ctx, "SELECT 1").Scan(&one); err != nil || one != 1 {
+		t.Errorf("Expected SELECT 1 to return 1, got %d (%v)", one, err)
+	}
+	conn.Close(ctx)
+
+	if err := s.DropDatabase(ctx, database); err != nil {
+		t.Errorf("DropDatabase: %v", err)
+	}
+}
@@ -52,6 +52,11 @@ async function apiRequest(endpoint, method = 'GET', body = null) {
         'Content-Type': 'application/json',
     };
 
+    // Adjuntar el token de acceso si hay sesión
+    if (currentUser?.token) {
+        headers['Authorization'] = `Bearer ${currentUser.token}`;
+    }
+
     const options = {
         method,
         headers,
@@ -74,6 +79,11 @@ async function apiRequest(endpoint, method = 'GET', body = null) {
             data = await response.text();
         }
         
+        // Token vencido o inválido: volver al login
+        if (response.status === 401 && currentUser) {
+            logout();
+        }
+
         if (!response.ok) {
             const errorMsg = data?.error || data?.message || data || 'Error en la solicitud';
             throw new Error(errorMsg);
@@ -98,16 +108,18 @@ async function handleLogin(email, password) {
         
         console.log('📤 Enviando login:', { email: loginData.email });
         
-        const user = await apiRequest('/login', 'POST', loginData);
+        const session = await apiRequest('/login', 'POST', loginData);
+        const user = session?.user;
         const userId = normalizeUuid(user?.id);
         
-        if (!user || !userId || typeof userId !== 'string') {
+        if (!user || !userId || typeof userId !== 'string' || !session.access_token) {
             throw new Error('Respuesta inválida del servidor');
         }
         
         currentUser = {
             id: userId,
             email: user.email,
+            token: session.access_token,
         };
         
         localStorage.setItem('user', JSON.stringify(currentUser));
@@ -265,7 +277,7 @@ function initFromStorage() {
             currentUser = JSON.parse(storedUser);
             const normalizedId = normalizeUuid(currentUser?.id);
 
-            if (currentUser && normalizedId && typeof normalizedId === 'string') {
+            if (currentUser && currentUser.token && normalizedId && typeof normalizedId === 'string') {
                 currentUser.id = normalizedId;
                 localStorage.setItem('user', JSON.stringify(currentUser));
                 loginCard.style.display = 'none';